@@ -5,9 +5,12 @@ import (
 	"path/filepath"
 	"strconv"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/data"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/scheduler"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -23,6 +26,9 @@ func Init() {
 }
 
 func Release() {
+	scheduler.Stop()
+	webhook.Stop()
+	audit.Stop()
 	db.Close()
 }
 
@@ -0,0 +1,61 @@
+//go:build fuse
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fuse"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// MountCmd represents the mount command
+var MountCmd = &cobra.Command{
+	Use:   "mount [src] [dst]",
+	Short: "Mount a path of the storage tree to a local directory with FUSE",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		bootstrap.LoadStorages()
+		bootstrap.InitTaskManager()
+		bootstrap.InitAudit()
+		bootstrap.InitWebhook()
+		username, _ := cmd.Flags().GetString("user")
+		user, err := op.GetAdmin()
+		if username != "" {
+			user, err = op.GetUserByName(username)
+		}
+		if err != nil {
+			return fmt.Errorf("failed get user: %+v", err)
+		}
+		src, err := user.JoinPath(args[0])
+		if err != nil {
+			return err
+		}
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer cancel()
+		ctx = context.WithValue(ctx, conf.UserKey, user)
+		ctx = context.WithValue(ctx, conf.MetaPassKey, "")
+		ctx = context.WithValue(ctx, conf.ProtocolKey, "fuse")
+		opts, _ := cmd.Flags().GetStringArray("option")
+		utils.Log.Infof("mount %s to %s as %s", src, args[1], user.Username)
+		if !fuse.Mount(ctx, src, args[1], opts) {
+			return fmt.Errorf("failed to mount %s", args[1])
+		}
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MountCmd)
+	MountCmd.Flags().String("user", "", "mount as the given user with its base path and permissions, defaults to the admin user")
+	MountCmd.Flags().StringArrayP("option", "o", nil, "extra options passed to the fuse library")
+}
@@ -0,0 +1,22 @@
+//go:build !fuse
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// MountCmd represents the mount command, FUSE support needs libfuse at build time
+var MountCmd = &cobra.Command{
+	Use:   "mount [src] [dst]",
+	Short: "Mount a path of the storage tree to a local directory with FUSE",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fmt.Errorf("this binary was built without FUSE support, rebuild it with `-tags fuse`")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MountCmd)
+}
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
@@ -180,6 +182,53 @@ var listStorageCmd = &cobra.Command{
 	},
 }
 
+var rotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "Re-encrypt the confidential fields of the storages and the s3 secrets with a new encryption key",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		newKey, _ := cmd.Flags().GetString("new-key")
+		newKeyFile, _ := cmd.Flags().GetString("new-key-file")
+		decrypt, _ := cmd.Flags().GetBool("decrypt")
+		if newKeyFile != "" {
+			data, err := os.ReadFile(newKeyFile)
+			if err != nil {
+				return fmt.Errorf("failed to read new key file: %+v", err)
+			}
+			newKey = strings.TrimSpace(string(data))
+		}
+		if newKey == "" && !decrypt {
+			return fmt.Errorf("new key is required, or use --decrypt to store the fields in plain text")
+		}
+		if newKey != "" && decrypt {
+			return fmt.Errorf("new key can't be used with --decrypt")
+		}
+		Init()
+		defer Release()
+		key, err := op.NewEncryptionKey(newKey)
+		if err != nil {
+			return fmt.Errorf("failed to init new key: %+v", err)
+		}
+		count, err := op.ReencryptStorages(key)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt storages: %+v", err)
+		}
+		utils.Log.Infof("%d storages have been re-encrypted from CLI", count)
+		fmt.Printf("%d storages have been re-encrypted\n", count)
+		count, err = op.ReencryptS3Secrets(key)
+		if err != nil {
+			return fmt.Errorf("failed to re-encrypt s3 secrets: %+v", err)
+		}
+		utils.Log.Infof("%d s3 secrets have been re-encrypted from CLI", count)
+		fmt.Printf("%d s3 secrets have been re-encrypted\n", count)
+		if decrypt {
+			fmt.Println("Please remove encryption_key and encryption_key_file from the config and restart")
+		} else {
+			fmt.Println("Please set the new key as encryption_key or in encryption_key_file of the config and restart")
+		}
+		return nil
+	},
+}
+
 func init() {
 
 	RootCmd.AddCommand(storageCmd)
@@ -188,6 +237,10 @@ func init() {
 	storageCmd.PersistentFlags().IntVarP(&storageTableHeight, "height", "H", 10, "Table height")
 	storageCmd.AddCommand(deleteStorageCmd)
 	deleteStorageCmd.Flags().BoolP("force", "f", false, "Force delete without confirmation")
+	storageCmd.AddCommand(rotateKeyCmd)
+	rotateKeyCmd.Flags().String("new-key", "", "New encryption key of the confidential fields")
+	rotateKeyCmd.Flags().String("new-key-file", "", "File containing the new encryption key")
+	rotateKeyCmd.Flags().Bool("decrypt", false, "Decrypt the confidential fields instead of re-encrypting them")
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
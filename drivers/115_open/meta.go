@@ -12,8 +12,8 @@ type Addition struct {
 	OrderBy        string  `json:"order_by" type:"select" options:"file_name,file_size,user_utime,file_type"`
 	OrderDirection string  `json:"order_direction" type:"select" options:"asc,desc"`
 	LimitRate      float64 `json:"limit_rate" type:"float" default:"1" help:"limit all api request rate ([limit]r/1s)"`
-	AccessToken    string  `json:"access_token" required:"true"`
-	RefreshToken   string  `json:"refresh_token" required:"true"`
+	AccessToken    string  `json:"access_token" confidential:"true" required:"true"`
+	RefreshToken   string  `json:"refresh_token" confidential:"true" required:"true"`
 }
 
 var config = driver.Config{
@@ -183,5 +183,18 @@ func (d *Open123) Put(ctx context.Context, dstDir model.Obj, file model.FileStre
 	return nil, fmt.Errorf("upload complete timeout")
 }
 
+func (d *Open123) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	userInfo, err := d.getUserInfo()
+	if err != nil {
+		return nil, err
+	}
+	// the temporary space is added to the permanent one until it expires
+	return &model.StorageDetails{
+		TotalSpace: userInfo.Data.SpacePermanent + userInfo.Data.SpaceTemp,
+		UsedSpace:  userInfo.Data.SpaceUsed,
+	}, nil
+}
+
 var _ driver.Driver = (*Open123)(nil)
 var _ driver.PutResult = (*Open123)(nil)
+var _ driver.WithDetails = (*Open123)(nil)
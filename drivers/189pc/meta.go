@@ -7,7 +7,7 @@ import (
 
 type Addition struct {
 	Username string `json:"username" required:"true"`
-	Password string `json:"password" required:"true"`
+	Password string `json:"password" confidential:"true" required:"true"`
 	VCode    string `json:"validate_code"`
 	driver.RootID
 	OrderBy        string `json:"order_by" type:"select" options:"filename,filesize,lastOpTime" default:"filename"`
@@ -291,9 +291,21 @@ func (d *AliyundriveOpen) Other(ctx context.Context, args model.OtherArgs) (inte
 	return resp, nil
 }
 
+func (d *AliyundriveOpen) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	res, err := d.request(ctx, limiterOther, "/adrive/v1.0/user/getSpaceInfo", http.MethodPost, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageDetails{
+		TotalSpace: utils.Json.Get(res, "personal_space_info", "total_size").ToInt64(),
+		UsedSpace:  utils.Json.Get(res, "personal_space_info", "used_size").ToInt64(),
+	}, nil
+}
+
 var _ driver.Driver = (*AliyundriveOpen)(nil)
 var _ driver.MkdirResult = (*AliyundriveOpen)(nil)
 var _ driver.MoveResult = (*AliyundriveOpen)(nil)
 var _ driver.RenameResult = (*AliyundriveOpen)(nil)
 var _ driver.PutResult = (*AliyundriveOpen)(nil)
 var _ driver.GetRooter = (*AliyundriveOpen)(nil)
+var _ driver.WithDetails = (*AliyundriveOpen)(nil)
@@ -6,7 +6,7 @@ import (
 )
 
 type Addition struct {
-	RefreshToken string `json:"refresh_token" required:"true"`
+	RefreshToken string `json:"refresh_token" confidential:"true" required:"true"`
 	ShareId      string `json:"share_id" required:"true"`
 	SharePwd     string `json:"share_pwd"`
 	driver.RootID
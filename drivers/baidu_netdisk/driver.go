@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
 	stdpath "path"
@@ -20,6 +21,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/pkg/errgroup"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/avast/retry-go"
+	"github.com/go-resty/resty/v2"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -364,4 +366,18 @@ func (d *BaiduNetdisk) uploadSlice(ctx context.Context, params map[string]string
 	return nil
 }
 
+func (d *BaiduNetdisk) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	res, err := d.request("https://pan.baidu.com/api/quota", http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx).SetQueryParam("checkfree", "1")
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageDetails{
+		TotalSpace: utils.Json.Get(res, "total").ToInt64(),
+		UsedSpace:  utils.Json.Get(res, "used").ToInt64(),
+	}, nil
+}
+
 var _ driver.Driver = (*BaiduNetdisk)(nil)
+var _ driver.WithDetails = (*BaiduNetdisk)(nil)
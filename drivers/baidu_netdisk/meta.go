@@ -13,10 +13,10 @@ type Addition struct {
 	UseOnlineAPI          bool   `json:"use_online_api" default:"true"`
 	APIAddress            string `json:"api_url_address" default:"https://api.oplist.org/baiduyun/renewapi"`
 	ClientID              string `json:"client_id"`
-	ClientSecret          string `json:"client_secret"`
+	ClientSecret          string `json:"client_secret" confidential:"true"`
 	CustomCrackUA         string `json:"custom_crack_ua" required:"true" default:"netdisk"`
 	AccessToken           string
-	RefreshToken          string `json:"refresh_token" required:"true"`
+	RefreshToken          string `json:"refresh_token" confidential:"true" required:"true"`
 	UploadThread          string `json:"upload_thread" default:"3" help:"1<=thread<=32"`
 	UploadAPI             string `json:"upload_api" default:"https://d.pcs.baidu.com"`
 	CustomUploadPartSize  int64  `json:"custom_upload_part_size" type:"number" default:"0" help:"0 for auto"`
@@ -11,12 +11,12 @@ import (
 type Addition struct {
 	// 超星用户名及密码
 	UserName string `json:"user_name" required:"true"`
-	Password string `json:"password" required:"true"`
+	Password string `json:"password" confidential:"true" required:"true"`
 	// 从自己新建的小组url里获取
 	Bbsid string `json:"bbsid" required:"true"`
 	driver.RootID
 	// 可不填，程序会自动登录获取
-	Cookie string `json:"cookie"`
+	Cookie string `json:"cookie" confidential:"true"`
 }
 
 type Conf struct {
@@ -12,9 +12,9 @@ type Addition struct {
 	// define other
 	Address             string `json:"address" required:"true"`
 	Username            string `json:"username"`
-	Password            string `json:"password"`
-	AccessToken         string `json:"access_token"`
-	RefreshToken        string `json:"refresh_token"`
+	Password            string `json:"password" confidential:"true"`
+	AccessToken         string `json:"access_token" confidential:"true"`
+	RefreshToken        string `json:"refresh_token" confidential:"true"`
 	CustomUA            string `json:"custom_ua"`
 	EnableFolderSize    bool   `json:"enable_folder_size"`
 	EnableThumb         bool   `json:"enable_thumb"`
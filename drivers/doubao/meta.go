@@ -10,7 +10,7 @@ type Addition struct {
 	// driver.RootPath
 	driver.RootID
 	// define other
-	Cookie       string `json:"cookie" type:"text"`
+	Cookie       string `json:"cookie" confidential:"true" type:"text"`
 	UploadThread string `json:"upload_thread" default:"3"`
 	DownloadApi  string `json:"download_api" type:"select" options:"get_file_url,get_download_info" default:"get_file_url"`
 }
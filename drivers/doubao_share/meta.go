@@ -7,7 +7,7 @@ import (
 
 type Addition struct {
 	driver.RootPath
-	Cookie   string `json:"cookie" type:"text"`
+	Cookie   string `json:"cookie" confidential:"true" type:"text"`
 	ShareIds string `json:"share_ids" type:"text" required:"true"`
 }
 
@@ -231,4 +231,19 @@ func (d *Dropbox) Put(ctx context.Context, dstDir model.Obj, stream model.FileSt
 	return err
 }
 
+func (d *Dropbox) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	res, err := d.request("/2/users/get_space_usage", http.MethodPost, func(req *resty.Request) {
+		req.SetContext(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the allocation is the one of the account or the whole team
+	return &model.StorageDetails{
+		TotalSpace: utils.Json.Get(res, "allocation", "allocated").ToInt64(),
+		UsedSpace:  utils.Json.Get(res, "used").ToInt64(),
+	}, nil
+}
+
 var _ driver.Driver = (*Dropbox)(nil)
+var _ driver.WithDetails = (*Dropbox)(nil)
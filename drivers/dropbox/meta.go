@@ -10,9 +10,9 @@ type Addition struct {
 	UseOnlineAPI    bool   `json:"use_online_api" default:"false"`
 	APIAddress      string `json:"api_url_address" default:"https://api.oplist.org/dropboxs/renewapi"`
 	ClientID        string `json:"client_id" required:"false" help:"Keep it empty if you don't have one"`
-	ClientSecret    string `json:"client_secret" required:"false" help:"Keep it empty if you don't have one"`
+	ClientSecret    string `json:"client_secret" confidential:"true" required:"false" help:"Keep it empty if you don't have one"`
 	AccessToken     string
-	RefreshToken    string `json:"refresh_token" required:"true"`
+	RefreshToken    string `json:"refresh_token" confidential:"true" required:"true"`
 	RootNamespaceId string
 }
 
@@ -7,13 +7,13 @@ import (
 
 type Addition struct {
 	driver.RootPath
-	Token            string `json:"token" type:"string" required:"true"`
+	Token            string `json:"token" confidential:"true" type:"string" required:"true"`
 	Owner            string `json:"owner" type:"string" required:"true"`
 	Repo             string `json:"repo" type:"string" required:"true"`
 	Ref              string `json:"ref" type:"string" help:"A branch, a tag or a commit SHA, main branch by default."`
 	GitHubProxy      string `json:"gh_proxy" type:"string" help:"GitHub proxy, e.g. https://ghproxy.net/raw.githubusercontent.com or https://gh-proxy.com/raw.githubusercontent.com"`
-	GPGPrivateKey    string `json:"gpg_private_key" type:"text"`
-	GPGKeyPassphrase string `json:"gpg_key_passphrase" type:"string"`
+	GPGPrivateKey    string `json:"gpg_private_key" confidential:"true" type:"text"`
+	GPGKeyPassphrase string `json:"gpg_key_passphrase" confidential:"true" type:"string"`
 	CommitterName    string `json:"committer_name" type:"string"`
 	CommitterEmail   string `json:"committer_email" type:"string"`
 	AuthorName       string `json:"author_name" type:"string"`
@@ -167,4 +167,21 @@ func (d *GoogleDrive) Put(ctx context.Context, dstDir model.Obj, stream model.Fi
 	return err
 }
 
+func (d *GoogleDrive) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	var resp AboutResp
+	_, err := d.request("https://www.googleapis.com/drive/v3/about", http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx).SetQueryParam("fields", "storageQuota")
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	total, _ := strconv.ParseInt(resp.StorageQuota.Limit, 10, 64)
+	used, _ := strconv.ParseInt(resp.StorageQuota.Usage, 10, 64)
+	return &model.StorageDetails{
+		TotalSpace: total,
+		UsedSpace:  used,
+	}, nil
+}
+
 var _ driver.Driver = (*GoogleDrive)(nil)
+var _ driver.WithDetails = (*GoogleDrive)(nil)
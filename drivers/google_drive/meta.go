@@ -7,13 +7,13 @@ import (
 
 type Addition struct {
 	driver.RootID
-	RefreshToken   string `json:"refresh_token" required:"true"`
+	RefreshToken   string `json:"refresh_token" confidential:"true" required:"true"`
 	OrderBy        string `json:"order_by" type:"string" help:"such as: folder,name,modifiedTime"`
 	OrderDirection string `json:"order_direction" type:"select" options:"asc,desc"`
 	UseOnlineAPI   bool   `json:"use_online_api" default:"true"`
 	APIAddress     string `json:"api_url_address" default:"https://api.oplist.org/googleui/renewapi"`
 	ClientID       string `json:"client_id"`
-	ClientSecret   string `json:"client_secret"`
+	ClientSecret   string `json:"client_secret" confidential:"true"`
 	ChunkSize      int64  `json:"chunk_size" type:"number" default:"5" help:"chunk size while uploading (unit: MB)"`
 }
 
@@ -65,6 +65,14 @@ func fileToObj(f File) *model.ObjThumb {
 	return obj
 }
 
+type AboutResp struct {
+	StorageQuota struct {
+		// absent for the unlimited storages
+		Limit string `json:"limit"`
+		Usage string `json:"usage"`
+	} `json:"storageQuota"`
+}
+
 type Error struct {
 	Error struct {
 		Errors []struct {
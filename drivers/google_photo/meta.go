@@ -7,9 +7,9 @@ import (
 
 type Addition struct {
 	driver.RootID
-	RefreshToken string `json:"refresh_token" required:"true"`
+	RefreshToken string `json:"refresh_token" confidential:"true" required:"true"`
 	ClientID     string `json:"client_id" required:"true" default:"202264815644.apps.googleusercontent.com"`
-	ClientSecret string `json:"client_secret" required:"true" default:"X4Z3ca8xfWDb1Voo-F9a7ZxJ"`
+	ClientSecret string `json:"client_secret" confidential:"true" required:"true" default:"X4Z3ca8xfWDb1Voo-F9a7ZxJ"`
 	ShowArchive  bool   `json:"show_archive"`
 }
 
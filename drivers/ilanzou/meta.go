@@ -8,7 +8,7 @@ import (
 type Addition struct {
 	driver.RootID
 	Username string `json:"username" type:"string" required:"true"`
-	Password string `json:"password" type:"string" required:"true"`
+	Password string `json:"password" confidential:"true" type:"string" required:"true"`
 	Ip       string `json:"ip" type:"string"`
 
 	Token string
@@ -9,9 +9,9 @@ type Addition struct {
 	Type string `json:"type" type:"select" options:"account,cookie,url" default:"cookie"`
 
 	Account  string `json:"account"`
-	Password string `json:"password"`
+	Password string `json:"password" confidential:"true"`
 
-	Cookie string `json:"cookie" help:"about 15 days valid, ignore if shareUrl is used"`
+	Cookie string `json:"cookie" confidential:"true" help:"about 15 days valid, ignore if shareUrl is used"`
 
 	driver.RootID
 	SharePassword  string `json:"share_password"`
@@ -427,4 +427,9 @@ func (d *Local) Put(ctx context.Context, dstDir model.Obj, stream model.FileStre
 	return nil
 }
 
+func (d *Local) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	return getDiskUsage(d.GetRootPath())
+}
+
 var _ driver.Driver = (*Local)(nil)
+var _ driver.WithDetails = (*Local)(nil)
@@ -0,0 +1,12 @@
+//go:build !windows && !linux && !darwin && !freebsd
+
+package local
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func getDiskUsage(path string) (*model.StorageDetails, error) {
+	return nil, errs.NotImplement
+}
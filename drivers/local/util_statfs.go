@@ -0,0 +1,23 @@
+//go:build linux || darwin || freebsd
+
+package local
+
+import (
+	"syscall"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func getDiskUsage(path string) (*model.StorageDetails, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	bsize := uint64(stat.Bsize)
+	// the space reserved for root is taken as used, since it can't be written by openlist
+	return &model.StorageDetails{
+		TotalSpace: int64(uint64(stat.Blocks) * bsize),
+		UsedSpace:  int64((uint64(stat.Blocks) - uint64(stat.Bavail)) * bsize),
+	}, nil
+}
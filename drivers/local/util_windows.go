@@ -6,6 +6,10 @@ import (
 	"io/fs"
 	"path/filepath"
 	"syscall"
+	"unsafe"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
 )
 
 func isHidden(f fs.FileInfo, fullPath string) bool {
@@ -20,3 +24,26 @@ func isHidden(f fs.FileInfo, fullPath string) bool {
 	}
 	return attrs&syscall.FILE_ATTRIBUTE_HIDDEN != 0
 }
+
+var procGetDiskFreeSpaceExW = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+func getDiskUsage(path string) (*model.StorageDetails, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var freeBytesAvailable, totalBytes, totalFreeBytes uint64
+	ret, _, err := procGetDiskFreeSpaceExW.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(unsafe.Pointer(&freeBytesAvailable)),
+		uintptr(unsafe.Pointer(&totalBytes)),
+		uintptr(unsafe.Pointer(&totalFreeBytes)),
+	)
+	if ret == 0 {
+		return nil, errors.WithStack(err)
+	}
+	return &model.StorageDetails{
+		TotalSpace: int64(totalBytes),
+		UsedSpace:  int64(totalBytes - freeBytesAvailable),
+	}, nil
+}
@@ -11,7 +11,7 @@ type Addition struct {
 	// define other
 	// Field string `json:"field" type:"select" required:"true" options:"a,b,c" default:"a"`
 	Endpoint    string `json:"endpoint" required:"true" default:"https://misskey.io"`
-	AccessToken string `json:"access_token" required:"true"`
+	AccessToken string `json:"access_token" confidential:"true" required:"true"`
 }
 
 var config = driver.Config{
@@ -8,7 +8,7 @@ import (
 )
 
 type Addition struct {
-	Cookie    string `json:"cookie" type:"text" required:"true" help:""`
+	Cookie    string `json:"cookie" confidential:"true" type:"text" required:"true" help:""`
 	SongLimit uint64 `json:"song_limit" default:"200" type:"number" help:"only get 200 songs by default"`
 }
 
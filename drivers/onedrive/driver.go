@@ -207,4 +207,19 @@ func (d *Onedrive) Put(ctx context.Context, dstDir model.Obj, stream model.FileS
 	return err
 }
 
+func (d *Onedrive) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	var resp DriveResp
+	_, err := d.Request(d.getDriveUrl(), http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx).SetQueryParam("$select", "quota")
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageDetails{
+		TotalSpace: resp.Quota.Total,
+		UsedSpace:  resp.Quota.Used,
+	}, nil
+}
+
 var _ driver.Driver = (*Onedrive)(nil)
+var _ driver.WithDetails = (*Onedrive)(nil)
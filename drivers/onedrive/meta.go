@@ -12,9 +12,9 @@ type Addition struct {
 	UseOnlineAPI bool   `json:"use_online_api" default:"true"`
 	APIAddress   string `json:"api_url_address" default:"https://api.oplist.org/onedrive/renewapi"`
 	ClientID     string `json:"client_id"`
-	ClientSecret string `json:"client_secret"`
+	ClientSecret string `json:"client_secret" confidential:"true"`
 	RedirectUri  string `json:"redirect_uri" required:"true" default:"https://api.oplist.org/onedrive/callback"`
-	RefreshToken string `json:"refresh_token" required:"true"`
+	RefreshToken string `json:"refresh_token" confidential:"true" required:"true"`
 	SiteId       string `json:"site_id"`
 	ChunkSize    int64  `json:"chunk_size" type:"number" default:"5"`
 	CustomHost   string `json:"custom_host" help:"Custom host for onedrive download link"`
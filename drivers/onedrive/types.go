@@ -73,6 +73,13 @@ type Files struct {
 	NextLink string `json:"@odata.nextLink"`
 }
 
+type DriveResp struct {
+	Quota struct {
+		Total int64 `json:"total"`
+		Used  int64 `json:"used"`
+	} `json:"quota"`
+}
+
 // Metadata represents a request to update Metadata.
 // It includes only the writeable properties.
 // omitempty is intentionally included for all, per https://learn.microsoft.com/en-us/onedrive/developer/rest-api/api/driveitem_update?view=odsp-graph-online#request-body
@@ -11,8 +11,8 @@ type Addition struct {
 	OrderDirection string `json:"order_direction" type:"select" options:"asc,desc" default:"asc"`
 	UseOnlineAPI   bool   `json:"use_online_api" default:"true"`
 	APIAddress     string `json:"api_url_address" default:"https://api.oplist.org/quarkyun/renewapi"`
-	AccessToken    string `json:"access_token" required:"false" default:""`
-	RefreshToken   string `json:"refresh_token" required:"true"`
+	AccessToken    string `json:"access_token" confidential:"true" required:"false" default:""`
+	RefreshToken   string `json:"refresh_token" confidential:"true" required:"true"`
 	AppID          string `json:"app_id" required:"true" help:"Keep it empty if you don't have one"`
 	SignKey        string `json:"sign_key" required:"true" help:"Keep it empty if you don't have one"`
 }
@@ -9,7 +9,7 @@ type Addition struct {
 	// Usually one of two
 	driver.RootID
 	// define other
-	RefreshToken string `json:"refresh_token" required:"false" default:""`
+	RefreshToken string `json:"refresh_token" confidential:"true" required:"false" default:""`
 	// 必要且影响登录,由签名决定
 	DeviceID string `json:"device_id"  required:"false" default:""`
 	// 登陆所用的数据 无需手动填写
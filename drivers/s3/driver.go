@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
@@ -210,4 +211,17 @@ func (d *S3) Put(ctx context.Context, dstDir model.Obj, s model.FileStreamer, up
 	return err
 }
 
+func (d *S3) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	if !d.ShowUsedSpace {
+		return nil, errs.NotImplement
+	}
+	used, err := d.getUsedSpace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// the capacity of a bucket is unlimited
+	return &model.StorageDetails{UsedSpace: used}, nil
+}
+
 var _ driver.Driver = (*S3)(nil)
+var _ driver.WithDetails = (*S3)(nil)
@@ -11,8 +11,8 @@ type Addition struct {
 	Endpoint                 string `json:"endpoint" required:"true"`
 	Region                   string `json:"region"`
 	AccessKeyID              string `json:"access_key_id" required:"true"`
-	SecretAccessKey          string `json:"secret_access_key" required:"true"`
-	SessionToken             string `json:"session_token"`
+	SecretAccessKey          string `json:"secret_access_key" confidential:"true" required:"true"`
+	SessionToken             string `json:"session_token" confidential:"true"`
 	CustomHost               string `json:"custom_host"`
 	EnableCustomHostPresign  bool   `json:"enable_custom_host_presign"`
 	SignURLExpire            int    `json:"sign_url_expire" type:"number" default:"4"`
@@ -21,6 +21,7 @@ type Addition struct {
 	ListObjectVersion        string `json:"list_object_version" type:"select" options:"v1,v2" default:"v1"`
 	RemoveBucket             bool   `json:"remove_bucket" help:"Remove bucket name from path when using custom host."`
 	AddFilenameToDisposition bool   `json:"add_filename_to_disposition" help:"Add filename to Content-Disposition header."`
+	ShowUsedSpace            bool   `json:"show_used_space" help:"Report the used space by listing all the objects under the root, which is slow for large buckets."`
 }
 
 func init() {
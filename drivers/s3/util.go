@@ -187,6 +187,36 @@ func (d *S3) listV2(prefix string, args model.ListArgs) ([]model.Obj, error) {
 	return files, nil
 }
 
+// getUsedSpace sums the size of all the objects under the root, s3 has no api telling the usage
+func (d *S3) getUsedSpace(ctx context.Context) (int64, error) {
+	prefix := getKey(d.GetRootPath(), true)
+	var used int64
+	sum := func(objects []*s3.Object) {
+		for _, object := range objects {
+			used += aws.Int64Value(object.Size)
+		}
+	}
+	var err error
+	if d.ListObjectVersion == "v2" {
+		err = d.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+			Bucket: &d.Bucket,
+			Prefix: &prefix,
+		}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+			sum(page.Contents)
+			return true
+		})
+	} else {
+		err = d.client.ListObjectsPagesWithContext(ctx, &s3.ListObjectsInput{
+			Bucket: &d.Bucket,
+			Prefix: &prefix,
+		}, func(page *s3.ListObjectsOutput, _ bool) bool {
+			sum(page.Contents)
+			return true
+		})
+	}
+	return used, err
+}
+
 func (d *S3) copy(ctx context.Context, src string, dst string, isDir bool) error {
 	if isDir {
 		return d.copyDir(ctx, src, dst)
@@ -18,9 +18,9 @@ type ExpertAddition struct {
 
 	// 登录方式1
 	Username string `json:"username" required:"true" help:"login type is user,this is required"`
-	Password string `json:"password" required:"true" help:"login type is user,this is required"`
+	Password string `json:"password" confidential:"true" required:"true" help:"login type is user,this is required"`
 	// 登录方式2
-	RefreshToken string `json:"refresh_token" required:"true" help:"login type is refresh_token,this is required"`
+	RefreshToken string `json:"refresh_token" confidential:"true" required:"true" help:"login type is refresh_token,this is required"`
 
 	// 签名方法1
 	Algorithms string `json:"algorithms" required:"true" help:"sign type is algorithms,this is required" default:"9uJNVj/wLmdwKrJaVj/omlQ,Oz64Lp0GigmChHMf/6TNfxx7O9PyopcczMsnf,Eb+L7Ce+Ej48u,jKY0,ASr0zCl6v8W4aidjPK5KHd1Lq3t+vBFf41dqv5+fnOd,wQlozdg6r1qxh0eRmt3QgNXOvSZO6q/GXK,gmirk+ciAvIgA/cxUUCema47jr/YToixTT+Q6O,5IiCoM9B1/788ntB,P07JH0h6qoM6TSUAK2aL9T5s2QBVeY9JWvalf,+oK0AN"`
@@ -31,12 +31,12 @@ type ExpertAddition struct {
 	// 验证码
 	CaptchaToken string `json:"captcha_token"`
 	// 信任密钥
-	CreditKey string `json:"credit_key" help:"credit key,used for login"`
+	CreditKey string `json:"credit_key" confidential:"true" help:"credit key,used for login"`
 
 	// 必要且影响登录,由签名决定
 	DeviceID      string `json:"device_id" default:""`
 	ClientID      string `json:"client_id"  required:"true" default:"Xp6vsxz_7IYVw2BB"`
-	ClientSecret  string `json:"client_secret"  required:"true" default:"Xp6vsy4tN9toTVdMSpomVdXpRmES"`
+	ClientSecret  string `json:"client_secret" confidential:"true"  required:"true" default:"Xp6vsy4tN9toTVdMSpomVdXpRmES"`
 	ClientVersion string `json:"client_version"  required:"true" default:"8.31.0.9726"`
 	PackageName   string `json:"package_name"  required:"true" default:"com.xunlei.downloadprovider"`
 
@@ -74,10 +74,10 @@ func (i *ExpertAddition) GetIdentity() string {
 type Addition struct {
 	driver.RootID
 	Username     string `json:"username" required:"true"`
-	Password     string `json:"password" required:"true"`
+	Password     string `json:"password" confidential:"true" required:"true"`
 	CaptchaToken string `json:"captcha_token"`
 	// 信任密钥
-	CreditKey string `json:"credit_key" help:"credit key,used for login"`
+	CreditKey string `json:"credit_key" confidential:"true" help:"credit key,used for login"`
 	// 登录设备ID
 	DeviceID string `json:"device_id" default:""`
 }
@@ -18,11 +18,11 @@ type ExpertAddition struct {
 
 	// 登录方式1
 	Username string `json:"username" required:"true" help:"login type is user,this is required"`
-	Password string `json:"password" required:"true" help:"login type is user,this is required"`
+	Password string `json:"password" confidential:"true" required:"true" help:"login type is user,this is required"`
 	// 登录方式2
-	RefreshToken string `json:"refresh_token" required:"true" help:"login type is refresh_token,this is required"`
+	RefreshToken string `json:"refresh_token" confidential:"true" required:"true" help:"login type is refresh_token,this is required"`
 
-	SafePassword string `json:"safe_password" required:"true" help:"super safe password"` // 超级保险箱密码
+	SafePassword string `json:"safe_password" confidential:"true" required:"true" help:"super safe password"` // 超级保险箱密码
 
 	// 签名方法1
 	Algorithms string `json:"algorithms" required:"true" help:"sign type is algorithms,this is required" default:"Cw4kArmKJ/aOiFTxnQ0ES+D4mbbrIUsFn,HIGg0Qfbpm5ThZ/RJfjoao4YwgT9/M,u/PUD,OlAm8tPkOF1qO5bXxRN2iFttuDldrg,FFIiM6sFhWhU7tIMVUKOF7CUv/KzgwwV8FE,yN,4m5mglrIHksI6wYdq,LXEfS7,T+p+C+F2yjgsUtiXWU/cMNYEtJI4pq7GofW,14BrGIEMXkbvFvZ49nDUfVCRcHYFOJ1BP1Y,kWIH3Row,RAmRTKNCjucPWC"`
@@ -33,12 +33,12 @@ type ExpertAddition struct {
 	// 验证码
 	CaptchaToken string `json:"captcha_token"`
 	// 信任密钥
-	CreditKey string `json:"credit_key" help:"credit key,used for login"`
+	CreditKey string `json:"credit_key" confidential:"true" help:"credit key,used for login"`
 
 	// 必要且影响登录,由签名决定
 	DeviceID      string `json:"device_id"  required:"false" default:""`
 	ClientID      string `json:"client_id"  required:"true" default:"ZUBzD9J_XPXfn7f7"`
-	ClientSecret  string `json:"client_secret"  required:"true" default:"yESVmHecEe6F0aou69vl-g"`
+	ClientSecret  string `json:"client_secret" confidential:"true"  required:"true" default:"yESVmHecEe6F0aou69vl-g"`
 	ClientVersion string `json:"client_version"  required:"true" default:"1.40.0.7208"`
 	PackageName   string `json:"package_name"  required:"true" default:"com.xunlei.browser"`
 
@@ -80,11 +80,11 @@ func (i *ExpertAddition) GetIdentity() string {
 type Addition struct {
 	driver.RootID
 	Username     string `json:"username" required:"true"`
-	Password     string `json:"password" required:"true"`
-	SafePassword string `json:"safe_password" required:"true"` // 超级保险箱密码
+	Password     string `json:"password" confidential:"true" required:"true"`
+	SafePassword string `json:"safe_password" confidential:"true" required:"true"` // 超级保险箱密码
 	CaptchaToken string `json:"captcha_token"`
-	CreditKey    string `json:"credit_key" help:"credit key,used for login"` // 信任密钥
-	DeviceID     string `json:"device_id" default:""`                        // 登录设备ID
+	CreditKey    string `json:"credit_key" confidential:"true" help:"credit key,used for login"` // 信任密钥
+	DeviceID     string `json:"device_id" default:""`                                            // 登录设备ID
 	UseVideoUrl  bool   `json:"use_video_url" default:"false"`
 	// 离线下载是否使用 流畅播(Fluent Play)接口
 	UseFluentPlay bool   `json:"use_fluent_play" default:"false" help:"use fluent play for offline download,only magnet links supported"`
@@ -18,9 +18,9 @@ type ExpertAddition struct {
 
 	// 登录方式1
 	Username string `json:"username" required:"true" help:"login type is user,this is required"`
-	Password string `json:"password" required:"true" help:"login type is user,this is required"`
+	Password string `json:"password" confidential:"true" required:"true" help:"login type is user,this is required"`
 	// 登录方式2
-	RefreshToken string `json:"refresh_token" required:"true" help:"login type is refresh_token,this is required"`
+	RefreshToken string `json:"refresh_token" confidential:"true" required:"true" help:"login type is refresh_token,this is required"`
 
 	// 签名方法1
 	Algorithms string `json:"algorithms" required:"true" help:"sign type is algorithms,this is required" default:"kVy0WbPhiE4v6oxXZ88DvoA3Q,lON/AUoZKj8/nBtcE85mVbkOaVdVa,rLGffQrfBKH0BgwQ33yZofvO3Or,FO6HWqw,GbgvyA2,L1NU9QvIQIH7DTRt,y7llk4Y8WfYflt6,iuDp1WPbV3HRZudZtoXChxH4HNVBX5ZALe,8C28RTXmVcco0,X5Xh,7xe25YUgfGgD0xW3ezFS,,CKCR,8EmDjBo6h3eLaK7U6vU2Qys0NsMx,t2TeZBXKqbdP09Arh9C3"`
@@ -34,7 +34,7 @@ type ExpertAddition struct {
 	// 必要且影响登录,由签名决定
 	DeviceID      string `json:"device_id"  required:"false" default:""`
 	ClientID      string `json:"client_id"  required:"true" default:"ZQL_zwA4qhHcoe_2"`
-	ClientSecret  string `json:"client_secret"  required:"true" default:"Og9Vr1L8Ee6bh0olFxFDRg"`
+	ClientSecret  string `json:"client_secret" confidential:"true"  required:"true" default:"Og9Vr1L8Ee6bh0olFxFDRg"`
 	ClientVersion string `json:"client_version"  required:"true" default:"1.06.0.2132"`
 	PackageName   string `json:"package_name"  required:"true" default:"com.thunder.downloader"`
 
@@ -72,7 +72,7 @@ func (i *ExpertAddition) GetIdentity() string {
 type Addition struct {
 	driver.RootID
 	Username     string `json:"username" required:"true"`
-	Password     string `json:"password" required:"true"`
+	Password     string `json:"password" confidential:"true" required:"true"`
 	CaptchaToken string `json:"captcha_token"`
 	UseVideoUrl  bool   `json:"use_video_url" default:"true"`
 }
@@ -9,7 +9,7 @@ type Addition struct {
 	Vendor   string `json:"vendor" type:"select" options:"sharepoint,other" default:"other"`
 	Address  string `json:"address" required:"true"`
 	Username string `json:"username" required:"true"`
-	Password string `json:"password" required:"true"`
+	Password string `json:"password" confidential:"true" required:"true"`
 	driver.RootPath
 	TlsInsecureSkipVerify bool `json:"tls_insecure_skip_verify" default:"false"`
 }
@@ -135,4 +135,18 @@ func (d *YandexDisk) Put(ctx context.Context, dstDir model.Obj, s model.FileStre
 	return err
 }
 
+func (d *YandexDisk) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	res, err := d.requestDisk("", http.MethodGet, func(req *resty.Request) {
+		req.SetContext(ctx).SetQueryParam("fields", "total_space,used_space")
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StorageDetails{
+		TotalSpace: utils.Json.Get(res, "total_space").ToInt64(),
+		UsedSpace:  utils.Json.Get(res, "used_space").ToInt64(),
+	}, nil
+}
+
 var _ driver.Driver = (*YandexDisk)(nil)
+var _ driver.WithDetails = (*YandexDisk)(nil)
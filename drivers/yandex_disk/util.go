@@ -71,7 +71,12 @@ func (d *YandexDisk) refreshToken() error {
 }
 
 func (d *YandexDisk) request(pathname string, method string, callback base.ReqCallback, resp interface{}) ([]byte, error) {
-	u := "https://cloud-api.yandex.net/v1/disk/resources" + pathname
+	return d.requestDisk("/resources"+pathname, method, callback, resp)
+}
+
+// requestDisk requests the api of the disk, the path of the resources api starts with /resources
+func (d *YandexDisk) requestDisk(pathname string, method string, callback base.ReqCallback, resp interface{}) ([]byte, error) {
+	u := "https://cloud-api.yandex.net/v1/disk" + pathname
 	req := base.RestyClient.R()
 	req.SetHeader("Authorization", "OAuth "+d.AccessToken)
 	if callback != nil {
@@ -93,7 +98,7 @@ func (d *YandexDisk) request(pathname string, method string, callback base.ReqCa
 			if err != nil {
 				return nil, err
 			}
-			return d.request(pathname, method, callback, resp)
+			return d.requestDisk(pathname, method, callback, resp)
 		}
 		return nil, errors.New(e.Description)
 	}
@@ -0,0 +1,67 @@
+package archives
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/archive/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+type tarWriter struct {
+	tw *tar.Writer
+	gw *gzip.Writer
+}
+
+func newTarWriter(gz bool) tool.Compressor {
+	return func(w io.Writer, password string) (tool.ArchiveWriter, error) {
+		if password != "" {
+			return nil, errors.WithMessage(errs.NotSupport, "tar archives can't be encrypted")
+		}
+		if !gz {
+			return &tarWriter{tw: tar.NewWriter(w)}, nil
+		}
+		gw := gzip.NewWriter(w)
+		return &tarWriter{tw: tar.NewWriter(gw), gw: gw}, nil
+	}
+}
+
+func (w *tarWriter) Create(name string, obj model.Obj) (io.Writer, error) {
+	hdr := &tar.Header{
+		Name:     name,
+		ModTime:  obj.ModTime(),
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     obj.GetSize(),
+		Format:   tar.FormatPAX,
+	}
+	if obj.IsDir() {
+		hdr.Name = strings.TrimSuffix(name, "/") + "/"
+		hdr.Typeflag = tar.TypeDir
+		hdr.Mode = 0755
+		hdr.Size = 0
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+	return w.tw, nil
+}
+
+func (w *tarWriter) Close() error {
+	err := w.tw.Close()
+	if w.gw != nil {
+		if e := w.gw.Close(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func init() {
+	tool.RegisterCompressor("tar", newTarWriter(false))
+	tool.RegisterCompressor("tar.gz", newTarWriter(true))
+}
@@ -20,3 +20,14 @@ type Tool interface {
 	Extract(ss []*stream.SeekableStream, args model.ArchiveInnerArgs) (io.ReadCloser, int64, error)
 	Decompress(ss []*stream.SeekableStream, outputPath string, args model.ArchiveInnerArgs, up model.UpdateProgress) error
 }
+
+// ArchiveWriter adds entries to an archive being written
+type ArchiveWriter interface {
+	// Create starts a new entry named with its slash separated path in the archive,
+	// the returned writer is valid until the next call of Create or Close
+	Create(name string, obj model.Obj) (io.Writer, error)
+	Close() error
+}
+
+// Compressor creates an ArchiveWriter writing into w, password may be empty
+type Compressor func(w io.Writer, password string) (ArchiveWriter, error)
@@ -1,12 +1,15 @@
 package tool
 
 import (
+	"slices"
+
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 )
 
 var (
 	Tools               = make(map[string]Tool)
 	MultipartExtensions = make(map[string]MultipartExtension)
+	Compressors         = make(map[string]Compressor)
 )
 
 func RegisterTool(tool Tool) {
@@ -30,3 +33,26 @@ func GetArchiveTool(ext string) (*MultipartExtension, Tool, error) {
 	}
 	return &partExt, t, nil
 }
+
+// RegisterCompressor registers a compressor for the archive format, such as "zip" and "tar.gz"
+func RegisterCompressor(format string, c Compressor) {
+	Compressors[format] = c
+}
+
+// CompressorFormats returns the sorted formats archives can be compressed into
+func CompressorFormats() []string {
+	formats := make([]string, 0, len(Compressors))
+	for format := range Compressors {
+		formats = append(formats, format)
+	}
+	slices.Sort(formats)
+	return formats
+}
+
+func GetCompressor(format string) (Compressor, error) {
+	c, ok := Compressors[format]
+	if !ok {
+		return nil, errs.UnknownArchiveFormat
+	}
+	return c, nil
+}
@@ -0,0 +1,58 @@
+package zip
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/archive/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/yeka/zip"
+)
+
+type writer struct {
+	w        *zip.Writer
+	password string
+	method   uint16
+}
+
+func newWriter(w io.Writer, password string) (tool.ArchiveWriter, error) {
+	return &writer{w: zip.NewWriter(w), password: password, method: zip.Deflate}, nil
+}
+
+// NewStreamWriter creates an unencrypted zip writer storing files without compression,
+// so that archives of any size can be streamed with little cpu usage
+func NewStreamWriter(w io.Writer) tool.ArchiveWriter {
+	return &writer{w: zip.NewWriter(w), method: zip.Store}
+}
+
+func (w *writer) Create(name string, obj model.Obj) (io.Writer, error) {
+	fh := &zip.FileHeader{
+		Name:   name,
+		Method: w.method,
+		// names are always utf-8 encoded
+		Flags: 0x800,
+	}
+	fh.SetModTime(obj.ModTime())
+	if obj.IsDir() {
+		fh.Name = strings.TrimSuffix(name, "/") + "/"
+		fh.Method = zip.Store
+		fh.SetMode(os.ModeDir | 0755)
+	} else {
+		fh.UncompressedSize64 = uint64(obj.GetSize())
+		fh.SetMode(0644)
+		if w.password != "" {
+			fh.SetPassword(w.password)
+			fh.SetEncryptionMethod(zip.AES256Encryption)
+		}
+	}
+	return w.w.CreateHeader(fh)
+}
+
+func (w *writer) Close() error {
+	return w.w.Close()
+}
+
+func init() {
+	tool.RegisterCompressor("zip", newWriter)
+}
@@ -0,0 +1,77 @@
+package zip
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/yeka/zip"
+)
+
+func TestWriterWithPassword(t *testing.T) {
+	var buf bytes.Buffer
+	aw, err := newWriter(&buf, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = aw.Create("dir", &model.Object{Name: "dir", IsFolder: true, Modified: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	w, err := aw.Create("dir/文件.txt", &model.Object{Name: "文件.txt", Size: 5, Modified: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err = aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.File) != 2 || r.File[0].Name != "dir/" || r.File[1].Name != "dir/文件.txt" {
+		t.Fatalf("unexpected entries: %+v", r.File)
+	}
+	f := r.File[1]
+	if !f.IsEncrypted() {
+		t.Fatal("expect file encrypted")
+	}
+	f.SetPassword("secret")
+	rc, err := f.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("expect hello, got %q: %v", data, err)
+	}
+}
+
+func TestStreamWriter(t *testing.T) {
+	var buf bytes.Buffer
+	aw := NewStreamWriter(&buf)
+	w, err := aw.Create("a.txt", &model.Object{Name: "a.txt", Size: 5, Modified: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = w.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err = aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.File) != 1 || r.File[0].Method != zip.Store || r.File[0].IsEncrypted() {
+		t.Fatalf("unexpected entries: %+v", r.File)
+	}
+}
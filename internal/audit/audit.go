@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	MakeDir    = "mkdir"
+	Rename     = "rename"
+	Move       = "move"
+	Copy       = "copy"
+	Remove     = "remove"
+	Upload     = "upload"
+	OfflineURL = "put_url"
+	Decompress = "decompress"
+	Compress   = "compress"
+	Sync       = "sync"
+	Download   = "download"
+)
+
+const (
+	queueSize     = 4096
+	batchSize     = 200
+	flushInterval = time.Second
+	// how long Record waits for the queue before writing the log itself
+	enqueueTimeout = 5 * time.Second
+)
+
+var (
+	mu      sync.RWMutex
+	running bool
+	queue   chan model.AuditLog
+	done    chan struct{}
+	cleaner *cron.Cron
+)
+
+// Record queues an audit log of an operation done by the user in ctx. If the writer falls
+// behind, the caller waits for the queue for a while and then writes the log itself,
+// the log is dropped only if that fails too.
+func Record(ctx context.Context, operation, srcPath, dstPath string, size int64, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if !running || !setting.GetBool(conf.AuditLogEnabled) {
+		return
+	}
+	if operation == Download && !setting.GetBool(conf.AuditLogDownload) {
+		return
+	}
+	l := model.AuditLog{
+		Time:      time.Now(),
+		Operation: operation,
+		SrcPath:   srcPath,
+		DstPath:   dstPath,
+		Size:      size,
+		Success:   err == nil,
+	}
+	if err != nil {
+		l.Error = err.Error()
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		l.UserId = user.ID
+		l.Username = user.Username
+	}
+	l.Protocol, _ = ctx.Value(conf.ProtocolKey).(string)
+	if l.Protocol == "" {
+		l.Protocol = "internal"
+	}
+	if ip, ok := ctx.Value(conf.ClientIPKey).(string); ok {
+		if host, _, e := net.SplitHostPort(ip); e == nil {
+			ip = host
+		}
+		l.IP = ip
+	}
+	select {
+	case queue <- l:
+		return
+	default:
+	}
+	timer := time.NewTimer(enqueueTimeout)
+	defer timer.Stop()
+	select {
+	case queue <- l:
+	case <-timer.C:
+		if e := db.CreateAuditLogs([]model.AuditLog{l}); e != nil {
+			metrics.AddAuditLogsDropped(1)
+			log.Errorf("audit queue is full and failed write log, drop log: %s %s: %+v", operation, srcPath, e)
+		}
+	}
+}
+
+// Start runs the background writer and the retention cleaner
+func Start() {
+	mu.Lock()
+	defer mu.Unlock()
+	if running {
+		return
+	}
+	queue = make(chan model.AuditLog, queueSize)
+	done = make(chan struct{})
+	go write(queue, done)
+	cleaner = cron.NewCron(time.Hour)
+	cleaner.Do(clean)
+	go clean()
+	running = true
+}
+
+// Stop flushes the queued logs and stops the background jobs
+func Stop() {
+	mu.Lock()
+	if !running {
+		mu.Unlock()
+		return
+	}
+	running = false
+	close(queue)
+	cleaner.Stop()
+	mu.Unlock()
+	<-done
+}
+
+func write(queue <-chan model.AuditLog, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]model.AuditLog, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := db.CreateAuditLogs(batch); err != nil {
+			metrics.AddAuditLogsDropped(len(batch))
+			log.Errorf("failed write %d audit logs: %+v", len(batch), err)
+		}
+		batch = make([]model.AuditLog, 0, batchSize)
+	}
+	for {
+		select {
+		case l, ok := <-queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, l)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func clean() {
+	days := setting.GetInt(conf.AuditLogRetentionDays, 90)
+	if days <= 0 {
+		return
+	}
+	n, err := db.DeleteAuditLogsBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Errorf("failed clean audit logs: %+v", err)
+		return
+	}
+	if n > 0 {
+		log.Infof("cleaned %d audit logs older than %d days", n, days)
+	}
+}
+
+func GetLogs(q model.AuditQuery, pageIndex, pageSize int) ([]model.AuditLog, int64, error) {
+	return db.GetAuditLogs(q, pageIndex, pageSize)
+}
+
+// Export writes the matched logs to w in JSON Lines format
+func Export(q model.AuditQuery, w io.Writer) error {
+	enc := utils.Json.NewEncoder(w)
+	return db.WalkAuditLogs(q, func(logs []model.AuditLog) error {
+		for i := range logs {
+			if err := enc.Encode(&logs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Clear deletes the logs before t
+func Clear(t time.Time) (int64, error) {
+	return db.DeleteAuditLogsBefore(t)
+}
@@ -0,0 +1,68 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestRecord(t *testing.T) {
+	err := op.SaveSettingItem(&model.SettingItem{Key: conf.AuditLogEnabled, Value: "true", Type: conf.TypeBool, Group: model.AUDIT})
+	if err != nil {
+		t.Fatalf("failed save setting: %+v", err)
+	}
+	audit.Start()
+	ctx := context.WithValue(context.Background(), conf.UserKey, &model.User{ID: 2, Username: "alice"})
+	ctx = context.WithValue(ctx, conf.ProtocolKey, "ftp")
+	ctx = context.WithValue(ctx, conf.ClientIPKey, "10.0.0.1:2121")
+	audit.Record(ctx, audit.Remove, "/local/a", "", 0, nil)
+	audit.Record(ctx, audit.Move, "/local/b", "/other", 0, errors.New("denied"))
+	audit.Record(context.Background(), audit.MakeDir, "/local2/c", "", 0, nil)
+	audit.Stop()
+
+	logs, total, err := audit.GetLogs(model.AuditQuery{Path: "/local"}, 1, 10)
+	if err != nil {
+		t.Fatalf("failed get logs: %+v", err)
+	}
+	if total != 2 {
+		t.Fatalf("expected 2 logs under /local, got %d", total)
+	}
+	// newest first
+	if logs[0].Operation != audit.Move || logs[0].Success || logs[0].Error != "denied" {
+		t.Errorf("unexpected log: %+v", logs[0])
+	}
+	if logs[1].Username != "alice" || logs[1].Protocol != "ftp" || logs[1].IP != "10.0.0.1" {
+		t.Errorf("unexpected log: %+v", logs[1])
+	}
+	_, total, _ = audit.GetLogs(model.AuditQuery{Protocol: "internal"}, 1, 10)
+	if total != 1 {
+		t.Errorf("expected 1 internal log, got %d", total)
+	}
+
+	var buf bytes.Buffer
+	if err = audit.Export(model.AuditQuery{Username: "alice"}, &buf); err != nil {
+		t.Fatalf("failed export: %+v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Errorf("expected 2 exported lines, got %d", lines)
+	}
+}
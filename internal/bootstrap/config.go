@@ -10,6 +10,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/drivers/base"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/net"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/caarlos0/env/v9"
 	"github.com/shirou/gopsutil/v4/mem"
@@ -111,6 +112,9 @@ func InitConfig() {
 	if conf.Conf.DistDir != "" {
 		convertAbsPath(&conf.Conf.DistDir)
 	}
+	if conf.Conf.EncryptionKeyFile != "" {
+		convertAbsPath(&conf.Conf.EncryptionKeyFile)
+	}
 	err := os.MkdirAll(conf.Conf.TempDir, 0o777)
 	if err != nil {
 		log.Fatalf("create temp dir error: %+v", err)
@@ -118,6 +122,23 @@ func InitConfig() {
 	log.Debugf("config: %+v", conf.Conf)
 	base.InitClient()
 	initURL()
+	initEncryptionKey()
+}
+
+// initEncryptionKey sets the master key of the confidential fields of the storages,
+// from the config or the env, or else from the key file
+func initEncryptionKey() {
+	key := conf.Conf.EncryptionKey
+	if key == "" && conf.Conf.EncryptionKeyFile != "" {
+		data, err := os.ReadFile(conf.Conf.EncryptionKeyFile)
+		if err != nil {
+			log.Fatalf("read encryption key file error: %+v", err)
+		}
+		key = strings.TrimSpace(string(data))
+	}
+	if err := op.SetEncryptionKey(key); err != nil {
+		log.Fatalf("init encryption key error: %+v", err)
+	}
 }
 
 func confFromEnv() {
@@ -150,6 +171,9 @@ func CleanTempDir() {
 		log.Errorln("failed list temp file: ", err)
 	}
 	for _, file := range files {
+		if file.Name() == conf.S3MultipartDir || file.Name() == conf.TransferSpoolDir {
+			continue
+		}
 		if err := os.RemoveAll(filepath.Join(conf.Conf.TempDir, file.Name())); err != nil {
 			log.Errorln("failed delete temp file: ", err)
 		}
@@ -149,7 +149,7 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.FilterReadMeScripts, Value: "true", Type: conf.TypeBool, Group: model.PREVIEW},
 		// global settings
 		{Key: conf.HideFiles, Value: "/\\/README.md/i", Type: conf.TypeText, Group: model.GLOBAL},
-		{Key: "package_download", Value: "true", Type: conf.TypeBool, Group: model.GLOBAL},
+		{Key: conf.PackageDownload, Value: "true", Type: conf.TypeBool, Group: model.GLOBAL},
 		{Key: conf.CustomizeHead, MigrationValue: `<script src="https://cdnjs.cloudflare.com/polyfill/v3/polyfill.min.js?features=String.prototype.replaceAll"></script>`, Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE},
 		{Key: conf.CustomizeBody, Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE},
 		{Key: conf.LinkExpiration, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE},
@@ -163,6 +163,7 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.ForwardDirectLinkParams, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL},
 		{Key: conf.IgnoreDirectLinkParams, Value: "sign,openlist_ts", Type: conf.TypeString, Group: model.GLOBAL},
 		{Key: conf.WebauthnLoginEnabled, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PUBLIC},
+		{Key: conf.RecycleBinRetentionDays, Value: "30", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `days to keep removed objects in the recycle bin of storages, 0 means forever`},
 
 		// single settings
 		{Key: conf.Token, Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
@@ -221,10 +222,22 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.TaskCopyThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Copy.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.TaskDecompressDownloadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Decompress.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.TaskDecompressUploadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.DecompressUpload.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+		{Key: conf.TaskCompressThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Compress.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+		{Key: conf.TaskSyncThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Sync.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxClientDownloadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxClientUploadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxServerDownloadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxServerUploadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+
+		// audit settings
+		{Key: conf.AuditLogEnabled, Value: "true", Type: conf.TypeBool, Group: model.AUDIT, Flag: model.PRIVATE},
+		{Key: conf.AuditLogDownload, Value: "false", Type: conf.TypeBool, Group: model.AUDIT, Flag: model.PRIVATE, Help: `also record every download link request`},
+		{Key: conf.AuditLogRetentionDays, Value: "90", Type: conf.TypeNumber, Group: model.AUDIT, Flag: model.PRIVATE, Help: `0 means keep forever`},
+
+		// webhook settings
+		{Key: conf.WebhookTimeout, Value: "10", Type: conf.TypeNumber, Group: model.WEBHOOK, Flag: model.PRIVATE, Help: `timeout of a delivery in seconds`},
+		{Key: conf.WebhookMaxRetry, Value: "3", Type: conf.TypeNumber, Group: model.WEBHOOK, Flag: model.PRIVATE, Help: `retries of a failed delivery, with exponential backoff`},
+		{Key: conf.WebhookLogRetentionDays, Value: "30", Type: conf.TypeNumber, Group: model.WEBHOOK, Flag: model.PRIVATE, Help: `0 means keep forever`},
 	}
 	additionalSettingItems := tool.Tools.Items()
 	// 固定顺序
@@ -0,0 +1,20 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+var recycleCron *cron.Cron
+
+func InitRecycleBin() {
+	recycleCron = cron.NewCron(time.Hour)
+	recycleCron.Do(func() {
+		op.CleanExpiredRecycleItems(context.Background(), setting.GetInt(conf.RecycleBinRetentionDays, 30))
+	})
+}
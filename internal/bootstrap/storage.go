@@ -11,6 +11,17 @@ import (
 )
 
 func LoadStorages() {
+	// encrypt the confidential fields saved before the encryption key was set
+	if count, err := op.EncryptStorages(); err != nil {
+		utils.Log.Errorf("failed encrypt storages: %+v", err)
+	} else if count > 0 {
+		utils.Log.Infof("encrypted the confidential fields of %d storages", count)
+	}
+	if count, err := op.EncryptS3Secrets(); err != nil {
+		utils.Log.Errorf("failed encrypt s3 secrets: %+v", err)
+	} else if count > 0 {
+		utils.Log.Infof("encrypted %d s3 secrets", count)
+	}
 	storages, err := db.GetEnabledStorages()
 	if err != nil {
 		utils.Log.Fatalf("failed get enabled storages: %+v", err)
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
@@ -51,4 +52,8 @@ func InitStreamLimit() {
 	initLimiter(&stream.ClientUploadLimit, conf.StreamMaxClientUploadSpeed)
 	initLimiter(&stream.ServerDownloadLimit, conf.StreamMaxServerDownloadSpeed)
 	initLimiter(&stream.ServerUploadLimit, conf.StreamMaxServerUploadSpeed)
+	metrics.RegisterLimiter("client_download", func() metrics.Limiter { return stream.ClientDownloadLimit })
+	metrics.RegisterLimiter("client_upload", func() metrics.Limiter { return stream.ClientUploadLimit })
+	metrics.RegisterLimiter("server_download", func() metrics.Limiter { return stream.ServerDownloadLimit })
+	metrics.RegisterLimiter("server_upload", func() metrics.Limiter { return stream.ServerUploadLimit })
 }
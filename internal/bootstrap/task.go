@@ -4,9 +4,11 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/tache"
 )
 
@@ -18,35 +20,56 @@ func taskFilterNegative(num int) int64 {
 }
 
 func InitTaskManager() {
-	fs.UploadTaskManager = tache.NewManager[*fs.UploadTask](tache.WithWorks(setting.GetInt(conf.TaskUploadThreadsNum, conf.Conf.Tasks.Upload.Workers)), tache.WithMaxRetry(conf.Conf.Tasks.Upload.MaxRetry)) //upload will not support persist
+	fs.UploadTaskManager = task.NewPriorityManager[*fs.UploadTask]("upload", setting.GetInt(conf.TaskUploadThreadsNum, conf.Conf.Tasks.Upload.Workers), tache.WithMaxRetry(conf.Conf.Tasks.Upload.MaxRetry)) //upload will not support persist
 	op.RegisterSettingChangingCallback(func() {
 		fs.UploadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskUploadThreadsNum, conf.Conf.Tasks.Upload.Workers)))
 	})
-	fs.CopyTaskManager = tache.NewManager[*fs.FileTransferTask](tache.WithWorks(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant), db.UpdateTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Copy.MaxRetry))
+	fs.CopyTaskManager = task.NewPriorityManager[*fs.FileTransferTask]("copy", setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant), db.UpdateTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Copy.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.CopyTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers)))
 	})
-	fs.MoveTaskManager = tache.NewManager[*fs.FileTransferTask](tache.WithWorks(setting.GetInt(conf.TaskMoveThreadsNum, conf.Conf.Tasks.Move.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("move", conf.Conf.Tasks.Move.TaskPersistant), db.UpdateTaskDataFunc("move", conf.Conf.Tasks.Move.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Move.MaxRetry))
+	fs.MoveTaskManager = task.NewPriorityManager[*fs.FileTransferTask]("move", setting.GetInt(conf.TaskMoveThreadsNum, conf.Conf.Tasks.Move.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("move", conf.Conf.Tasks.Move.TaskPersistant), db.UpdateTaskDataFunc("move", conf.Conf.Tasks.Move.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Move.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.MoveTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskMoveThreadsNum, conf.Conf.Tasks.Move.Workers)))
 	})
-	tool.DownloadTaskManager = tache.NewManager[*tool.DownloadTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant), db.UpdateTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Download.MaxRetry))
+	fs.CleanTransferSpool()
+	tool.DownloadTaskManager = task.NewPriorityManager[*tool.DownloadTask]("offline_download", setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant), db.UpdateTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Download.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		tool.DownloadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)))
 	})
-	tool.TransferTaskManager = tache.NewManager[*tool.TransferTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant), db.UpdateTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Transfer.MaxRetry))
+	tool.TransferTaskManager = task.NewPriorityManager[*tool.TransferTask]("offline_download_transfer", setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant), db.UpdateTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Transfer.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		tool.TransferTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)))
 	})
-	if len(tool.TransferTaskManager.GetAll()) == 0 { //prevent offline downloaded files from being deleted
+	pausedDownloads := tool.DownloadTaskManager.GetByCondition(func(t *tool.DownloadTask) bool {
+		return t.IsPaused()
+	})
+	if len(tool.TransferTaskManager.GetAll()) == 0 && len(pausedDownloads) == 0 { //prevent offline downloaded and partially downloaded files from being deleted
 		CleanTempDir()
 	}
-	fs.ArchiveDownloadTaskManager = tache.NewManager[*fs.ArchiveDownloadTask](tache.WithWorks(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant), db.UpdateTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Decompress.MaxRetry))
+	fs.ArchiveDownloadTaskManager = task.NewPriorityManager[*fs.ArchiveDownloadTask]("decompress", setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant), db.UpdateTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Decompress.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveDownloadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)))
 	})
-	fs.ArchiveContentUploadTaskManager.Manager = tache.NewManager[*fs.ArchiveContentUploadTask](tache.WithWorks(setting.GetInt(conf.TaskDecompressUploadThreadsNum, conf.Conf.Tasks.DecompressUpload.Workers)), tache.WithMaxRetry(conf.Conf.Tasks.DecompressUpload.MaxRetry)) //decompress upload will not support persist
+	fs.ArchiveContentUploadTaskManager.PriorityManager = task.NewPriorityManager[*fs.ArchiveContentUploadTask]("decompress_upload", setting.GetInt(conf.TaskDecompressUploadThreadsNum, conf.Conf.Tasks.DecompressUpload.Workers), tache.WithMaxRetry(conf.Conf.Tasks.DecompressUpload.MaxRetry)) //decompress upload will not support persist
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveContentUploadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressUploadThreadsNum, conf.Conf.Tasks.DecompressUpload.Workers)))
 	})
+	fs.ArchiveCompressTaskManager = task.NewPriorityManager[*fs.ArchiveCompressTask]("compress", setting.GetInt(conf.TaskCompressThreadsNum, conf.Conf.Tasks.Compress.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("compress", conf.Conf.Tasks.Compress.TaskPersistant), db.UpdateTaskDataFunc("compress", conf.Conf.Tasks.Compress.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Compress.MaxRetry))
+	op.RegisterSettingChangingCallback(func() {
+		fs.ArchiveCompressTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskCompressThreadsNum, conf.Conf.Tasks.Compress.Workers)))
+	})
+	fs.SyncTaskManager = task.NewPriorityManager[*fs.SyncTask]("sync", setting.GetInt(conf.TaskSyncThreadsNum, conf.Conf.Tasks.Sync.Workers), tache.WithPersistFunction(db.GetTaskDataFunc("sync", conf.Conf.Tasks.Sync.TaskPersistant), db.UpdateTaskDataFunc("sync", conf.Conf.Tasks.Sync.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Sync.MaxRetry))
+	op.RegisterSettingChangingCallback(func() {
+		fs.SyncTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskSyncThreadsNum, conf.Conf.Tasks.Sync.Workers)))
+	})
+	metrics.RegisterTaskManager("upload", fs.UploadTaskManager.Manager)
+	metrics.RegisterTaskManager("copy", fs.CopyTaskManager.Manager)
+	metrics.RegisterTaskManager("move", fs.MoveTaskManager.Manager)
+	metrics.RegisterTaskManager("offline_download", tool.DownloadTaskManager.Manager)
+	metrics.RegisterTaskManager("offline_download_transfer", tool.TransferTaskManager.Manager)
+	metrics.RegisterTaskManager("decompress", fs.ArchiveDownloadTaskManager.Manager)
+	metrics.RegisterTaskManager("decompress_upload", fs.ArchiveContentUploadTaskManager.PriorityManager.Manager)
+	metrics.RegisterTaskManager("compress", fs.ArchiveCompressTaskManager.Manager)
+	metrics.RegisterTaskManager("sync", fs.SyncTaskManager.Manager)
 }
@@ -72,7 +72,12 @@ type TasksConfig struct {
 	Move               TaskConfig `json:"move" envPrefix:"MOVE_"`
 	Decompress         TaskConfig `json:"decompress" envPrefix:"DECOMPRESS_"`
 	DecompressUpload   TaskConfig `json:"decompress_upload" envPrefix:"DECOMPRESS_UPLOAD_"`
+	Compress           TaskConfig `json:"compress" envPrefix:"COMPRESS_"`
+	Sync               TaskConfig `json:"sync" envPrefix:"SYNC_"`
 	AllowRetryCanceled bool       `json:"allow_retry_canceled" env:"ALLOW_RETRY_CANCELED"`
+	// the copy and move tasks download the file into the temp dir before uploading it,
+	// so a paused or retried task reads the src from the byte it stopped at
+	ResumableTransfer bool `json:"resumable_transfer" env:"RESUMABLE_TRANSFER"`
 }
 
 type Cors struct {
@@ -85,8 +90,19 @@ type S3 struct {
 	Enable bool `json:"enable" env:"ENABLE"`
 	Port   int  `json:"port" env:"PORT"`
 	SSL    bool `json:"ssl" env:"SSL"`
+	// the max size in MB of the parts of the multipart uploads spooled in the temp dir, 0 means unlimited.
+	// The instances sharing the database share the limit
+	MultipartSpoolLimit int64 `json:"multipart_spool_limit" env:"MULTIPART_SPOOL_LIMIT"`
 }
 
+// S3MultipartDir is the dir in the temp dir the parts of the multipart uploads are spooled in,
+// it's kept when the temp dir is cleaned at startup since the uploads are kept in the database
+const S3MultipartDir = "s3-multipart"
+
+// TransferSpoolDir is the dir in the temp dir the files of the resumable copy and move tasks are
+// downloaded to, it's kept when the temp dir is cleaned at startup since the tasks may be recovered
+const TransferSpoolDir = "transfer-spool"
+
 type FTP struct {
 	Enable                  bool   `json:"enable" env:"ENABLE"`
 	Listen                  string `json:"listen" env:"LISTEN"`
@@ -105,12 +121,25 @@ type SFTP struct {
 	Listen string `json:"listen" env:"LISTEN"`
 }
 
+type WebDAV struct {
+	// "database" shares the locks between the instances and keeps them over restarts, "memory" doesn't
+	LockSystem string `json:"lock_system" env:"LOCK_SYSTEM"`
+}
+
+type Metrics struct {
+	Enable bool   `json:"enable" env:"ENABLE"`
+	Listen string `json:"listen" env:"LISTEN"` // serve on the main http server behind the admin authentication if empty
+}
+
 type Config struct {
 	Force                 bool        `json:"force" env:"FORCE"`
 	SiteURL               string      `json:"site_url" env:"SITE_URL"`
 	Cdn                   string      `json:"cdn" env:"CDN"`
 	JwtSecret             string      `json:"jwt_secret" env:"JWT_SECRET"`
 	TokenExpiresIn        int         `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
+	RefreshTokenExpiresIn int         `json:"refresh_token_expires_in" env:"REFRESH_TOKEN_EXPIRES_IN"`
+	EncryptionKey         string      `json:"encryption_key" env:"ENCRYPTION_KEY"`
+	EncryptionKeyFile     string      `json:"encryption_key_file" env:"ENCRYPTION_KEY_FILE"`
 	Database              Database    `json:"database" envPrefix:"DB_"`
 	Meilisearch           Meilisearch `json:"meilisearch" envPrefix:"MEILISEARCH_"`
 	Scheme                Scheme      `json:"scheme"`
@@ -128,6 +157,8 @@ type Config struct {
 	S3                    S3          `json:"s3" envPrefix:"S3_"`
 	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
 	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
+	WebDAV                WebDAV      `json:"webdav" envPrefix:"WEBDAV_"`
+	Metrics               Metrics     `json:"metrics" envPrefix:"METRICS_"`
 	LastLaunchedVersion   string      `json:"last_launched_version"`
 }
 
@@ -146,9 +177,10 @@ func DefaultConfig(dataDir string) *Config {
 			CertFile:   "",
 			KeyFile:    "",
 		},
-		JwtSecret:      random.String(16),
-		TokenExpiresIn: 48,
-		TempDir:        tempDir,
+		JwtSecret:             random.String(16),
+		TokenExpiresIn:        48,
+		RefreshTokenExpiresIn: 720,
+		TempDir:               tempDir,
 		Database: Database{
 			Type:        "sqlite3",
 			Port:        0,
@@ -212,7 +244,18 @@ func DefaultConfig(dataDir string) *Config {
 				Workers:  5,
 				MaxRetry: 2,
 			},
+			Compress: TaskConfig{
+				Workers:  5,
+				MaxRetry: 2,
+				// TaskPersistant: true,
+			},
+			Sync: TaskConfig{
+				Workers:  2,
+				MaxRetry: 2,
+				// TaskPersistant: true,
+			},
 			AllowRetryCanceled: false,
+			ResumableTransfer:  false,
 		},
 		Cors: Cors{
 			AllowOrigins: []string{"*"},
@@ -220,9 +263,10 @@ func DefaultConfig(dataDir string) *Config {
 			AllowHeaders: []string{"*"},
 		},
 		S3: S3{
-			Enable: false,
-			Port:   5246,
-			SSL:    false,
+			Enable:              false,
+			Port:                5246,
+			SSL:                 false,
+			MultipartSpoolLimit: 64 * 1024,
 		},
 		FTP: FTP{
 			Enable:                  false,
@@ -240,6 +284,13 @@ func DefaultConfig(dataDir string) *Config {
 			Enable: false,
 			Listen: ":5222",
 		},
+		WebDAV: WebDAV{
+			LockSystem: "database",
+		},
+		Metrics: Metrics{
+			Enable: false,
+			Listen: "",
+		},
 		LastLaunchedVersion: "",
 	}
 }
@@ -35,6 +35,7 @@ const (
 	FilterReadMeScripts      = "filter_readme_scripts"
 	// global
 	HideFiles               = "hide_files"
+	PackageDownload         = "package_download"
 	CustomizeHead           = "customize_head"
 	CustomizeBody           = "customize_body"
 	LinkExpiration          = "link_expiration"
@@ -45,6 +46,7 @@ const (
 	ForwardDirectLinkParams = "forward_direct_link_params"
 	IgnoreDirectLinkParams  = "ignore_direct_link_params"
 	WebauthnLoginEnabled    = "webauthn_login_enabled"
+	RecycleBinRetentionDays = "recycle_bin_retention_days"
 
 	// index
 	SearchIndex     = "search_index"
@@ -135,10 +137,22 @@ const (
 	TaskMoveThreadsNum                    = "move_task_threads_num"
 	TaskDecompressDownloadThreadsNum      = "decompress_download_task_threads_num"
 	TaskDecompressUploadThreadsNum        = "decompress_upload_task_threads_num"
+	TaskCompressThreadsNum                = "compress_task_threads_num"
+	TaskSyncThreadsNum                    = "sync_task_threads_num"
 	StreamMaxClientDownloadSpeed          = "max_client_download_speed"
 	StreamMaxClientUploadSpeed            = "max_client_upload_speed"
 	StreamMaxServerDownloadSpeed          = "max_server_download_speed"
 	StreamMaxServerUploadSpeed            = "max_server_upload_speed"
+
+	// audit
+	AuditLogEnabled       = "audit_log_enabled"
+	AuditLogDownload      = "audit_log_download"
+	AuditLogRetentionDays = "audit_log_retention_days"
+
+	// webhook
+	WebhookTimeout          = "webhook_timeout"
+	WebhookMaxRetry         = "webhook_max_retry"
+	WebhookLogRetentionDays = "webhook_log_retention_days"
 )
 
 const (
@@ -167,4 +181,10 @@ const (
 	RequestHeaderKey
 	UserAgentKey
 	PathKey
+	ShareKey
+	ProtocolKey
+	NoRecycleKey
+	NoUsageKey
+	VerifyTransferKey
+	ConflictPolicyKey
 )
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetAPITokensByUserId(userId uint, pageIndex, pageSize int) (tokens []model.APIToken, count int64, err error) {
+	tokenDB := db.Model(&model.APIToken{})
+	query := model.APIToken{UserID: userId}
+	if err := tokenDB.Where(query).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's api tokens count")
+	}
+	if err := tokenDB.Where(query).Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&tokens).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's api tokens")
+	}
+	return tokens, count, nil
+}
+
+func GetAPITokenById(id uint) (*model.APIToken, error) {
+	var t model.APIToken
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get api token")
+	}
+	return &t, nil
+}
+
+func GetAPITokenByKeyID(keyID string) (*model.APIToken, error) {
+	t := model.APIToken{KeyID: keyID}
+	if err := db.Where(t).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get api token")
+	}
+	return &t, nil
+}
+
+func CreateAPIToken(t *model.APIToken) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+// UpdateAPITokenLastUsed only saves the last used time and ip, the token may be revoked meanwhile
+func UpdateAPITokenLastUsed(t *model.APIToken) error {
+	return errors.WithStack(db.Model(&model.APIToken{ID: t.ID}).Updates(map[string]any{
+		"last_used_time": t.LastUsedTime,
+		"last_used_ip":   t.LastUsedIP,
+	}).Error)
+}
+
+func DeleteAPITokenById(id uint) error {
+	return errors.WithStack(db.Delete(&model.APIToken{}, id).Error)
+}
+
+func DeleteAPITokensByUserId(userId uint) error {
+	return errors.WithStack(db.Where(model.APIToken{UserID: userId}).Delete(&model.APIToken{}).Error)
+}
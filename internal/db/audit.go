@@ -0,0 +1,65 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateAuditLogs(logs []model.AuditLog) error {
+	return errors.WithStack(db.CreateInBatches(logs, 500).Error)
+}
+
+func whereAudit(q model.AuditQuery) *gorm.DB {
+	auditDB := db.Model(&model.AuditLog{})
+	if q.Username != "" {
+		auditDB = auditDB.Where(columnName("username")+" = ?", q.Username)
+	}
+	if q.Operation != "" {
+		auditDB = auditDB.Where(columnName("operation")+" = ?", q.Operation)
+	}
+	if q.Protocol != "" {
+		auditDB = auditDB.Where(columnName("protocol")+" = ?", q.Protocol)
+	}
+	if q.IP != "" {
+		auditDB = auditDB.Where(columnName("ip")+" = ?", q.IP)
+	}
+	if q.Path != "" && q.Path != "/" {
+		auditDB = auditDB.Where(fmt.Sprintf("(%s = ? OR %s LIKE ? OR %s = ? OR %s LIKE ?)",
+			columnName("src_path"), columnName("src_path"), columnName("dst_path"), columnName("dst_path")),
+			q.Path, q.Path+"/%", q.Path, q.Path+"/%")
+	}
+	if !q.Start.IsZero() {
+		auditDB = auditDB.Where(columnName("time")+" >= ?", q.Start)
+	}
+	if !q.End.IsZero() {
+		auditDB = auditDB.Where(columnName("time")+" <= ?", q.End)
+	}
+	return auditDB
+}
+
+func GetAuditLogs(q model.AuditQuery, pageIndex, pageSize int) (logs []model.AuditLog, count int64, err error) {
+	if err = whereAudit(q).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit logs count")
+	}
+	if err = whereAudit(q).Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find audit logs")
+	}
+	return logs, count, nil
+}
+
+// WalkAuditLogs calls fn with the matched logs in ascending order, batch by batch
+func WalkAuditLogs(q model.AuditQuery, fn func(logs []model.AuditLog) error) error {
+	var logs []model.AuditLog
+	return errors.WithStack(whereAudit(q).FindInBatches(&logs, 1000, func(tx *gorm.DB, batch int) error {
+		return fn(logs)
+	}).Error)
+}
+
+func DeleteAuditLogsBefore(t time.Time) (int64, error) {
+	res := db.Where(columnName("time")+" < ?", t).Delete(&model.AuditLog{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
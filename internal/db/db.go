@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.Share), new(model.ShareDownload), new(model.UserUsage), new(model.UsageRecord), new(model.AuditLog), new(model.RecycleItem), new(model.Webhook), new(model.WebhookDelivery), new(model.ScheduledJob), new(model.ScheduledJobRun), new(model.Group), new(model.GroupMember), new(model.ACL), new(model.APIToken), new(model.Session), new(model.DeadProp), new(model.WebDAVLock), new(model.WebDAVLockGuard), new(model.S3Key), new(model.S3Bucket), new(model.S3MultipartUpload), new(model.S3MultipartPart))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
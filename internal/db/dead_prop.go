@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// whereDeadPropsUnder matches the dead props of the path and of the objects in it
+func whereDeadPropsUnder(tx *gorm.DB, path string) *gorm.DB {
+	return tx.Where(fmt.Sprintf("%s = ? OR %s LIKE ?", columnName("path"), columnName("path")),
+		path, fmt.Sprintf("%s/%%", path))
+}
+
+func GetDeadPropsByPath(path string) ([]model.DeadProp, error) {
+	var props []model.DeadProp
+	if err := db.Where(columnName("path")+" = ?", path).Order(columnName("id")).Find(&props).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find dead props")
+	}
+	return props, nil
+}
+
+// PatchDeadProps removes the dead props named by remove and sets the ones in set of the path
+// in a transaction
+func PatchDeadProps(path string, set, remove []model.DeadProp) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		for _, p := range append(remove, set...) {
+			if err := tx.Where(fmt.Sprintf("%s = ? AND %s = ? AND %s = ?",
+				columnName("path"), columnName("space"), columnName("local")),
+				path, p.Space, p.Local).Delete(&model.DeadProp{}).Error; err != nil {
+				return err
+			}
+		}
+		for i := range set {
+			set[i].ID = 0
+			set[i].Path = path
+		}
+		if len(set) == 0 {
+			return nil
+		}
+		return tx.Create(&set).Error
+	}))
+}
+
+// DeleteDeadPropsByPath deletes the dead props of the path and of the objects in it
+func DeleteDeadPropsByPath(path string) error {
+	return errors.WithStack(deleteDeadProps(db, path, true))
+}
+
+// MoveDeadProps moves the dead props of src to dst, replacing the ones of dst,
+// the objects in them are included if recursive
+func MoveDeadProps(src, dst string, recursive bool) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		props, err := findDeadProps(tx, src, recursive)
+		if err != nil || len(props) == 0 {
+			return err
+		}
+		if err = deleteDeadProps(tx, dst, recursive); err != nil {
+			return err
+		}
+		for _, p := range props {
+			if err = tx.Model(&p).Update("path", dst+p.Path[len(src):]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// CopyDeadProps copies the dead props of src to dst, replacing the ones of dst,
+// the objects in them are included if recursive
+func CopyDeadProps(src, dst string, recursive bool) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		props, err := findDeadProps(tx, src, recursive)
+		if err != nil || len(props) == 0 {
+			return err
+		}
+		if err = deleteDeadProps(tx, dst, recursive); err != nil {
+			return err
+		}
+		for i := range props {
+			props[i].ID = 0
+			props[i].Path = dst + props[i].Path[len(src):]
+		}
+		return tx.CreateInBatches(&props, 100).Error
+	}))
+}
+
+func findDeadProps(tx *gorm.DB, path string, recursive bool) ([]model.DeadProp, error) {
+	var props []model.DeadProp
+	if !recursive {
+		err := tx.Where(columnName("path")+" = ?", path).Find(&props).Error
+		return props, err
+	}
+	if err := whereDeadPropsUnder(tx, path).Find(&props).Error; err != nil {
+		return nil, err
+	}
+	// LIKE also matches the paths with wildcards in place of % and _
+	matched := props[:0]
+	for _, p := range props {
+		if utils.IsSubPath(path, p.Path) {
+			matched = append(matched, p)
+		}
+	}
+	return matched, nil
+}
+
+func deleteDeadProps(tx *gorm.DB, path string, recursive bool) error {
+	props, err := findDeadProps(tx, path, recursive)
+	if err != nil || len(props) == 0 {
+		return err
+	}
+	return tx.Delete(&props).Error
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetGroupById(id uint) (*model.Group, error) {
+	var g model.Group
+	if err := db.First(&g, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group")
+	}
+	if err := db.Model(&model.GroupMember{}).Where("group_id = ?", id).Pluck("user_id", &g.UserIds).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group members")
+	}
+	return &g, nil
+}
+
+func GetGroups(pageIndex, pageSize int) (groups []model.Group, count int64, err error) {
+	groupDB := db.Model(&model.Group{})
+	if err = groupDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get groups count")
+	}
+	if err = groupDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&groups).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find groups")
+	}
+	for i := range groups {
+		if err = db.Model(&model.GroupMember{}).Where("group_id = ?", groups[i].ID).Pluck("user_id", &groups[i].UserIds).Error; err != nil {
+			return nil, 0, errors.Wrapf(err, "failed get group members")
+		}
+	}
+	return groups, count, nil
+}
+
+func setGroupMembers(tx *gorm.DB, g *model.Group) error {
+	if err := tx.Where("group_id = ?", g.ID).Delete(&model.GroupMember{}).Error; err != nil {
+		return err
+	}
+	for _, uid := range g.UserIds {
+		if err := tx.Create(&model.GroupMember{GroupID: g.ID, UserID: uid}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func CreateGroup(g *model.Group) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(g).Error; err != nil {
+			return err
+		}
+		return setGroupMembers(tx, g)
+	}))
+}
+
+func UpdateGroup(g *model.Group) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(g).Error; err != nil {
+			return err
+		}
+		return setGroupMembers(tx, g)
+	}))
+}
+
+// DeleteGroupById deletes the group with its members and acl entries
+func DeleteGroupById(id uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&model.GroupMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("group_id = ?", id).Delete(&model.ACL{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Group{}, id).Error
+	}))
+}
+
+// DeleteUserGroupsAndACLs removes the deleted user from the groups and its acl entries
+func DeleteUserGroupsAndACLs(userId uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&model.GroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userId).Delete(&model.ACL{}).Error
+	}))
+}
+
+func GetAllGroupMembers() (members []model.GroupMember, err error) {
+	if err = db.Find(&members).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get group members")
+	}
+	return members, nil
+}
+
+func GetAllACLs() (acls []model.ACL, err error) {
+	if err = db.Find(&acls).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get acls")
+	}
+	return acls, nil
+}
+
+func GetACLs(pageIndex, pageSize int) (acls []model.ACL, count int64, err error) {
+	aclDB := db.Model(&model.ACL{})
+	if err = aclDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get acls count")
+	}
+	if err = aclDB.Order(columnName("path")).Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&acls).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find acls")
+	}
+	return acls, count, nil
+}
+
+func GetACLById(id uint) (*model.ACL, error) {
+	var a model.ACL
+	if err := db.First(&a, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get acl")
+	}
+	return &a, nil
+}
+
+func CreateACL(a *model.ACL) error {
+	return errors.WithStack(db.Create(a).Error)
+}
+
+func UpdateACL(a *model.ACL) error {
+	return errors.WithStack(db.Save(a).Error)
+}
+
+func DeleteACLById(id uint) error {
+	return errors.WithStack(db.Delete(&model.ACL{}, id).Error)
+}
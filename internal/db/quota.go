@@ -0,0 +1,147 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetUserUsage(userId uint) (*model.UserUsage, error) {
+	usage := model.UserUsage{UserId: userId}
+	if err := db.Where(usage).Limit(1).Find(&usage).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get user usage")
+	}
+	return &usage, nil
+}
+
+func GetUserUsages(userIds []uint) (usages []model.UserUsage, err error) {
+	if err = db.Where(columnName("user_id")+" IN ?", userIds).Find(&usages).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get user usages")
+	}
+	return usages, nil
+}
+
+// AddUserUsage adds the deltas, which are negative for the released usage, to the usage of the
+// user, it never goes below 0
+func AddUserUsage(userId uint, bytes, files int64) error {
+	return errors.WithStack(addUserUsage(db, userId, bytes, files))
+}
+
+func addUserUsage(tx *gorm.DB, userId uint, bytes, files int64) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"used_bytes": addNonNegative("used_bytes", bytes),
+			"used_files": addNonNegative("used_files", files),
+		}),
+	}).Create(&model.UserUsage{UserId: userId, UsedBytes: max(bytes, 0), UsedFiles: max(files, 0)}).Error
+}
+
+func addNonNegative(column string, delta int64) clause.Expr {
+	column = columnName(column)
+	return gorm.Expr("CASE WHEN "+column+" + ? < 0 THEN 0 ELSE "+column+" + ? END", delta, delta)
+}
+
+func DeleteUserUsage(userId uint) error {
+	return errors.WithStack(db.Delete(&model.UserUsage{}, userId).Error)
+}
+
+// getUsageRecords returns the records of the path and the paths under it
+func getUsageRecords(tx *gorm.DB, storageId uint, path string) ([]model.UsageRecord, error) {
+	var like strings.Builder
+	writeLikeLiteral(&like, strings.TrimSuffix(path, "/")+"/")
+	like.WriteByte('%')
+	var records []model.UsageRecord
+	err := tx.Where(columnName("storage_id")+" = ?", storageId).
+		Where(columnName("path")+" = ? OR "+columnName("path")+" LIKE ? ESCAPE '!'", path, like.String()).
+		Find(&records).Error
+	return records, err
+}
+
+// addUsageOfRecords adds the records, multiplied by sign, to the usages of their users
+func addUsageOfRecords(tx *gorm.DB, records []model.UsageRecord, sign int64) error {
+	if sign == 0 {
+		return nil
+	}
+	type usage struct{ bytes, files int64 }
+	usages := make(map[uint]usage)
+	for _, r := range records {
+		u := usages[r.UserId]
+		usages[r.UserId] = usage{bytes: u.bytes + r.Bytes, files: u.files + 1}
+	}
+	for userId, u := range usages {
+		if err := addUserUsage(tx, userId, sign*u.bytes, sign*u.files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordUsage accounts the uploaded file to the user, replacing the record of the file it
+// overwrites, which is released from the user who uploaded that one
+func RecordUsage(storageId uint, path string, userId uint, bytes int64) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		var old []model.UsageRecord
+		if err := tx.Where(model.UsageRecord{StorageId: storageId, Path: path}).Find(&old).Error; err != nil {
+			return err
+		}
+		if err := addUsageOfRecords(tx, old, -1); err != nil {
+			return err
+		}
+		if len(old) > 0 {
+			if err := tx.Delete(&old).Error; err != nil {
+				return err
+			}
+		}
+		if userId == 0 {
+			return nil
+		}
+		record := model.UsageRecord{StorageId: storageId, Path: path, UserId: userId, Bytes: bytes}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		return addUserUsage(tx, userId, bytes, 1)
+	}))
+}
+
+// DeleteUsageRecords deletes the records of the path and the paths under it, releasing them from
+// the usages of their users if release is set
+func DeleteUsageRecords(storageId uint, path string, release bool) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		records, err := getUsageRecords(tx, storageId, path)
+		if err != nil || len(records) == 0 {
+			return err
+		}
+		if release {
+			if err = addUsageOfRecords(tx, records, -1); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&records).Error
+	}))
+}
+
+// MoveUsageRecords moves the records of the path and the paths under it to dstPath, the records
+// multiplied by sign are added to the usages of their users, which is -1 for moving into the
+// recycle bin and 1 for moving out of it
+func MoveUsageRecords(storageId uint, srcPath, dstPath string, sign int64) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		records, err := getUsageRecords(tx, storageId, srcPath)
+		if err != nil || len(records) == 0 {
+			return err
+		}
+		for _, r := range records {
+			if err = tx.Model(&r).Update("path", dstPath+strings.TrimPrefix(r.Path, srcPath)).Error; err != nil {
+				return err
+			}
+		}
+		return addUsageOfRecords(tx, records, sign)
+	}))
+}
+
+func DeleteUsageRecordsByUserId(userId uint) error {
+	return errors.WithStack(db.Where(columnName("user_id")+" = ?", userId).Delete(&model.UsageRecord{}).Error)
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateRecycleItem(item *model.RecycleItem) error {
+	return errors.WithStack(db.Create(item).Error)
+}
+
+func GetRecycleItemById(id uint) (*model.RecycleItem, error) {
+	var item model.RecycleItem
+	if err := db.First(&item, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get recycle item")
+	}
+	return &item, nil
+}
+
+func GetRecycleItems(pageIndex, pageSize int) (items []model.RecycleItem, count int64, err error) {
+	itemDB := db.Model(&model.RecycleItem{})
+	if err = itemDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get recycle items count")
+	}
+	if err = itemDB.Order(columnName("deleted") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find recycle items")
+	}
+	return items, count, nil
+}
+
+func GetRecycleItemsByUserId(userId uint, pageIndex, pageSize int) (items []model.RecycleItem, count int64, err error) {
+	itemDB := db.Model(&model.RecycleItem{})
+	query := model.RecycleItem{UserId: userId}
+	if err = itemDB.Where(query).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's recycle items count")
+	}
+	if err = itemDB.Where(query).Order(columnName("deleted") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's recycle items")
+	}
+	return items, count, nil
+}
+
+func GetRecycleItemsBefore(t time.Time) (items []model.RecycleItem, err error) {
+	if err = db.Where(columnName("deleted")+" < ?", t).Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find expired recycle items")
+	}
+	return items, nil
+}
+
+func DeleteRecycleItemById(id uint) error {
+	return errors.WithStack(db.Delete(&model.RecycleItem{}, id).Error)
+}
+
+func DeleteRecycleItemsByMountPath(mountPath string) error {
+	return errors.WithStack(db.Where(columnName("mount_path")+" = ?", mountPath).Delete(&model.RecycleItem{}).Error)
+}
@@ -0,0 +1,255 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetS3KeysByUserId(userId uint, pageIndex, pageSize int) (keys []model.S3Key, count int64, err error) {
+	keyDB := db.Model(&model.S3Key{})
+	query := model.S3Key{UserID: userId}
+	if err := keyDB.Where(query).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's s3 keys count")
+	}
+	if err := keyDB.Where(query).Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&keys).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's s3 keys")
+	}
+	return keys, count, nil
+}
+
+func GetS3KeyById(id uint) (*model.S3Key, error) {
+	var k model.S3Key
+	if err := db.First(&k, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 key")
+	}
+	return &k, nil
+}
+
+func GetS3KeyByAccessKeyID(accessKeyID string) (*model.S3Key, error) {
+	k := model.S3Key{AccessKeyID: accessKeyID}
+	if err := db.Where(k).First(&k).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 key")
+	}
+	return &k, nil
+}
+
+func CreateS3Key(k *model.S3Key) error {
+	return errors.WithStack(db.Create(k).Error)
+}
+
+// UpdateS3KeyLastUsed only saves the last used time and ip, the key may be deleted meanwhile
+func UpdateS3KeyLastUsed(k *model.S3Key) error {
+	return errors.WithStack(db.Model(&model.S3Key{ID: k.ID}).Updates(map[string]any{
+		"last_used_time": k.LastUsedTime,
+		"last_used_ip":   k.LastUsedIP,
+	}).Error)
+}
+
+func DeleteS3KeyById(id uint) error {
+	return errors.WithStack(db.Delete(&model.S3Key{}, id).Error)
+}
+
+// GetS3Secrets returns the s3 keys and the api tokens with the secrets of s3
+func GetS3Secrets() (keys []model.S3Key, tokens []model.APIToken, err error) {
+	if err := db.Order(columnName("id")).Find(&keys).Error; err != nil {
+		return nil, nil, errors.Wrapf(err, "failed find s3 keys")
+	}
+	if err := db.Order(columnName("id")).Find(&tokens).Error; err != nil {
+		return nil, nil, errors.Wrapf(err, "failed find api tokens")
+	}
+	return keys, tokens, nil
+}
+
+// UpdateS3Secrets only saves the secrets of the s3 keys and the api tokens, in one transaction
+func UpdateS3Secrets(keys []model.S3Key, tokens []model.APIToken) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, k := range keys {
+			if err := tx.Model(&model.S3Key{ID: k.ID}).Update("secret", k.Secret).Error; err != nil {
+				return errors.Wrapf(err, "failed update secret of s3 key %s", k.AccessKeyID)
+			}
+		}
+		for _, t := range tokens {
+			if err := tx.Model(&model.APIToken{ID: t.ID}).Update("s3_secret", t.S3Secret).Error; err != nil {
+				return errors.Wrapf(err, "failed update s3 secret of api token %s", t.KeyID)
+			}
+		}
+		return nil
+	})
+}
+
+func GetS3BucketsByUserId(userId uint) (buckets []model.S3Bucket, err error) {
+	if err := db.Where(model.S3Bucket{UserID: userId}).Order(columnName("id")).Find(&buckets).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find user's s3 buckets")
+	}
+	return buckets, nil
+}
+
+func GetS3BucketById(id uint) (*model.S3Bucket, error) {
+	var b model.S3Bucket
+	if err := db.First(&b, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 bucket")
+	}
+	return &b, nil
+}
+
+func GetS3BucketByUserName(userId uint, name string) (*model.S3Bucket, error) {
+	b := model.S3Bucket{UserID: userId, Name: name}
+	if err := db.Where(b).First(&b).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 bucket")
+	}
+	return &b, nil
+}
+
+func CreateS3Bucket(b *model.S3Bucket) error {
+	return errors.WithStack(db.Create(b).Error)
+}
+
+func UpdateS3Bucket(b *model.S3Bucket) error {
+	return errors.WithStack(db.Save(b).Error)
+}
+
+func DeleteS3BucketById(id uint) error {
+	return errors.WithStack(db.Delete(&model.S3Bucket{}, id).Error)
+}
+
+// DeleteS3ByUserId deletes the s3 keys and buckets of the user
+func DeleteS3ByUserId(userId uint) error {
+	if err := db.Where(model.S3Key{UserID: userId}).Delete(&model.S3Key{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Where(model.S3Bucket{UserID: userId}).Delete(&model.S3Bucket{}).Error)
+}
+
+func CreateS3MultipartUpload(u *model.S3MultipartUpload) error {
+	return errors.WithStack(db.Create(u).Error)
+}
+
+func GetS3MultipartUpload(id string) (*model.S3MultipartUpload, error) {
+	var u model.S3MultipartUpload
+	if err := db.Where(columnName("id")+" = ?", id).First(&u).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get s3 multipart upload")
+	}
+	return &u, nil
+}
+
+// GetS3MultipartUploads returns the uploads of the user in the bucket, except the ones completing
+func GetS3MultipartUploads(bucket string, userId uint) (uploads []model.S3MultipartUpload, err error) {
+	if err := db.Where(fmt.Sprintf("%s = ? AND %s = ? AND %s = ?", columnName("bucket"), columnName("user_id"), columnName("completing")),
+		bucket, userId, false).Find(&uploads).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 multipart uploads")
+	}
+	return uploads, nil
+}
+
+// GetAllS3MultipartUploads returns the uploads active before activeBefore, or all if it's 0
+func GetAllS3MultipartUploads(activeBefore int64) (uploads []model.S3MultipartUpload, err error) {
+	uploadDB := db.Model(&model.S3MultipartUpload{})
+	if activeBefore > 0 {
+		uploadDB = uploadDB.Where(columnName("active")+" < ?", activeBefore)
+	}
+	if err := uploadDB.Find(&uploads).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 multipart uploads")
+	}
+	return uploads, nil
+}
+
+// TouchS3MultipartUpload sets the active time of the upload
+func TouchS3MultipartUpload(id string, active int64) error {
+	return errors.WithStack(db.Model(&model.S3MultipartUpload{}).Where(columnName("id")+" = ?", id).
+		Update("active", active).Error)
+}
+
+// SetS3MultipartUploadCompleting marks the upload completing or not, it reports false if the
+// upload is gone or marked so already
+func SetS3MultipartUploadCompleting(id string, completing bool, active int64) (bool, error) {
+	res := db.Model(&model.S3MultipartUpload{}).
+		Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("id"), columnName("completing")), id, !completing).
+		Updates(map[string]any{"completing": completing, "active": active})
+	return res.RowsAffected > 0, errors.WithStack(res.Error)
+}
+
+// ResetStaleS3MultipartCompletions unmarks the uploads completing but not active since activeBefore
+func ResetStaleS3MultipartCompletions(activeBefore int64) error {
+	return errors.WithStack(db.Model(&model.S3MultipartUpload{}).
+		Where(fmt.Sprintf("%s = ? AND %s < ?", columnName("completing"), columnName("active")), true, activeBefore).
+		Update("completing", false).Error)
+}
+
+// DeleteS3MultipartUpload deletes the upload and its parts if it's completing or not as given,
+// and if it's active before activeBefore unless it's 0. It reports whether the upload is deleted.
+func DeleteS3MultipartUpload(id string, completing bool, activeBefore int64) (bool, error) {
+	deleted := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		uploadDB := tx.Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("id"), columnName("completing")), id, completing)
+		if activeBefore > 0 {
+			uploadDB = uploadDB.Where(columnName("active")+" < ?", activeBefore)
+		}
+		res := uploadDB.Delete(&model.S3MultipartUpload{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		deleted = true
+		return tx.Where(columnName("upload_id")+" = ?", id).Delete(&model.S3MultipartPart{}).Error
+	})
+	return deleted, errors.WithStack(err)
+}
+
+// GetS3MultipartParts returns the parts of the upload ordered by the number
+func GetS3MultipartParts(uploadId string) (parts []model.S3MultipartPart, err error) {
+	if err := db.Where(columnName("upload_id")+" = ?", uploadId).Order(columnName("number")).Find(&parts).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find s3 multipart parts")
+	}
+	return parts, nil
+}
+
+// SaveS3MultipartPart saves the part unless the upload is gone or completing, and returns the
+// part of the same number it replaces. The update of the upload row orders it with the completion.
+func SaveS3MultipartPart(part *model.S3MultipartPart, active int64) (saved bool, old *model.S3MultipartPart, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.S3MultipartUpload{}).
+			Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("id"), columnName("completing")), part.UploadID, false).
+			Update("active", active)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		var parts []model.S3MultipartPart
+		if err := tx.Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("upload_id"), columnName("number")), part.UploadID, part.Number).
+			Find(&parts).Error; err != nil {
+			return err
+		}
+		if len(parts) > 0 {
+			old = &parts[0]
+			if err := tx.Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("upload_id"), columnName("number")), part.UploadID, part.Number).
+				Delete(&model.S3MultipartPart{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(part).Error; err != nil {
+			return err
+		}
+		saved = true
+		return nil
+	})
+	if err != nil {
+		return false, nil, errors.WithStack(err)
+	}
+	return saved, old, nil
+}
+
+// DeleteS3MultipartPart deletes the part of the number of the upload
+func DeleteS3MultipartPart(uploadId string, number int) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("upload_id"), columnName("number")), uploadId, number).
+		Delete(&model.S3MultipartPart{}).Error)
+}
+
+// SumS3MultipartPartSize returns the total size of the parts of all the uploads
+func SumS3MultipartPartSize() (int64, error) {
+	var size int64
+	if err := db.Model(&model.S3MultipartPart{}).Select("COALESCE(SUM(" + columnName("size") + "), 0)").Scan(&size).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed sum the size of s3 multipart parts")
+	}
+	return size, nil
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetScheduledJobById(id uint) (*model.ScheduledJob, error) {
+	var j model.ScheduledJob
+	if err := db.First(&j, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get scheduled job")
+	}
+	return &j, nil
+}
+
+func CreateScheduledJob(j *model.ScheduledJob) error {
+	return errors.WithStack(db.Create(j).Error)
+}
+
+func UpdateScheduledJob(j *model.ScheduledJob) error {
+	return errors.WithStack(db.Save(j).Error)
+}
+
+// UpdateScheduledJobResult saves the result of the last run without touching the job settings
+func UpdateScheduledJobResult(j *model.ScheduledJob) error {
+	return errors.WithStack(db.Model(j).Select("last_run_time", "last_success", "last_error").Updates(j).Error)
+}
+
+func GetScheduledJobs(pageIndex, pageSize int) (jobs []model.ScheduledJob, count int64, err error) {
+	jobDB := db.Model(&model.ScheduledJob{})
+	if err = jobDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get scheduled jobs count")
+	}
+	if err = jobDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find scheduled jobs")
+	}
+	return jobs, count, nil
+}
+
+func GetEnabledScheduledJobs() (jobs []model.ScheduledJob, err error) {
+	err = db.Where(columnName("disabled")+" = ?", false).Find(&jobs).Error
+	return jobs, errors.WithStack(err)
+}
+
+func DeleteScheduledJobById(id uint) error {
+	if err := db.Where(columnName("job_id")+" = ?", id).Delete(&model.ScheduledJobRun{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.ScheduledJob{}, id).Error)
+}
+
+// CreateScheduledJobRun saves the run and keeps only the latest keep runs of the job
+func CreateScheduledJobRun(r *model.ScheduledJobRun, keep int) error {
+	if err := db.Create(r).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	var ids []uint
+	err := db.Model(&model.ScheduledJobRun{}).Where(columnName("job_id")+" = ?", r.JobId).
+		Order(columnName("id")+" DESC").Offset(keep).Limit(1).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Where(columnName("job_id")+" = ? AND "+columnName("id")+" <= ?", r.JobId, ids[0]).
+		Delete(&model.ScheduledJobRun{}).Error)
+}
+
+func GetScheduledJobRuns(jobId uint, pageIndex, pageSize int) (runs []model.ScheduledJobRun, count int64, err error) {
+	runDB := db.Model(&model.ScheduledJobRun{}).Where(columnName("job_id")+" = ?", jobId)
+	if err = runDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get scheduled job runs count")
+	}
+	if err = runDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find scheduled job runs")
+	}
+	return runs, count, nil
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"fmt"
 	stdpath "path"
+	"regexp/syntax"
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -35,7 +36,7 @@ func DeleteSearchNodesByParent(path string) error {
 	if err != nil {
 		return err
 	}
-	dir, name := stdpath.Split(path)
+	dir, name := stdpath.Dir(path), stdpath.Base(path)
 	return db.Where(fmt.Sprintf("%s = ? AND %s = ?",
 		columnName("parent"), columnName("name")),
 		dir, name).Delete(&model.SearchNode{}).Error
@@ -77,15 +78,165 @@ func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int6
 		isDir := req.Scope == 1
 		searchDB.Where(db.Where("is_dir = ?", isDir))
 	}
+	searchDB = whereSearchFilters(searchDB, req)
+	order := fmt.Sprintf("%s %s", columnName(req.OrderBy), req.OrderDirection)
+	if req.OrderBy != "name" {
+		order += ", name asc"
+	}
+	searchDB = searchDB.Order(order)
+	if req.Pattern != "" {
+		return searchNodeByPattern(searchDB, req)
+	}
 
 	var count int64
 	if err := searchDB.Count(&count).Error; err != nil {
 		return nil, 0, errors.Wrapf(err, "failed get search items count")
 	}
 	var files []model.SearchNode
-	if err := searchDB.Order("name asc").Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
+	if err := searchDB.Offset((req.Page - 1) * req.PerPage).Limit(req.PerPage).
 		Find(&files).Error; err != nil {
 		return nil, 0, err
 	}
 	return files, count, nil
 }
+
+func whereSearchFilters(searchDB *gorm.DB, req model.SearchReq) *gorm.DB {
+	if req.MinSize > 0 {
+		searchDB = searchDB.Where(fmt.Sprintf("%s >= ?", columnName("size")), req.MinSize)
+	}
+	if req.MaxSize > 0 {
+		searchDB = searchDB.Where(fmt.Sprintf("%s <= ?", columnName("size")), req.MaxSize)
+	}
+	if req.ModifiedAfter != nil {
+		searchDB = searchDB.Where(fmt.Sprintf("%s >= ?", columnName("modified")), *req.ModifiedAfter)
+	}
+	if req.ModifiedBefore != nil {
+		searchDB = searchDB.Where(fmt.Sprintf("%s <= ?", columnName("modified")), *req.ModifiedBefore)
+	}
+	if len(req.Exts) > 0 {
+		searchDB = searchDB.Where(fmt.Sprintf("%s IN ?", columnName("ext")), req.Exts)
+	}
+	if req.Pattern != "" {
+		// narrow down the candidates, the exact match is done by searchNodeByPattern
+		like := globToLike(req.Pattern)
+		if req.PatternType == model.PatternRegex {
+			like = regexToLike(req.Pattern)
+		}
+		if like != "%" {
+			searchDB = searchDB.Where(fmt.Sprintf("%s LIKE ? ESCAPE '!'", columnName("name")), like)
+		}
+	}
+	return searchDB
+}
+
+// globToLike converts a glob pattern to a LIKE pattern escaped with '!'.
+// Character classes are loosened to a single character wildcard.
+func globToLike(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteByte('%')
+		case '?':
+			b.WriteByte('_')
+		case '[':
+			for i < len(pattern) && pattern[i] != ']' {
+				i++
+			}
+			b.WriteByte('_')
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				c = pattern[i]
+			}
+			fallthrough
+		default:
+			writeLikeLiteral(&b, string(c))
+		}
+	}
+	return b.String()
+}
+
+// regexToLike converts a regex matching the whole name to a LIKE pattern
+// escaped with '!', keeping the case-sensitive literals of the top level
+// concatenation in order. Everything else is loosened to '%'.
+func regexToLike(pattern string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "%"
+	}
+	re = re.Simplify()
+	subs := []*syntax.Regexp{re}
+	if re.Op == syntax.OpConcat {
+		subs = re.Sub
+	}
+	var (
+		b    strings.Builder
+		wild bool
+	)
+	wildcard := func() {
+		if !wild {
+			b.WriteByte('%')
+			wild = true
+		}
+	}
+	for _, sub := range subs {
+		switch sub.Op {
+		case syntax.OpLiteral:
+			if sub.Flags&syntax.FoldCase != 0 {
+				wildcard()
+			} else {
+				writeLikeLiteral(&b, string(sub.Rune))
+				wild = false
+			}
+		case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine,
+			syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+			// zero width, the literals around still follow each other
+		default:
+			wildcard()
+		}
+	}
+	if b.Len() == 0 {
+		return "%"
+	}
+	return b.String()
+}
+
+func writeLikeLiteral(b *strings.Builder, s string) {
+	for _, c := range []byte(s) {
+		if c == '%' || c == '_' || c == '!' {
+			b.WriteByte('!')
+		}
+		b.WriteByte(c)
+	}
+}
+
+// searchNodeByPattern walks the candidates narrowed by the LIKE filter of
+// whereSearchFilters and matches the name pattern in go, since regex support
+// differs between databases.
+func searchNodeByPattern(searchDB *gorm.DB, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	rows, err := searchDB.Rows()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var (
+		count  int64
+		offset = int64((req.Page - 1) * req.PerPage)
+		files  []model.SearchNode
+	)
+	for rows.Next() {
+		var node model.SearchNode
+		if err = db.ScanRows(rows, &node); err != nil {
+			return nil, 0, err
+		}
+		if !req.MatchName(node.Name) {
+			continue
+		}
+		if count >= offset && len(files) < req.PerPage {
+			files = append(files, node)
+		}
+		count++
+	}
+	return files, count, rows.Err()
+}
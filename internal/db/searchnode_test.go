@@ -0,0 +1,72 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestSearchNodeFilters(t *testing.T) {
+	now := time.Now()
+	nodes := []model.SearchNode{
+		{Parent: "/media", Name: "a.mp4", Size: 300, Modified: now.Add(-time.Hour), Ext: "mp4"},
+		{Parent: "/media", Name: "b.MKV", Size: 200, Modified: now.Add(-48 * time.Hour), Ext: "mkv"},
+		{Parent: "/media", Name: "c_1.txt", Size: 100, Modified: now, Ext: "txt"},
+		{Parent: "/media/sub", Name: "d.mp4", Size: 50, Modified: now, Ext: "mp4"},
+		{Parent: "/other", Name: "e.mp4", Size: 1000, Modified: now, Ext: "mp4"},
+	}
+	if err := db.BatchCreateSearchNodes(&nodes); err != nil {
+		t.Fatal(err)
+	}
+	after := now.Add(-2 * time.Hour)
+	tests := []struct {
+		name string
+		req  model.SearchReq
+		want []string
+	}{
+		{"size", model.SearchReq{MinSize: 100, MaxSize: 300}, []string{"a.mp4", "b.MKV", "c_1.txt"}},
+		{"modified", model.SearchReq{ModifiedAfter: &after}, []string{"a.mp4", "c_1.txt", "d.mp4"}},
+		{"ext", model.SearchReq{Exts: []string{".MKV", "txt"}}, []string{"b.MKV", "c_1.txt"}},
+		{"glob", model.SearchReq{Pattern: "?_*.txt"}, []string{"c_1.txt"}},
+		{"regex", model.SearchReq{Pattern: `[a-d]\.mp4`, PatternType: model.PatternRegex}, []string{"a.mp4", "d.mp4"}},
+		{"regex literals", model.SearchReq{Pattern: `c_\d+\.txt`, PatternType: model.PatternRegex}, []string{"c_1.txt"}},
+		{"regex fold case", model.SearchReq{Pattern: `(?i)B\.mkv`, PatternType: model.PatternRegex}, []string{"b.MKV"}},
+		{"order", model.SearchReq{OrderBy: "size", OrderDirection: "desc"}, []string{"a.mp4", "b.MKV", "c_1.txt", "d.mp4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			req.Parent = "/media"
+			req.PageReq = model.PageReq{Page: 1, PerPage: 10}
+			if err := req.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			res, total, err := db.SearchNode(req, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != int64(len(tt.want)) || len(res) != len(tt.want) {
+				t.Fatalf("expect %v, got %d: %+v", tt.want, total, res)
+			}
+			for i := range res {
+				if res[i].Name != tt.want[i] {
+					t.Errorf("expect %v, got %+v", tt.want, res)
+				}
+			}
+		})
+	}
+}
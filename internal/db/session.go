@@ -0,0 +1,65 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetSessionsByUserId(userId uint, pageIndex, pageSize int) (sessions []model.Session, count int64, err error) {
+	sessionDB := db.Model(&model.Session{})
+	query := model.Session{UserID: userId}
+	if err := sessionDB.Where(query).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's sessions count")
+	}
+	if err := sessionDB.Where(query).Order(columnName("last_seen") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&sessions).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's sessions")
+	}
+	return sessions, count, nil
+}
+
+func GetSessionById(id uint) (*model.Session, error) {
+	var s model.Session
+	if err := db.First(&s, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get session")
+	}
+	return &s, nil
+}
+
+func GetSessionBySID(sid string) (*model.Session, error) {
+	s := model.Session{SID: sid}
+	if err := db.Where(s).First(&s).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get session")
+	}
+	return &s, nil
+}
+
+func CreateSession(s *model.Session) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func UpdateSession(s *model.Session) error {
+	return errors.WithStack(db.Save(s).Error)
+}
+
+// UpdateSessionLastSeen only saves the last seen time and ip, the session may be revoked meanwhile
+func UpdateSessionLastSeen(s *model.Session) error {
+	return errors.WithStack(db.Model(&model.Session{ID: s.ID}).Updates(map[string]any{
+		"last_seen": s.LastSeen,
+		"ip":        s.IP,
+	}).Error)
+}
+
+func DeleteSessionById(id uint) error {
+	return errors.WithStack(db.Delete(&model.Session{}, id).Error)
+}
+
+func DeleteSessionsByUserId(userId uint) error {
+	return errors.WithStack(db.Where(model.Session{UserID: userId}).Delete(&model.Session{}).Error)
+}
+
+func DeleteExpiredSessions(userId uint) error {
+	return errors.WithStack(db.Where(model.Session{UserID: userId}).
+		Where(columnName("expires_at")+" < ?", time.Now()).Delete(&model.Session{}).Error)
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func GetShareById(id string) (*model.Share, error) {
+	var s model.Share
+	if err := db.Where(columnName("id")+" = ?", id).First(&s).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get share")
+	}
+	return &s, nil
+}
+
+func CreateShare(s *model.Share) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func UpdateShare(s *model.Share) error {
+	return errors.WithStack(db.Save(s).Error)
+}
+
+func GetShares(pageIndex, pageSize int) (shares []model.Share, count int64, err error) {
+	shareDB := db.Model(&model.Share{})
+	if err = shareDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get shares count")
+	}
+	if err = shareDB.Order(columnName("created")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&shares).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find shares")
+	}
+	return shares, count, nil
+}
+
+func GetSharesByUserId(userId uint, pageIndex, pageSize int) (shares []model.Share, count int64, err error) {
+	shareDB := db.Model(&model.Share{})
+	query := model.Share{UserId: userId}
+	if err = shareDB.Where(query).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get user's shares count")
+	}
+	if err = shareDB.Where(query).Order(columnName("created")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&shares).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find user's shares")
+	}
+	return shares, count, nil
+}
+
+// IncreaseShareAccessed counts one access, it returns false if the share is already exhausted
+func IncreaseShareAccessed(id string) (bool, error) {
+	res := db.Model(&model.Share{}).
+		Where(columnName("id")+" = ?", id).
+		Where("("+columnName("max_accessed")+" = 0 OR "+columnName("accessed")+" < "+columnName("max_accessed")+")").
+		Update("accessed", gorm.Expr(columnName("accessed")+" + 1"))
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed update share accessed")
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func DeleteShareById(id string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(columnName("share_id")+" = ?", id).Delete(&model.ShareDownload{}).Error; err != nil {
+			return err
+		}
+		return tx.Where(columnName("id")+" = ?", id).Delete(&model.Share{}).Error
+	}))
+}
+
+// SaveShareDownload creates the download or extends the expiry of it
+func SaveShareDownload(d *model.ShareDownload) error {
+	return errors.WithStack(db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"expiry"}),
+	}).Create(d).Error)
+}
+
+// HasShareDownload reports whether the download is counted and not expired at now
+func HasShareDownload(id string, now int64) (bool, error) {
+	var count int64
+	err := db.Model(&model.ShareDownload{}).
+		Where(columnName("id")+" = ? AND "+columnName("expiry")+" > ?", id, now).
+		Count(&count).Error
+	return count > 0, errors.Wrapf(err, "failed get share download")
+}
+
+func DeleteExpiredShareDownloads(now int64) error {
+	return errors.WithStack(db.Where(columnName("expiry")+" <= ?", now).Delete(&model.ShareDownload{}).Error)
+}
+
+func DeleteSharesByUserId(userId uint) error {
+	return errors.WithStack(db.Where(model.Share{UserId: userId}).Delete(&model.Share{}).Error)
+}
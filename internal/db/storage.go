@@ -5,6 +5,7 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 // why don't need `cache` for storage?
@@ -22,6 +23,18 @@ func UpdateStorage(storage *model.Storage) error {
 	return errors.WithStack(db.Save(storage).Error)
 }
 
+// UpdateStorages updates the storages in database in one transaction
+func UpdateStorages(storages []model.Storage) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		for i := range storages {
+			if err := tx.Save(&storages[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
 // DeleteStorageById just delete storage from database by id
 func DeleteStorageById(id uint) error {
 	return errors.WithStack(db.Delete(&model.Storage{}, id).Error)
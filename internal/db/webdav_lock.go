@@ -0,0 +1,122 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// whereWebDAVLockNotHeld matches the locks not held, or held before staleBefore by a request
+// which may never release them
+func whereWebDAVLockNotHeld(tx *gorm.DB, staleBefore int64) *gorm.DB {
+	return tx.Where(fmt.Sprintf("%s = ? OR %s < ?", columnName("held"), columnName("held_at")), false, staleBefore)
+}
+
+func GetWebDAVLockByToken(token string) (*model.WebDAVLock, error) {
+	var lock model.WebDAVLock
+	if err := db.Where(columnName("token")+" = ?", token).First(&lock).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webdav lock")
+	}
+	return &lock, nil
+}
+
+// DeleteExpiredWebDAVLocks deletes the locks expired at now, except the ones held
+func DeleteExpiredWebDAVLocks(now, staleBefore int64) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s >= 0 AND %s <= ?", columnName("duration"), columnName("expiry")), now).
+		Where(whereWebDAVLockNotHeld(db, staleBefore)).
+		Delete(&model.WebDAVLock{}).Error)
+}
+
+// lockWebDAVLockGuard updates the guard row in the transaction, which holds the row lock
+// of the guard until the transaction ends, the first lock creates the row
+func lockWebDAVLockGuard(tx *gorm.DB) error {
+	res := tx.Model(&model.WebDAVLockGuard{}).Where(columnName("id")+" = ?", 1).
+		Update("version", gorm.Expr(columnName("version")+" + 1"))
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error
+	}
+	return tx.Create(&model.WebDAVLockGuard{ID: 1, Version: 1}).Error
+}
+
+// CreateWebDAVLock creates the lock unless it conflicts with another one, which is the lock
+// of the same root, an infinite depth lock of the ancestors, or for an infinite depth lock,
+// a lock of the descendants. The conflicts span different roots, so the creations are
+// serialized by the guard row. It reports whether the lock is created.
+func CreateWebDAVLock(lock *model.WebDAVLock, ancestors []string) (bool, error) {
+	created := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := lockWebDAVLockGuard(tx); err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&model.WebDAVLock{}).Where(columnName("root")+" = ?", lock.Root).Count(&count).Error; err != nil || count > 0 {
+			return err
+		}
+		if len(ancestors) > 0 {
+			if err := tx.Model(&model.WebDAVLock{}).
+				Where(fmt.Sprintf("%s IN ? AND %s = ?", columnName("root"), columnName("zero_depth")), ancestors, false).
+				Count(&count).Error; err != nil || count > 0 {
+				return err
+			}
+		}
+		if !lock.ZeroDepth {
+			prefix := strings.TrimSuffix(lock.Root, "/") + "/"
+			var roots []string
+			if err := tx.Model(&model.WebDAVLock{}).Where(columnName("root")+" LIKE ?", prefix+"%").
+				Pluck("root", &roots).Error; err != nil {
+				return err
+			}
+			// LIKE also matches the roots with wildcards in place of % and _
+			for _, root := range roots {
+				if strings.HasPrefix(root, prefix) {
+					return nil
+				}
+			}
+		}
+		if err := tx.Create(lock).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		// the lock of the same root may be created by another instance at the same time
+		var count int64
+		if db.Model(&model.WebDAVLock{}).Where(columnName("root")+" = ?", lock.Root).Count(&count).Error == nil && count > 0 {
+			return false, nil
+		}
+	}
+	return created, errors.WithStack(err)
+}
+
+// HoldWebDAVLock marks the lock held by a request at now, it reports false if it's held already
+func HoldWebDAVLock(id uint, now, staleBefore int64) (bool, error) {
+	res := db.Model(&model.WebDAVLock{}).Where(columnName("id")+" = ?", id).
+		Where(whereWebDAVLockNotHeld(db, staleBefore)).
+		Updates(map[string]any{"held": true, "held_at": now})
+	return res.RowsAffected > 0, errors.WithStack(res.Error)
+}
+
+func ReleaseWebDAVLock(id uint) error {
+	return errors.WithStack(db.Model(&model.WebDAVLock{}).Where(columnName("id")+" = ?", id).
+		Updates(map[string]any{"held": false, "held_at": 0}).Error)
+}
+
+// RefreshWebDAVLock sets the duration and the expiry of the lock unless it's held
+func RefreshWebDAVLock(id uint, duration, expiry, staleBefore int64) (bool, error) {
+	res := db.Model(&model.WebDAVLock{}).Where(columnName("id")+" = ?", id).
+		Where(whereWebDAVLockNotHeld(db, staleBefore)).
+		Updates(map[string]any{"duration": duration, "expiry": expiry})
+	return res.RowsAffected > 0, errors.WithStack(res.Error)
+}
+
+// DeleteWebDAVLock deletes the lock unless it's held
+func DeleteWebDAVLock(id uint, staleBefore int64) (bool, error) {
+	res := db.Where(columnName("id")+" = ?", id).
+		Where(whereWebDAVLockNotHeld(db, staleBefore)).
+		Delete(&model.WebDAVLock{})
+	return res.RowsAffected > 0, errors.WithStack(res.Error)
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	var w model.Webhook
+	if err := db.First(&w, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webhook")
+	}
+	return &w, nil
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Create(w).Error)
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	return errors.WithStack(db.Save(w).Error)
+}
+
+func GetWebhooks(pageIndex, pageSize int) (webhooks []model.Webhook, count int64, err error) {
+	webhookDB := db.Model(&model.Webhook{})
+	if err = webhookDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get webhooks count")
+	}
+	if err = webhookDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&webhooks).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find webhooks")
+	}
+	return webhooks, count, nil
+}
+
+func GetEnabledWebhooks() (webhooks []model.Webhook, err error) {
+	err = db.Where(columnName("disabled")+" = ?", false).Find(&webhooks).Error
+	return webhooks, errors.WithStack(err)
+}
+
+func DeleteWebhookById(id uint) error {
+	if err := db.Where(columnName("webhook_id")+" = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Delete(&model.Webhook{}, id).Error)
+}
+
+func CreateWebhookDelivery(d *model.WebhookDelivery) error {
+	return errors.WithStack(db.Create(d).Error)
+}
+
+func GetWebhookDeliveries(webhookId uint, pageIndex, pageSize int) (deliveries []model.WebhookDelivery, count int64, err error) {
+	deliveryDB := db.Model(&model.WebhookDelivery{})
+	if webhookId != 0 {
+		deliveryDB = deliveryDB.Where(columnName("webhook_id")+" = ?", webhookId)
+	}
+	if err = deliveryDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get webhook deliveries count")
+	}
+	if err = deliveryDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find webhook deliveries")
+	}
+	return deliveries, count, nil
+}
+
+func DeleteWebhookDeliveriesBefore(t time.Time) (int64, error) {
+	res := db.Where(columnName("time")+" < ?", t).Delete(&model.WebhookDelivery{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
@@ -0,0 +1,12 @@
+package errs
+
+import "errors"
+
+var (
+	InvalidAPIToken      = errors.New("api token is invalid")
+	APITokenExpired      = errors.New("api token has expired")
+	APITokenIPNotAllowed = errors.New("api token is not allowed from this ip")
+	APITokenNotAllowed   = errors.New("api tokens are not allowed here")
+	InvalidAPITokenScope = errors.New("invalid api token scopes")
+	InvalidAPITokenIPs   = errors.New("invalid api token allowed ips")
+)
@@ -0,0 +1,8 @@
+package errs
+
+import "errors"
+
+var (
+	InvalidACLSubject = errors.New("an acl entry must be set for either a user or a group")
+	InvalidACLPerm    = errors.New("invalid acl operations")
+)
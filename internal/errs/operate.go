@@ -4,4 +4,7 @@ import "errors"
 
 var (
 	PermissionDenied = errors.New("permission denied")
+	VerifyFailed     = errors.New("transfer verification failed")
+	InvalidConflict  = errors.New("invalid conflict policy")
+	ObjectSkipped    = errors.New("skipped by the conflict policy")
 )
@@ -0,0 +1,9 @@
+package errs
+
+import "errors"
+
+var (
+	RecycleItemNotFound    = errors.New("recycle bin item not found")
+	RecycleBinNotSupported = errors.New("storage supports neither move nor copy, disable its recycle bin to delete")
+	RestoreTargetExists    = errors.New("an object already exists at the original path")
+)
@@ -0,0 +1,9 @@
+package errs
+
+import "errors"
+
+var (
+	InvalidS3Key        = errors.New("s3 key is invalid")
+	InvalidS3BucketName = errors.New("invalid s3 bucket name")
+	S3BucketExists      = errors.New("s3 bucket with the same name already exists")
+)
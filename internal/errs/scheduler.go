@@ -0,0 +1,8 @@
+package errs
+
+import "fmt"
+
+var (
+	ScheduledJobRunning = fmt.Errorf("the last run of the scheduled job is not finished")
+	InvalidScheduleType = fmt.Errorf("invalid scheduled job type")
+)
@@ -5,4 +5,5 @@ import "fmt"
 var (
 	SearchNotAvailable  = fmt.Errorf("search not available")
 	BuildIndexIsRunning = fmt.Errorf("build index is running, please try later")
+	UpdateIndexRunning  = fmt.Errorf("update index of the storage is running, please try later")
 )
@@ -0,0 +1,9 @@
+package errs
+
+import "errors"
+
+var (
+	SessionRevoked      = errors.New("session has been revoked, login please")
+	SessionExpired      = errors.New("session has expired, login please")
+	InvalidRefreshToken = errors.New("refresh token is invalid")
+)
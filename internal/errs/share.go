@@ -0,0 +1,11 @@
+package errs
+
+import "errors"
+
+var (
+	ShareNotFound  = errors.New("share not found")
+	ShareDisabled  = errors.New("share is disabled")
+	ShareExpired   = errors.New("share has expired")
+	ShareExhausted = errors.New("share has reached its max access count")
+	WrongShareCode = errors.New("share password is incorrect")
+)
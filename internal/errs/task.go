@@ -0,0 +1,8 @@
+package errs
+
+import "errors"
+
+var (
+	TaskNotPausable = errors.New("the task is finished or being canceled")
+	TaskNotPaused   = errors.New("the task is not paused or not stopped yet")
+)
@@ -107,17 +107,18 @@ func (t *ArchiveDownloadTask) RunWithoutPushUploadTask() (*ArchiveContentUploadT
 			Creator: t.Creator,
 			ApiUrl:  t.ApiUrl,
 		},
-		ObjName:       baseName,
-		InPlace:       !t.PutIntoNewDir,
-		FilePath:      dir,
-		DstActualPath: t.DstActualPath,
-		dstStorage:    t.DstStorage,
-		DstStorageMp:  t.DstStorageMp,
+		ObjName:        baseName,
+		InPlace:        !t.PutIntoNewDir,
+		FilePath:       dir,
+		DstActualPath:  t.DstActualPath,
+		dstStorage:     t.DstStorage,
+		DstStorageMp:   t.DstStorageMp,
+		ConflictPolicy: t.ConflictPolicy,
 	}
 	return uploadTask, nil
 }
 
-var ArchiveDownloadTaskManager *tache.Manager[*ArchiveDownloadTask]
+var ArchiveDownloadTaskManager *task.PriorityManager[*ArchiveDownloadTask]
 
 type ArchiveContentUploadTask struct {
 	task.TaskExtension
@@ -128,8 +129,10 @@ type ArchiveContentUploadTask struct {
 	DstActualPath string
 	dstStorage    driver.Driver
 	DstStorageMp  string
-	finalized     bool
-	groupID       string
+	// what to do if a file exists in the destination
+	ConflictPolicy model.ConflictPolicy
+	finalized      bool
+	groupID        string
 }
 
 func (t *ArchiveContentUploadTask) GetName() string {
@@ -213,13 +216,14 @@ func (t *ArchiveContentUploadTask) RunWithNextTaskCallback(f func(nextTask *Arch
 					Creator: t.Creator,
 					ApiUrl:  t.ApiUrl,
 				},
-				ObjName:       entry.Name(),
-				InPlace:       false,
-				FilePath:      nextFilePath,
-				DstActualPath: nextDstActualPath,
-				dstStorage:    t.dstStorage,
-				DstStorageMp:  t.DstStorageMp,
-				groupID:       t.groupID,
+				ObjName:        entry.Name(),
+				InPlace:        false,
+				FilePath:       nextFilePath,
+				DstActualPath:  nextDstActualPath,
+				dstStorage:     t.dstStorage,
+				DstStorageMp:   t.DstStorageMp,
+				ConflictPolicy: t.ConflictPolicy,
+				groupID:        t.groupID,
 			})
 			if err != nil {
 				es = stderrors.Join(es, err)
@@ -229,6 +233,19 @@ func (t *ArchiveContentUploadTask) RunWithNextTaskCallback(f func(nextTask *Arch
 			return es
 		}
 	} else {
+		name, result, err := resolveConflict(t.Ctx(), t.ConflictPolicy, t.dstStorage, t.DstActualPath, &model.Object{
+			Name:     t.ObjName,
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			t.status = result
+			t.deleteSrcFile()
+			return nil
+		}
 		file, err := os.Open(t.FilePath)
 		if err != nil {
 			return err
@@ -236,7 +253,7 @@ func (t *ArchiveContentUploadTask) RunWithNextTaskCallback(f func(nextTask *Arch
 		t.SetTotalBytes(info.Size())
 		fs := &stream.FileStream{
 			Obj: &model.Object{
-				Name:     t.ObjName,
+				Name:     name,
 				Size:     info.Size(),
 				Modified: time.Now(),
 			},
@@ -250,6 +267,10 @@ func (t *ArchiveContentUploadTask) RunWithNextTaskCallback(f func(nextTask *Arch
 		if err != nil {
 			return err
 		}
+		t.status = "done"
+		if result != "" {
+			t.status = result
+		}
 	}
 	t.deleteSrcFile()
 	return nil
@@ -299,13 +320,13 @@ func genTempFileName(prefix string) (string, error) {
 }
 
 type archiveContentUploadTaskManagerType struct {
-	*tache.Manager[*ArchiveContentUploadTask]
+	*task.PriorityManager[*ArchiveContentUploadTask]
 }
 
 func (m *archiveContentUploadTaskManagerType) Remove(id string) {
 	if t, ok := m.GetByID(id); ok {
 		t.deleteSrcFile()
-		m.Manager.Remove(id)
+		m.PriorityManager.Remove(id)
 	}
 }
 
@@ -331,7 +352,7 @@ func (m *archiveContentUploadTaskManagerType) RemoveByCondition(condition func(t
 }
 
 var ArchiveContentUploadTaskManager = &archiveContentUploadTaskManagerType{
-	Manager: nil,
+	PriorityManager: nil,
 }
 
 func archiveMeta(ctx context.Context, path string, args model.ArchiveMetaArgs) (*model.ArchiveMetaProvider, error) {
@@ -359,7 +380,8 @@ func archiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
-	if srcStorage.GetStorage() == dstStorage.GetStorage() {
+	// the storage can't apply the policy to the decompressed objects
+	if srcStorage.GetStorage() == dstStorage.GetStorage() && !args.ConflictPolicy.Checked() {
 		err = op.ArchiveDecompress(ctx, srcStorage, srcObjActualPath, dstDirActualPath, args, lazyCache...)
 		if !errors.Is(err, errs.NotImplement) {
 			return nil, err
@@ -0,0 +1,229 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	stdpath "path"
+	"path/filepath"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/archive/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/pkg/errors"
+)
+
+type ArchiveCompressTask struct {
+	task.TaskExtension
+	Status      string   `json:"-"` //don't save status to save space
+	SrcDir      string   `json:"src_dir"`
+	Names       []string `json:"names"`
+	DstDir      string   `json:"dst_dir"`
+	ArchiveName string   `json:"archive_name"`
+	model.ArchiveCompressArgs
+}
+
+func (t *ArchiveCompressTask) GetName() string {
+	return fmt.Sprintf("compress %v in [%s] to [%s](%s)", t.Names, t.SrcDir, t.DstDir, t.ArchiveName)
+}
+
+func (t *ArchiveCompressTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *ArchiveCompressTask) Run() error {
+	if err := t.ReinitCtx(); err != nil {
+		return err
+	}
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	return t.RunWithoutCtx()
+}
+
+// RunWithoutCtx compresses the src objects into a temp file and uploads it to the dst dir
+func (t *ArchiveCompressTask) RunWithoutCtx() error {
+	compressor, err := tool.GetCompressor(t.Format)
+	if err != nil {
+		return err
+	}
+	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(t.DstDir)
+	if err != nil {
+		return errors.WithMessage(err, "failed get dst storage")
+	}
+	t.Status = "walking src objects"
+	type entry struct {
+		path string
+		name string
+		obj  model.Obj
+	}
+	var (
+		entries []entry
+		total   int64
+	)
+	err = WalkArchiveEntries(t.Ctx(), t.SrcDir, t.Names, func(path, name string, obj model.Obj) error {
+		entries = append(entries, entry{path: path, name: name, obj: obj})
+		if !obj.IsDir() {
+			total += obj.GetSize()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(conf.Conf.TempDir, "compress-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+	t.SetTotalBytes(total)
+	t.Status = "compressing"
+	aw, err := compressor(file, t.Password)
+	if err != nil {
+		return err
+	}
+	progress := driver.NewProgress(max(total, 1), t.SetProgress)
+	for _, e := range entries {
+		if utils.IsCanceled(t.Ctx()) {
+			_ = aw.Close()
+			return t.Ctx().Err()
+		}
+		if err = WriteArchiveEntry(t.Ctx(), aw, e.path, e.name, e.obj, progress); err != nil {
+			_ = aw.Close()
+			return errors.WithMessagef(err, "failed compress [%s]", e.path)
+		}
+	}
+	if err = aw.Close(); err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	t.SetTotalBytes(info.Size())
+	t.SetProgress(0)
+	t.Status = "uploading"
+	fs := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     t.ArchiveName,
+			Size:     info.Size(),
+			Modified: time.Now(),
+		},
+		Mimetype:     utils.GetMimeType(t.ArchiveName),
+		WebPutAsTask: true,
+		Reader:       file,
+	}
+	return op.Put(t.Ctx(), dstStorage, dstDirActualPath, fs, t.SetProgress)
+}
+
+var ArchiveCompressTaskManager *task.PriorityManager[*ArchiveCompressTask]
+
+// WalkArchiveEntries walks the named objects in srcDir and all their descendants,
+// calling fn with the full path, the slash separated path in the archive and the object.
+// Objects hidden from the user in ctx are skipped, and so are the objects for which fn
+// returns filepath.SkipDir, along with their descendants.
+func WalkArchiveEntries(ctx context.Context, srcDir string, names []string, fn func(path, name string, obj model.Obj) error) error {
+	var walk func(path, name string, obj model.Obj) error
+	walk = func(path, name string, obj model.Obj) error {
+		if utils.IsCanceled(ctx) {
+			return ctx.Err()
+		}
+		if err := fn(path, name, obj); err != nil {
+			if err == filepath.SkipDir {
+				return nil
+			}
+			return err
+		}
+		if !obj.IsDir() {
+			return nil
+		}
+		meta, _ := op.GetNearestMeta(path)
+		objs, err := list(context.WithValue(ctx, conf.MetaKey, meta), path, &ListArgs{NoLog: true})
+		if err != nil {
+			return errors.WithMessagef(err, "failed list [%s]", path)
+		}
+		for _, o := range objs {
+			if err = walk(stdpath.Join(path, o.GetName()), stdpath.Join(name, o.GetName()), o); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, name := range names {
+		path := stdpath.Join(srcDir, name)
+		obj, err := get(ctx, path)
+		if err != nil {
+			return errors.WithMessagef(err, "failed get [%s]", path)
+		}
+		if err = walk(path, obj.GetName(), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteArchiveEntry adds the object at path to the archive, file contents are also written to progress
+func WriteArchiveEntry(ctx context.Context, aw tool.ArchiveWriter, path, name string, obj model.Obj, progress io.Writer) error {
+	w, err := aw.Create(name, obj)
+	if err != nil || obj.IsDir() {
+		return err
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	link, _, err := op.Link(ctx, storage, actualPath, model.LinkArgs{})
+	if err != nil {
+		return errors.WithMessage(err, "failed get link")
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{
+		Obj: obj,
+		Ctx: ctx,
+	}, link)
+	if err != nil {
+		_ = link.Close()
+		return errors.WithMessage(err, "failed get stream")
+	}
+	defer ss.Close()
+	var r io.Reader = ss
+	if progress != nil {
+		r = io.TeeReader(ss, progress)
+	}
+	_, err = utils.CopyWithBuffer(w, r)
+	return err
+}
+
+func archiveCompress(ctx context.Context, srcDir string, names []string, dstDir, archiveName string, args model.ArchiveCompressArgs) (task.TaskExtensionInfo, error) {
+	if _, err := tool.GetCompressor(args.Format); err != nil {
+		return nil, err
+	}
+	tsk := &ArchiveCompressTask{
+		SrcDir:              srcDir,
+		Names:               names,
+		DstDir:              dstDir,
+		ArchiveName:         archiveName,
+		ArchiveCompressArgs: args,
+	}
+	if ctx.Value(conf.NoTaskKey) != nil {
+		tsk.Base.SetCtx(ctx)
+		return nil, tsk.RunWithoutCtx()
+	}
+	tsk.Creator, _ = ctx.Value(conf.UserKey).(*model.User)
+	tsk.ApiUrl = common.GetApiUrl(ctx)
+	ArchiveCompressTaskManager.Add(tsk)
+	return tsk, nil
+}
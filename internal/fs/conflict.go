@@ -0,0 +1,98 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+)
+
+// conflictPolicy returns the conflict policy of the request
+func conflictPolicy(ctx context.Context) model.ConflictPolicy {
+	p, _ := ctx.Value(conf.ConflictPolicyKey).(model.ConflictPolicy)
+	return p
+}
+
+// resolveConflict decides how to put src into the dst dir by the policy. It returns the name
+// to put src with, empty if src is skipped, and the result to report in the task status.
+func resolveConflict(ctx context.Context, policy model.ConflictPolicy, storage driver.Driver, dstDirActualPath string, src model.Obj) (string, string, error) {
+	name := src.GetName()
+	if !policy.Checked() {
+		return name, "", nil
+	}
+	dst, err := op.Get(ctx, storage, stdpath.Join(dstDirActualPath, name))
+	if errs.IsObjectNotFound(err) {
+		return name, "", nil
+	}
+	if err != nil {
+		return "", "", errors.WithMessagef(err, "failed get dst [%s]", name)
+	}
+	switch policy {
+	case model.ConflictSkip:
+		return "", "skipped, it exists", nil
+	case model.ConflictNewer:
+		// tolerate the precision of the modified times of the storages
+		if !src.ModTime().After(dst.ModTime().Add(time.Second)) {
+			return "", "skipped, the existing one is not older", nil
+		}
+		return name, "overwritten, the source is newer", nil
+	case model.ConflictSizeDiffers:
+		if src.GetSize() == dst.GetSize() {
+			return "", "skipped, the existing one has the same size", nil
+		}
+		return name, "overwritten, the sizes differ", nil
+	case model.ConflictRename:
+		objs, err := op.List(ctx, storage, dstDirActualPath, model.ListArgs{})
+		if err != nil {
+			return "", "", errors.WithMessage(err, "failed list dst dir")
+		}
+		names := make(map[string]struct{}, len(objs))
+		for _, obj := range objs {
+			names[obj.GetName()] = struct{}{}
+		}
+		ext := stdpath.Ext(name)
+		if src.IsDir() {
+			ext = ""
+		}
+		base := strings.TrimSuffix(name, ext)
+		for i := 1; ; i++ {
+			newName := fmt.Sprintf("%s (%d)%s", base, i, ext)
+			if _, ok := names[newName]; !ok {
+				return newName, "renamed to " + newName, nil
+			}
+		}
+	}
+	return "", "", errors.WithStack(errs.InvalidConflict)
+}
+
+// dstExists reports whether the object exists in the dst storage
+func dstExists(ctx context.Context, storage driver.Driver, path string) bool {
+	_, err := op.Get(ctx, storage, path)
+	return err == nil
+}
+
+// renameStream makes the file put with the name
+func renameStream(file model.FileStreamer, name string) error {
+	var fs *stream.FileStream
+	switch f := file.(type) {
+	case *stream.FileStream:
+		fs = f
+	case *stream.SeekableStream:
+		fs = f.FileStream
+	case *pausableStream:
+		return renameStream(f.FileStreamer, name)
+	default:
+		return errors.Errorf("can't rename the file stream %T", file)
+	}
+	fs.Obj = &model.ObjWrapName{Name: name, Obj: fs.Obj}
+	return nil
+}
@@ -0,0 +1,83 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+)
+
+func TestConflictPolicy(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	now := time.Now()
+	writeFiles(t, src, map[string]string{"skip.txt": "new", "rename.txt": "new", "newer.txt": "new", "older.txt": "old",
+		"size.txt": "same", "dir/exists.txt": "new", "dir/new.txt": "new"}, now.Add(-time.Hour))
+	writeFiles(t, src, map[string]string{"newer.txt": "newer"}, now)
+	writeFiles(t, dst, map[string]string{"skip.txt": "old", "rename.txt": "old", "newer.txt": "old", "older.txt": "newer",
+		"size.txt": "diff", "dir/exists.txt": "old"}, now.Add(-time.Minute))
+	for mountPath, root := range map[string]string{"/conflict_src": src, "/conflict_dst": dst} {
+		_, err := op.CreateStorage(context.Background(), model.Storage{
+			Driver:    "Local",
+			MountPath: mountPath,
+			Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+		})
+		if err != nil {
+			t.Fatalf("failed create storage: %+v", err)
+		}
+	}
+	ctx := context.WithValue(context.Background(), conf.NoTaskKey, struct{}{})
+	withPolicy := func(p model.ConflictPolicy) context.Context {
+		return context.WithValue(ctx, conf.ConflictPolicyKey, p)
+	}
+	for name, policy := range map[string]model.ConflictPolicy{
+		"skip.txt":   model.ConflictSkip,
+		"rename.txt": model.ConflictRename,
+		"newer.txt":  model.ConflictNewer,
+		"older.txt":  model.ConflictNewer,
+		"size.txt":   model.ConflictSizeDiffers,
+	} {
+		if _, err := transfer(withPolicy(policy), copy, "/conflict_src/"+name, "/conflict_dst"); err != nil {
+			t.Fatalf("failed copy %s: %+v", name, err)
+		}
+	}
+	// the existing file is skipped and kept in the source, the others are moved
+	if _, err := transfer(withPolicy(model.ConflictSkip), move, "/conflict_src/dir", "/conflict_dst"); err != nil {
+		t.Fatalf("failed move: %+v", err)
+	}
+	err := putDirectly(withPolicy(model.ConflictSkip), "/conflict_dst", &stream.FileStream{
+		Obj:    &model.Object{Name: "skip.txt", Size: 3},
+		Reader: strings.NewReader("put"),
+	})
+	if !errors.Is(err, errs.ObjectSkipped) {
+		t.Fatalf("expect skipped, got %v", err)
+	}
+
+	for root, files := range map[string]map[string]string{
+		dst: {"skip.txt": "old", "rename.txt": "old", "rename (1).txt": "new", "newer.txt": "newer", "older.txt": "newer",
+			"size.txt": "diff", "dir/exists.txt": "old", "dir/new.txt": "new"},
+		src: {"dir/exists.txt": "new"},
+	} {
+		for name, content := range files {
+			data, err := os.ReadFile(filepath.Join(root, name))
+			if err != nil {
+				t.Fatalf("failed read %s: %v", name, err)
+			}
+			if string(data) != content {
+				t.Errorf("%s: expect %q, got %q", name, content, data)
+			}
+		}
+	}
+	if _, err = os.Stat(filepath.Join(src, "dir/new.txt")); !os.IsNotExist(err) {
+		t.Errorf("expect the moved file removed from the source, got %v", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	stdpath "path"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -17,6 +19,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/OpenListTeam/tache"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 type taskType uint8
@@ -37,7 +40,17 @@ const (
 type FileTransferTask struct {
 	TaskData
 	TaskType taskType
-	groupID  string
+	Verify   bool `json:"verify"` // verify the files put even if the dst storage doesn't require it
+	// what to do if a file exists in the destination
+	ConflictPolicy model.ConflictPolicy `json:"conflict_policy"`
+	// the number of objs of the folder handed to the sub tasks, in name order,
+	// so a resumed or retried task doesn't add them again
+	Handed int `json:"handed,omitempty"`
+	// the size and modified time of the src file downloaded to the spool file,
+	// the file is downloaded again if the src is changed
+	SpoolSource string `json:"spool_source,omitempty"`
+	groupID     string
+	skipped     bool
 }
 
 func (t *FileTransferTask) GetName() string {
@@ -68,6 +81,10 @@ func (t *FileTransferTask) OnSucceeded() {
 }
 
 func (t *FileTransferTask) OnFailed() {
+	// a paused task continues from the downloaded bytes
+	if !t.IsPaused() {
+		t.removeSpool()
+	}
 	task_group.TransferCoordinator.Done(t.groupID, false)
 }
 
@@ -95,9 +112,18 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
 
-	if srcStorage.GetStorage() == dstStorage.GetStorage() {
+	policy := conflictPolicy(ctx)
+	// the storage can't apply the policy, so the objects are transferred one by one if they may conflict
+	if srcStorage.GetStorage() == dstStorage.GetStorage() &&
+		(!policy.Checked() || !dstExists(ctx, dstStorage, stdpath.Join(dstDirActualPath, stdpath.Base(srcObjActualPath)))) {
 		if taskType == copy {
 			err = op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath, lazyCache...)
+			if err == nil {
+				// the moves carry the dead props by the object change hook
+				if err := op.CopyDeadProps(srcObjPath, stdpath.Join(dstDirPath, stdpath.Base(srcObjPath)), true); err != nil {
+					log.Errorf("failed copy dead props of [%s]: %+v", srcObjPath, err)
+				}
+			}
 			if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
 				return nil, err
 			}
@@ -119,25 +145,34 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 			SrcStorageMp:  srcStorage.GetStorage().MountPath,
 			DstStorageMp:  dstStorage.GetStorage().MountPath,
 		},
-		TaskType: taskType,
+		TaskType:       taskType,
+		Verify:         ctx.Value(conf.VerifyTransferKey) != nil,
+		ConflictPolicy: policy,
 	}
 
 	if ctx.Value(conf.NoTaskKey) != nil {
 		var callback func(nextTask *FileTransferTask) error
 		hasSuccess := false
+		payloads := []any{task_group.SrcPathToRemove(srcObjPath)}
 		callback = func(nextTask *FileTransferTask) error {
 			nextTask.Base.SetCtx(ctx)
 			err := nextTask.RunWithNextTaskCallback(callback)
 			if err == nil {
 				hasSuccess = true
 			}
+			if nextTask.skipped {
+				payloads = append(payloads, task_group.SrcPathToKeep(stdpath.Join(nextTask.SrcStorageMp, nextTask.SrcActualPath)))
+			}
 			return err
 		}
 		t.Base.SetCtx(ctx)
 		err = t.RunWithNextTaskCallback(callback)
+		if t.skipped {
+			payloads = append(payloads, task_group.SrcPathToKeep(srcObjPath))
+		}
 		if hasSuccess || err == nil {
 			if taskType == move {
-				task_group.RefreshAndRemove(dstDirPath, task_group.SrcPathToRemove(srcObjPath))
+				task_group.RefreshAndRemove(dstDirPath, payloads...)
 			} else {
 				op.DeleteCache(t.DstStorage, dstDirActualPath)
 			}
@@ -171,6 +206,7 @@ func (t *FileTransferTask) RunWithNextTaskCallback(f func(nextTask *FileTransfer
 			return errors.WithMessagef(err, "failed list src [%s] objs", t.SrcActualPath)
 		}
 		dstActualPath := stdpath.Join(t.DstActualPath, srcObj.GetName())
+		t.transferDeadProps(srcObj.GetName())
 		if t.TaskType == copy {
 			if t.Ctx().Value(conf.NoTaskKey) != nil {
 				defer op.DeleteCache(t.DstStorage, dstActualPath)
@@ -178,12 +214,21 @@ func (t *FileTransferTask) RunWithNextTaskCallback(f func(nextTask *FileTransfer
 				task_group.TransferCoordinator.AppendPayload(t.groupID, task_group.DstPathToRefresh(dstActualPath))
 			}
 		}
-		for _, obj := range objs {
+		objs = slices.Clone(objs)
+		slices.SortFunc(objs, func(a, b model.Obj) int {
+			return strings.Compare(a.GetName(), b.GetName())
+		})
+		for i, obj := range objs {
+			if i < t.Handed {
+				continue
+			}
 			if utils.IsCanceled(t.Ctx()) {
 				return nil
 			}
 			err = f(&FileTransferTask{
-				TaskType: t.TaskType,
+				TaskType:       t.TaskType,
+				Verify:         t.Verify,
+				ConflictPolicy: t.ConflictPolicy,
 				TaskData: TaskData{
 					TaskExtension: task.TaskExtension{
 						Creator: t.Creator,
@@ -200,30 +245,86 @@ func (t *FileTransferTask) RunWithNextTaskCallback(f func(nextTask *FileTransfer
 			if err != nil {
 				return err
 			}
+			t.Handed = i + 1
 		}
 		t.Status = fmt.Sprintf("src object is dir, added all %s tasks of objs", t.TaskType)
 		return nil
 	}
 
+	name, result, err := resolveConflict(t.Ctx(), t.ConflictPolicy, t.DstStorage, t.DstActualPath, srcObj)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		t.Status = result
+		t.skipped = true
+		if t.TaskType == move && t.Ctx().Value(conf.NoTaskKey) == nil {
+			task_group.TransferCoordinator.AppendPayload(t.groupID, task_group.SrcPathToKeep(stdpath.Join(t.SrcStorageMp, t.SrcActualPath)))
+		}
+		return nil
+	}
 	link, _, err := op.Link(t.Ctx(), t.SrcStorage, t.SrcActualPath, model.LinkArgs{})
 	if err != nil {
 		return errors.WithMessagef(err, "failed get [%s] link", t.SrcActualPath)
 	}
-	// any link provided is seekable
-	ss, err := stream.NewSeekableStream(&stream.FileStream{
-		Obj: srcObj,
-		Ctx: t.Ctx(),
-	}, link)
-	if err != nil {
-		_ = link.Close()
-		return errors.WithMessagef(err, "failed get [%s] stream", t.SrcActualPath)
+	obj := srcObj
+	if name != srcObj.GetName() {
+		obj = &model.ObjWrapName{Name: name, Obj: srcObj}
+	}
+	var ss model.FileStreamer
+	if conf.Conf.Tasks.ResumableTransfer && t.GetID() != "" {
+		ss, err = t.spool(obj, link)
+		if err != nil {
+			return errors.WithMessagef(err, "failed download [%s]", t.SrcActualPath)
+		}
+	} else {
+		// any link provided is seekable
+		ss, err = stream.NewSeekableStream(&stream.FileStream{
+			Obj: obj,
+			Ctx: t.Ctx(),
+		}, link)
+		if err != nil {
+			_ = link.Close()
+			return errors.WithMessagef(err, "failed get [%s] stream", t.SrcActualPath)
+		}
 	}
 	t.SetTotalBytes(ss.GetSize())
 	t.Status = "uploading"
-	return op.Put(t.Ctx(), t.DstStorage, t.DstActualPath, ss, t.SetProgress, true)
+	if err = op.Put(t.Ctx(), t.DstStorage, t.DstActualPath, ss, t.SetProgress, true); err != nil {
+		return err
+	}
+	t.removeSpool()
+	if t.Verify || t.DstStorage.GetStorage().VerifyTransfer {
+		t.Status = "verifying"
+		if err = verifyTransfer(t.Ctx(), srcObj, t.SrcStorage, t.SrcActualPath, t.DstStorage, stdpath.Join(t.DstActualPath, name)); err != nil {
+			return err
+		}
+	}
+	t.transferDeadProps(name)
+	t.Status = "done"
+	if result != "" {
+		t.Status = result
+	}
+	return nil
+}
+
+// transferDeadProps copies or moves the webdav dead props of the object transferred to dstName,
+// each object of a folder is transferred by its own task
+func (t *FileTransferTask) transferDeadProps(dstName string) {
+	src := stdpath.Join(t.SrcStorageMp, t.SrcActualPath)
+	dst := stdpath.Join(t.DstStorageMp, t.DstActualPath, dstName)
+	var err error
+	if t.TaskType == copy {
+		err = op.CopyDeadProps(src, dst, false)
+	} else {
+		err = op.MoveDeadProps(src, dst, false)
+	}
+	if err != nil {
+		log.Errorf("failed %s dead props of [%s]: %+v", t.TaskType, src, err)
+	}
 }
 
 var (
-	CopyTaskManager *tache.Manager[*FileTransferTask]
-	MoveTaskManager *tache.Manager[*FileTransferTask]
+	CopyTaskManager *task.PriorityManager[*FileTransferTask]
+	MoveTaskManager *task.PriorityManager[*FileTransferTask]
 )
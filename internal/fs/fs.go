@@ -3,14 +3,17 @@ package fs
 import (
 	"context"
 	"io"
+	stdpath "path"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/pkg/errors"
 )
 
@@ -53,8 +56,10 @@ func Link(ctx context.Context, path string, args model.LinkArgs) (*model.Link, m
 	res, file, err := link(ctx, path, args)
 	if err != nil {
 		log.Errorf("failed link %s: %+v", path, err)
+		audit.Record(ctx, audit.Download, path, "", 0, err)
 		return nil, nil, err
 	}
+	audit.Record(ctx, audit.Download, path, "", file.GetSize(), nil)
 	return res, file, nil
 }
 
@@ -62,7 +67,10 @@ func MakeDir(ctx context.Context, path string, lazyCache ...bool) error {
 	err := makeDir(ctx, path, lazyCache...)
 	if err != nil {
 		log.Errorf("failed make dir %s: %+v", path, err)
+	} else {
+		webhook.Emit(ctx, webhook.FsMkdir, path, "", 0)
 	}
+	audit.Record(ctx, audit.MakeDir, path, "", 0, err)
 	return err
 }
 
@@ -70,7 +78,11 @@ func Move(ctx context.Context, srcPath, dstDirPath string, lazyCache ...bool) (t
 	req, err := transfer(ctx, move, srcPath, dstDirPath, lazyCache...)
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
+	} else if req == nil {
+		// moves done by tasks are reported by the task events
+		webhook.Emit(ctx, webhook.FsMove, srcPath, stdpath.Join(dstDirPath, stdpath.Base(srcPath)), 0)
 	}
+	audit.Record(ctx, audit.Move, srcPath, dstDirPath, 0, err)
 	return req, err
 }
 
@@ -78,7 +90,10 @@ func Copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool)
 	res, err := transfer(ctx, copy, srcObjPath, dstDirPath, lazyCache...)
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
+	} else if res == nil {
+		webhook.Emit(ctx, webhook.FsCopy, srcObjPath, stdpath.Join(dstDirPath, stdpath.Base(srcObjPath)), 0)
 	}
+	audit.Record(ctx, audit.Copy, srcObjPath, dstDirPath, 0, err)
 	return res, err
 }
 
@@ -86,7 +101,10 @@ func Rename(ctx context.Context, srcPath, dstName string, lazyCache ...bool) err
 	err := rename(ctx, srcPath, dstName, lazyCache...)
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
+	} else {
+		webhook.Emit(ctx, webhook.FsRename, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName), 0)
 	}
+	audit.Record(ctx, audit.Rename, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName), 0, err)
 	return err
 }
 
@@ -94,15 +112,24 @@ func Remove(ctx context.Context, path string) error {
 	err := remove(ctx, path)
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
+	} else {
+		webhook.Emit(ctx, webhook.FsRemove, path, "", 0)
 	}
+	audit.Record(ctx, audit.Remove, path, "", 0, err)
 	return err
 }
 
 func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, lazyCache ...bool) error {
 	err := putDirectly(ctx, dstDirPath, file, lazyCache...)
+	if errors.Is(err, errs.ObjectSkipped) {
+		return err
+	}
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
+	} else {
+		webhook.Emit(ctx, webhook.FsPut, stdpath.Join(dstDirPath, file.GetName()), "", file.GetSize())
 	}
+	audit.Record(ctx, audit.Upload, stdpath.Join(dstDirPath, file.GetName()), "", file.GetSize(), err)
 	return err
 }
 
@@ -111,6 +138,7 @@ func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	audit.Record(ctx, audit.Upload, stdpath.Join(dstDirPath, file.GetName()), "", file.GetSize(), err)
 	return t, err
 }
 
@@ -135,6 +163,25 @@ func ArchiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 	if err != nil {
 		log.Errorf("failed decompress [%s]%s: %+v", srcObjPath, args.InnerPath, err)
 	}
+	audit.Record(ctx, audit.Decompress, stdpath.Join(srcObjPath, args.InnerPath), dstDirPath, 0, err)
+	return t, err
+}
+
+func ArchiveCompress(ctx context.Context, srcDir string, names []string, dstDir, archiveName string, args model.ArchiveCompressArgs) (task.TaskExtensionInfo, error) {
+	t, err := archiveCompress(ctx, srcDir, names, dstDir, archiveName, args)
+	if err != nil {
+		log.Errorf("failed compress %v in %s: %+v", names, srcDir, err)
+	}
+	audit.Record(ctx, audit.Compress, srcDir, stdpath.Join(dstDir, archiveName), 0, err)
+	return t, err
+}
+
+func Sync(ctx context.Context, srcDir, dstDir string, args model.SyncArgs) (task.TaskExtensionInfo, error) {
+	t, err := syncDir(ctx, srcDir, dstDir, args)
+	if err != nil {
+		log.Errorf("failed sync %s to %s: %+v", srcDir, dstDir, err)
+	}
+	audit.Record(ctx, audit.Sync, srcDir, dstDir, 0, err)
 	return t, err
 }
 
@@ -174,6 +221,15 @@ func Other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 }
 
 func PutURL(ctx context.Context, path, dstName, urlStr string) error {
+	err := putURL(ctx, path, dstName, urlStr)
+	if err == nil {
+		webhook.Emit(ctx, webhook.FsPut, stdpath.Join(path, dstName), "", 0)
+	}
+	audit.Record(ctx, audit.OfflineURL, stdpath.Join(path, dstName), "", 0, err)
+	return err
+}
+
+func putURL(ctx context.Context, path, dstName, urlStr string) error {
 	storage, dstDirActualPath, err := op.GetStorageAndActualPath(path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
@@ -2,6 +2,7 @@ package fs
 
 import (
 	"context"
+	stdpath "path"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
@@ -35,6 +36,9 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 				return nil, errors.WithMessage(err, "failed get objs")
 			}
 		}
+		if utils.PathEqual(actualPath, "/") {
+			_objs = hideRecycleBin(_objs)
+		}
 	}
 
 	om := model.NewObjMerge()
@@ -42,7 +46,31 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 		om.InitHideReg(meta.Hide)
 	}
 	objs := om.Merge(_objs, virtualFiles...)
-	return objs, nil
+	return filterByACL(user, path, objs), nil
+}
+
+// filterByACL drops the objs the acl entries or the api token don't let the user read
+func filterByACL(user *model.User, path string, objs []model.Obj) []model.Obj {
+	if user == nil || user.IsAdmin() && user.Token == nil {
+		return objs
+	}
+	res := make([]model.Obj, 0, len(objs))
+	for _, obj := range objs {
+		if op.HasPermission(user, stdpath.Join(path, obj.GetName()), model.ACLRead, true) {
+			res = append(res, obj)
+		}
+	}
+	return res
+}
+
+// hideRecycleBin drops the recycle bin folder from the objs of a storage root
+func hideRecycleBin(objs []model.Obj) []model.Obj {
+	for i, obj := range objs {
+		if obj.GetName() == op.RecycleBinName {
+			return append(objs[:i:i], objs[i+1:]...)
+		}
+	}
+	return objs
 }
 
 func whetherHide(user *model.User, meta *model.Meta, path string) bool {
@@ -3,6 +3,7 @@ package fs
 import (
 	"context"
 	"fmt"
+	"io"
 	stdpath "path"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/tache"
 	"github.com/pkg/errors"
 )
@@ -24,6 +26,11 @@ type UploadTask struct {
 	storage          driver.Driver
 	dstDirActualPath string
 	file             model.FileStreamer
+	// the uploaded file with its hash, nil if the upload is not verified
+	verifyObj model.Obj
+	policy    model.ConflictPolicy
+	status    string
+	skipped   bool
 }
 
 func (t *UploadTask) GetName() string {
@@ -31,18 +38,49 @@ func (t *UploadTask) GetName() string {
 }
 
 func (t *UploadTask) GetStatus() string {
-	return "uploading"
+	return t.status
 }
 
 func (t *UploadTask) Run() error {
+	if err := t.ReinitCtx(); err != nil {
+		return err
+	}
 	t.ClearEndTime()
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
-	return op.Put(t.Ctx(), t.storage, t.dstDirActualPath, t.file, t.SetProgress, true)
+	file := t.file
+	if f := t.file.GetFile(); f != nil {
+		// the cached file is uploaded from the start again after the task is resumed
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return errors.Wrap(err, "failed seek the cached file")
+		}
+		file = &pausableStream{FileStreamer: t.file, t: t}
+	}
+	result, err := putWithPolicy(t.Ctx(), t.storage, t.dstDirActualPath, file, t.policy, t.verifyObj, func(status string) {
+		t.status = status
+	}, t.SetProgress, true)
+	if errors.Is(err, errs.ObjectSkipped) {
+		t.status, t.skipped = result, true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	t.status = "done"
+	if result != "" {
+		t.status = result
+	}
+	return nil
 }
 
 func (t *UploadTask) OnSucceeded() {
-	task_group.TransferCoordinator.Done(stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath), true)
+	dstDirPath := stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath)
+	task_group.TransferCoordinator.Done(dstDirPath, true)
+	if t.skipped {
+		return
+	}
+	// the upload is finished only now, PutAsTask just queues it
+	webhook.Emit(t.Ctx(), webhook.FsPut, stdpath.Join(dstDirPath, t.file.GetName()), "", t.file.GetSize())
 }
 
 func (t *UploadTask) OnFailed() {
@@ -57,7 +95,20 @@ func (t *UploadTask) SetRetry(retry int, maxRetry int) {
 	}
 }
 
-var UploadTaskManager *tache.Manager[*UploadTask]
+// pausableStream keeps the cached file of the upload task while the task is paused
+type pausableStream struct {
+	model.FileStreamer
+	t *UploadTask
+}
+
+func (s *pausableStream) Close() error {
+	if s.t.IsPaused() {
+		return nil
+	}
+	return s.FileStreamer.Close()
+}
+
+var UploadTaskManager *task.PriorityManager[*UploadTask]
 
 // putAsTask add as a put task and return immediately
 func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (task.TaskExtensionInfo, error) {
@@ -76,6 +127,12 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 		//file.SetReader(tempFile)
 		//file.SetTmpFile(tempFile)
 	}
+	var verifyObj model.Obj
+	if verifyEnabled(ctx, storage) {
+		if verifyObj, err = uploadedObj(file); err != nil {
+			return nil, err
+		}
+	}
 	taskCreator, _ := ctx.Value(conf.UserKey).(*model.User) // taskCreator is nil when convert failed
 	t := &UploadTask{
 		TaskExtension: task.TaskExtension{
@@ -85,6 +142,8 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 		storage:          storage,
 		dstDirActualPath: dstDirActualPath,
 		file:             file,
+		verifyObj:        verifyObj,
+		policy:           conflictPolicy(ctx),
 	}
 	t.SetTotalBytes(file.GetSize())
 	task_group.TransferCoordinator.AddTask(dstDirPath, nil)
@@ -103,5 +162,41 @@ func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer
 		_ = file.Close()
 		return errors.WithStack(errs.UploadNotSupported)
 	}
-	return op.Put(ctx, storage, dstDirActualPath, file, nil, lazyCache...)
+	var verifyObj model.Obj
+	if verifyEnabled(ctx, storage) {
+		if verifyObj, err = uploadedObj(file); err != nil {
+			_ = file.Close()
+			return err
+		}
+	}
+	_, err = putWithPolicy(ctx, storage, dstDirActualPath, file, conflictPolicy(ctx), verifyObj, nil, nil, lazyCache...)
+	return err
+}
+
+// putWithPolicy puts the file by the conflict policy and verifies it if verifyObj is not nil.
+// It returns the result of the policy, errs.ObjectSkipped if the file is skipped.
+func putWithPolicy(ctx context.Context, storage driver.Driver, dstDirActualPath string, file model.FileStreamer,
+	policy model.ConflictPolicy, verifyObj model.Obj, setStatus func(string), up driver.UpdateProgress, lazyCache ...bool) (string, error) {
+	if setStatus == nil {
+		setStatus = func(string) {}
+	}
+	name, result, err := resolveConflict(ctx, policy, storage, dstDirActualPath, file)
+	if err == nil && name == "" {
+		err = errors.WithStack(errs.ObjectSkipped)
+	} else if err == nil && name != file.GetName() {
+		err = renameStream(file, name)
+	}
+	if err != nil {
+		_ = file.Close()
+		return result, err
+	}
+	setStatus("uploading")
+	if err = op.Put(ctx, storage, dstDirActualPath, file, up, lazyCache...); err != nil {
+		return result, err
+	}
+	if verifyObj != nil {
+		setStatus("verifying")
+		err = verifyTransfer(ctx, verifyObj, nil, "", storage, stdpath.Join(dstDirActualPath, name))
+	}
+	return result, err
 }
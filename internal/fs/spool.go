@@ -0,0 +1,114 @@
+package fs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+func spoolPath(id string) string {
+	return filepath.Join(conf.Conf.TempDir, conf.TransferSpoolDir, id)
+}
+
+// spool downloads the src file into the temp dir and returns the stream of it. The bytes
+// downloaded by the previous run of the task are kept, the rest is read by range from the link
+func (t *FileTransferTask) spool(obj model.Obj, link *model.Link) (model.FileStreamer, error) {
+	defer link.Close()
+	size := obj.GetSize()
+	path := spoolPath(t.GetID())
+	if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o666)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		_ = file.Close()
+		return nil, errors.WithStack(err)
+	}
+	if source := fmt.Sprintf("%d-%d", size, obj.ModTime().UnixNano()); source != t.SpoolSource || offset > size {
+		// the src is changed since it was downloaded
+		if err = file.Truncate(0); err != nil {
+			_ = file.Close()
+			return nil, errors.WithStack(err)
+		}
+		offset = 0
+		t.SpoolSource = source
+		t.Persist()
+	}
+	if offset < size {
+		t.Status = "downloading"
+		if err = t.spoolFrom(file, offset, size, link); err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, errors.WithStack(err)
+	}
+	return &stream.FileStream{
+		Obj:     obj,
+		Ctx:     t.Ctx(),
+		Reader:  file,
+		Closers: utils.NewClosers(file),
+	}, nil
+}
+
+func (t *FileTransferTask) spoolFrom(file *os.File, offset, size int64, link *model.Link) error {
+	rr, err := stream.GetRangeReaderFromLink(size, link)
+	if err != nil {
+		return err
+	}
+	rc, err := rr.RangeRead(t.Ctx(), http_range.Range{Start: offset, Length: size - offset})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		return errors.WithStack(err)
+	}
+	return utils.CopyWithCtx(t.Ctx(), file, rc, size-offset, func(percentage float64) {
+		t.SetProgress((float64(offset) + percentage*float64(size-offset)/100) / float64(size) * 100)
+	})
+}
+
+func (t *FileTransferTask) removeSpool() {
+	if t.GetID() == "" {
+		return
+	}
+	if err := os.Remove(spoolPath(t.GetID())); err != nil && !os.IsNotExist(err) {
+		log.Warnf("failed remove the spool file of task %s: %+v", t.GetID(), err)
+	}
+}
+
+// CleanTransferSpool removes the spool files of the copy and move tasks that no longer exist
+func CleanTransferSpool() {
+	entries, err := os.ReadDir(filepath.Join(conf.Conf.TempDir, conf.TransferSpoolDir))
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		id := entry.Name()
+		if _, ok := CopyTaskManager.GetByID(id); ok {
+			continue
+		}
+		if _, ok := MoveTaskManager.GetByID(id); ok {
+			continue
+		}
+		if err := os.RemoveAll(spoolPath(id)); err != nil {
+			log.Warnf("failed remove the spool file of task %s: %+v", id, err)
+		}
+	}
+}
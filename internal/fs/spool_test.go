@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestSpoolResume(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	modified := time.Now().Add(-time.Hour)
+	writeFiles(t, src, map[string]string{"a.txt": "0123456789"}, modified)
+	for mountPath, root := range map[string]string{"/spool_src": src, "/spool_dst": dst} {
+		_, err := op.CreateStorage(context.Background(), model.Storage{
+			Driver:    "Local",
+			MountPath: mountPath,
+			Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+		})
+		if err != nil {
+			t.Fatalf("failed create storage: %+v", err)
+		}
+	}
+	srcStorage, _, _ := op.GetStorageAndActualPath("/spool_src")
+	dstStorage, _, _ := op.GetStorageAndActualPath("/spool_dst")
+	tempDir, resumable := conf.Conf.TempDir, conf.Conf.Tasks.ResumableTransfer
+	conf.Conf.TempDir, conf.Conf.Tasks.ResumableTransfer = t.TempDir(), true
+	defer func() {
+		conf.Conf.TempDir, conf.Conf.Tasks.ResumableTransfer = tempDir, resumable
+	}()
+
+	tsk := &FileTransferTask{
+		TaskData: TaskData{
+			SrcActualPath: "/a.txt",
+			DstActualPath: "/",
+			SrcStorage:    srcStorage,
+			DstStorage:    dstStorage,
+			SrcStorageMp:  "/spool_src",
+			DstStorageMp:  "/spool_dst",
+		},
+		TaskType:    copy,
+		SpoolSource: fmt.Sprintf("%d-%d", 10, modified.UnixNano()),
+	}
+	tsk.SetID("spool")
+	tsk.SetCtx(context.Background())
+	// the bytes downloaded before the task was paused, marked to tell them from the src
+	if err := os.MkdirAll(filepath.Dir(spoolPath("spool")), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(spoolPath("spool"), []byte("abcd"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err := tsk.RunWithNextTaskCallback(func(*FileTransferTask) error { return nil }); err != nil {
+		t.Fatalf("failed transfer: %+v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dst, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "abcd456789" {
+		t.Errorf("expect the src read from the 5th byte, got %q", data)
+	}
+	if _, err = os.Stat(spoolPath("spool")); !os.IsNotExist(err) {
+		t.Errorf("expect the spool file removed, got %v", err)
+	}
+}
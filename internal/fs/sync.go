@@ -0,0 +1,313 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	SyncMkdir    = "mkdir"
+	SyncCopy     = "copy"   // the object is not in the destination
+	SyncUpdate   = "update" // the file in the destination is outdated
+	SyncDelete   = "delete"
+	SyncConflict = "conflict" // a file and a folder have the same name, skipped unless deleting is enabled
+)
+
+// SyncAction is a planned change of the destination, Path is relative to the synced folders
+type SyncAction struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	IsDir  bool   `json:"is_dir"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason,omitempty"`
+	obj    model.Obj
+}
+
+type SyncTask struct {
+	task.TaskExtension
+	Status string `json:"-"` //don't save status to save space
+	SrcDir string `json:"src_dir"`
+	DstDir string `json:"dst_dir"`
+	model.SyncArgs
+}
+
+func (t *SyncTask) GetName() string {
+	return fmt.Sprintf("sync [%s] to [%s]", t.SrcDir, t.DstDir)
+}
+
+func (t *SyncTask) GetStatus() string {
+	return t.Status
+}
+
+func (t *SyncTask) Run() error {
+	if err := t.ReinitCtx(); err != nil {
+		return err
+	}
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	return t.RunWithoutCtx()
+}
+
+// RunWithoutCtx compares the folders and applies the changes to the destination
+func (t *SyncTask) RunWithoutCtx() error {
+	t.Status = "comparing"
+	p, err := planSync(t.Ctx(), t.SrcDir, t.DstDir, t.SyncArgs)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, a := range p.actions {
+		if a.Action == SyncCopy || a.Action == SyncUpdate {
+			total += a.Size
+		}
+	}
+	t.SetTotalBytes(total)
+	var done int64
+	for i, a := range p.actions {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		t.Status = fmt.Sprintf("%s [%s] (%d/%d)", a.Action, a.Path, i+1, len(p.actions))
+		err = p.apply(t.Ctx(), a, func(percentage float64) {
+			t.SetProgress((float64(done) + percentage*float64(a.Size)/100) / float64(max(total, 1)) * 100)
+		})
+		if err != nil {
+			return errors.WithMessagef(err, "failed %s [%s]", a.Action, a.Path)
+		}
+		if a.Action == SyncCopy || a.Action == SyncUpdate {
+			done += a.Size
+		}
+	}
+	t.Status = fmt.Sprintf("synced, %d changes", len(p.actions))
+	return nil
+}
+
+var SyncTaskManager *task.PriorityManager[*SyncTask]
+
+type syncPlan struct {
+	srcStorage, dstStorage driver.Driver
+	srcDir, dstDir         string
+	srcActualDir           string
+	dstActualDir           string
+	verify                 bool
+	// the user the objects are walked as, nil walks all of them
+	user    *model.User
+	actions []SyncAction
+}
+
+func planSync(ctx context.Context, srcDir, dstDir string, args model.SyncArgs) (*syncPlan, error) {
+	srcStorage, srcActualDir, err := op.GetStorageAndActualPath(srcDir)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstActualDir, err := op.GetStorageAndActualPath(dstDir)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	if srcStorage.GetStorage() == dstStorage.GetStorage() &&
+		(utils.IsSubPath(srcActualDir, dstActualDir) || utils.IsSubPath(dstActualDir, srcActualDir)) {
+		return nil, errors.New("the folders can't contain each other")
+	}
+	p := &syncPlan{
+		srcStorage:   srcStorage,
+		dstStorage:   dstStorage,
+		srcDir:       utils.FixAndCleanPath(srcDir),
+		dstDir:       utils.FixAndCleanPath(dstDir),
+		srcActualDir: srcActualDir,
+		dstActualDir: dstActualDir,
+		verify:       args.Verify,
+	}
+	p.user, _ = ctx.Value(conf.UserKey).(*model.User)
+	dstExists := true
+	if _, err = op.Get(ctx, dstStorage, dstActualDir); err != nil {
+		if !errs.IsObjectNotFound(err) {
+			return nil, errors.WithMessage(err, "failed get dst folder")
+		}
+		dstExists = false
+		p.actions = append(p.actions, SyncAction{Action: SyncMkdir, Path: "/", IsDir: true})
+	}
+	if err = p.diff(ctx, "/", dstExists, args); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// diff compares the folder at rel in the source with the one in the destination
+func (p *syncPlan) diff(ctx context.Context, rel string, dstExists bool, args model.SyncArgs) error {
+	if utils.IsCanceled(ctx) {
+		return ctx.Err()
+	}
+	listArgs := model.ListArgs{Refresh: args.Refresh}
+	srcObjs, err := op.List(ctx, p.srcStorage, stdpath.Join(p.srcActualDir, rel), listArgs)
+	if err != nil {
+		return errors.WithMessagef(err, "failed list src [%s]", rel)
+	}
+	srcObjs, _ = p.readable(p.srcDir, p.srcActualDir, rel, srcObjs)
+	dstObjs := make(map[string]model.Obj)
+	var dstHidden map[string]struct{}
+	if dstExists {
+		objs, err := op.List(ctx, p.dstStorage, stdpath.Join(p.dstActualDir, rel), listArgs)
+		if err != nil {
+			return errors.WithMessagef(err, "failed list dst [%s]", rel)
+		}
+		objs, dstHidden = p.readable(p.dstDir, p.dstActualDir, rel, objs)
+		for _, obj := range objs {
+			dstObjs[obj.GetName()] = obj
+		}
+	}
+	for _, src := range srcObjs {
+		path := stdpath.Join(rel, src.GetName())
+		if _, ok := dstHidden[src.GetName()]; ok {
+			p.actions = append(p.actions, SyncAction{Action: SyncConflict, Path: path, IsDir: src.IsDir(), Reason: "destination not accessible"})
+			continue
+		}
+		dst, ok := dstObjs[src.GetName()]
+		delete(dstObjs, src.GetName())
+		if ok && dst.IsDir() != src.IsDir() {
+			if !args.Delete {
+				p.actions = append(p.actions, SyncAction{Action: SyncConflict, Path: path, IsDir: src.IsDir(), Reason: "type mismatch"})
+				continue
+			}
+			p.actions = append(p.actions, SyncAction{Action: SyncDelete, Path: path, IsDir: dst.IsDir(), Size: dst.GetSize(), Reason: "type mismatch"})
+			ok = false
+		}
+		if src.IsDir() {
+			if !ok {
+				p.actions = append(p.actions, SyncAction{Action: SyncMkdir, Path: path, IsDir: true})
+			}
+			if err = p.diff(ctx, path, ok, args); err != nil {
+				return err
+			}
+			continue
+		}
+		if !ok {
+			p.actions = append(p.actions, SyncAction{Action: SyncCopy, Path: path, Size: src.GetSize(), obj: src})
+		} else if reason := fileChanged(src, dst); reason != "" {
+			p.actions = append(p.actions, SyncAction{Action: SyncUpdate, Path: path, Size: src.GetSize(), Reason: reason, obj: src})
+		}
+	}
+	if args.Delete {
+		for _, dst := range dstObjs {
+			p.actions = append(p.actions, SyncAction{Action: SyncDelete, Path: stdpath.Join(rel, dst.GetName()), IsDir: dst.IsDir(), Size: dst.GetSize(), Reason: "not in source"})
+		}
+	}
+	return nil
+}
+
+// readable drops the recycle bin and the objects the user can't read, which are the ones denied
+// by the acl entries, hidden or under a password in the folder at rel of dir, it returns the names
+// of the objects dropped, except the recycle bin
+func (p *syncPlan) readable(dir, actualDir, rel string, objs []model.Obj) ([]model.Obj, map[string]struct{}) {
+	var meta *model.Meta
+	if p.user != nil {
+		meta, _ = op.GetNearestMeta(stdpath.Join(dir, rel))
+	}
+	res := make([]model.Obj, 0, len(objs))
+	hidden := make(map[string]struct{})
+	for _, obj := range objs {
+		if op.IsInRecycleBin(stdpath.Join(actualDir, rel, obj.GetName())) {
+			continue
+		}
+		if p.user != nil && !common.CanAccess(p.user, meta, stdpath.Join(dir, rel, obj.GetName()), "") {
+			hidden[obj.GetName()] = struct{}{}
+			continue
+		}
+		res = append(res, obj)
+	}
+	return res, hidden
+}
+
+// fileChanged returns why the dst file differs from the src one, or empty if they are the same.
+// Hashes are compared if both have one of the same type, otherwise the sizes and the modified times are,
+// a dst file is outdated if it was modified before the src one.
+func fileChanged(src, dst model.Obj) string {
+	dstHash := dst.GetHash()
+	for ht, sum := range src.GetHash().All() {
+		if sum == "" {
+			continue
+		}
+		if dstSum := dstHash.GetHash(ht); dstSum != "" {
+			if dstSum != sum {
+				return ht.Name + " mismatch"
+			}
+			return ""
+		}
+	}
+	if src.GetSize() != dst.GetSize() {
+		return "size mismatch"
+	}
+	if src.ModTime().After(dst.ModTime().Add(time.Second)) {
+		return "source is newer"
+	}
+	return ""
+}
+
+func (p *syncPlan) apply(ctx context.Context, a SyncAction, up driver.UpdateProgress) error {
+	dstPath := stdpath.Join(p.dstActualDir, a.Path)
+	switch a.Action {
+	case SyncMkdir:
+		return op.MakeDir(ctx, p.dstStorage, dstPath)
+	case SyncDelete:
+		return op.Remove(ctx, p.dstStorage, dstPath)
+	case SyncCopy, SyncUpdate:
+		srcPath := stdpath.Join(p.srcActualDir, a.Path)
+		link, _, err := op.Link(ctx, p.srcStorage, srcPath, model.LinkArgs{})
+		if err != nil {
+			return errors.WithMessage(err, "failed get link")
+		}
+		ss, err := stream.NewSeekableStream(&stream.FileStream{
+			Obj: a.obj,
+			Ctx: ctx,
+		}, link)
+		if err != nil {
+			_ = link.Close()
+			return errors.WithMessage(err, "failed get stream")
+		}
+		if err = op.Put(ctx, p.dstStorage, stdpath.Dir(dstPath), ss, up, true); err != nil {
+			return err
+		}
+		if p.verify || verifyEnabled(ctx, p.dstStorage) {
+			return verifyTransfer(ctx, a.obj, p.srcStorage, srcPath, p.dstStorage, dstPath)
+		}
+	}
+	return nil
+}
+
+// PlanSync returns the changes a sync would make without applying them
+func PlanSync(ctx context.Context, srcDir, dstDir string, args model.SyncArgs) ([]SyncAction, error) {
+	p, err := planSync(ctx, srcDir, dstDir, args)
+	if err != nil {
+		return nil, err
+	}
+	return p.actions, nil
+}
+
+func syncDir(ctx context.Context, srcDir, dstDir string, args model.SyncArgs) (task.TaskExtensionInfo, error) {
+	t := &SyncTask{
+		SrcDir:   srcDir,
+		DstDir:   dstDir,
+		SyncArgs: args,
+	}
+	if ctx.Value(conf.NoTaskKey) != nil {
+		t.Base.SetCtx(ctx)
+		return nil, t.RunWithoutCtx()
+	}
+	t.Creator, _ = ctx.Value(conf.UserKey).(*model.User)
+	t.ApiUrl = common.GetApiUrl(ctx)
+	SyncTaskManager.Add(t)
+	return t, nil
+}
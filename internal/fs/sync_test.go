@@ -0,0 +1,99 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string, modified time.Time) {
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	now := time.Now()
+	writeFiles(t, src, map[string]string{"same.txt": "same", "changed.txt": "newer", "dir/new.txt": "new", "conflict": "file", op.RecycleBinName + "/src.txt": "src"}, now.Add(-time.Hour))
+	writeFiles(t, dst, map[string]string{"same.txt": "same", "changed.txt": "old", "extra.txt": "extra", "conflict/a.txt": "a", op.RecycleBinName + "/dst.txt": "dst"}, now)
+	for mountPath, root := range map[string]string{"/src": src, "/dst": dst} {
+		_, err := op.CreateStorage(context.Background(), model.Storage{
+			Driver:    "Local",
+			MountPath: mountPath,
+			Addition:  fmt.Sprintf(`{"root_folder_path":%q,"show_hidden":true}`, root),
+		})
+		if err != nil {
+			t.Fatalf("failed create storage: %+v", err)
+		}
+	}
+	ctx := context.WithValue(context.Background(), conf.NoTaskKey, struct{}{})
+
+	actions, err := PlanSync(ctx, "/src", "/dst", model.SyncArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, a := range actions {
+		got = append(got, a.Action+" "+a.Path)
+	}
+	sort.Strings(got)
+	want := []string{"conflict /conflict", "copy /dir/new.txt", "mkdir /dir", "update /changed.txt"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("expect %v, got %v", want, got)
+	}
+
+	if _, err = Sync(ctx, "/src", "/dst", model.SyncArgs{Delete: true, Refresh: true}); err != nil {
+		t.Fatalf("failed sync: %+v", err)
+	}
+	for name, content := range map[string]string{"same.txt": "same", "changed.txt": "newer", "dir/new.txt": "new", "conflict": "file"} {
+		data, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil || string(data) != content {
+			t.Errorf("expect %s synced, got %q: %v", name, data, err)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(dst, "extra.txt")); !os.IsNotExist(err) {
+		t.Errorf("expect extra.txt deleted, got %v", err)
+	}
+	// the recycle bins are left out on both sides
+	if _, err = os.Stat(filepath.Join(dst, op.RecycleBinName, "dst.txt")); err != nil {
+		t.Errorf("expect the recycle bin of dst kept, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dst, op.RecycleBinName, "src.txt")); !os.IsNotExist(err) {
+		t.Errorf("expect the recycle bin of src not synced, got %v", err)
+	}
+	actions, err = PlanSync(ctx, "/src", "/dst", model.SyncArgs{Delete: true, Refresh: true})
+	if err != nil || len(actions) != 0 {
+		t.Errorf("expect nothing to sync, got %+v: %v", actions, err)
+	}
+}
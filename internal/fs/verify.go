@@ -0,0 +1,133 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// computableHashes are the hash types computed by reading the file if a side doesn't provide one
+var computableHashes = []*utils.HashType{utils.MD5, utils.SHA1, utils.SHA256}
+
+// verifyEnabled reports whether the transfers to the storage are verified,
+// by the option of the storage or by the request
+func verifyEnabled(ctx context.Context, storage driver.Driver) bool {
+	return storage.GetStorage().VerifyTransfer || ctx.Value(conf.VerifyTransferKey) != nil
+}
+
+// verifyTransfer checks that the file put at dstPath has the same content as src.
+// The hashes are compared if both sides provide one of the same type, otherwise the hash of
+// the side missing it is computed by reading the file. srcStorage is nil if src is not stored,
+// src must provide one of computableHashes then.
+func verifyTransfer(ctx context.Context, src model.Obj, srcStorage driver.Driver, srcPath string, dstStorage driver.Driver, dstPath string) error {
+	dst, err := getPut(ctx, dstStorage, dstPath)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get [%s] to verify", dstPath)
+	}
+	if src.GetSize() != dst.GetSize() {
+		return errors.Wrapf(errs.VerifyFailed, "size mismatch of [%s]: source %d, destination %d", dstPath, src.GetSize(), dst.GetSize())
+	}
+	srcHash, dstHash := src.GetHash(), dst.GetHash()
+	for ht, srcSum := range srcHash.All() {
+		if dstSum := dstHash.GetHash(ht); srcSum != "" && dstSum != "" {
+			return compareHash(dstPath, ht, srcSum, dstSum)
+		}
+	}
+	ht, srcSum, dstSum := utils.MD5, "", ""
+	for _, t := range computableHashes {
+		if sum := srcHash.GetHash(t); sum != "" {
+			ht, srcSum = t, sum
+			break
+		}
+	}
+	if srcSum == "" {
+		for _, t := range computableHashes {
+			if sum := dstHash.GetHash(t); sum != "" {
+				ht, dstSum = t, sum
+				break
+			}
+		}
+		if srcStorage == nil {
+			return errors.Errorf("no hash of the source of [%s] to verify", dstPath)
+		}
+		if srcSum, err = hashObj(ctx, srcStorage, srcPath, src, ht); err != nil {
+			return errors.WithMessagef(err, "failed compute %s of source [%s]", ht.Name, srcPath)
+		}
+	}
+	if dstSum == "" {
+		if dstSum, err = hashObj(ctx, dstStorage, dstPath, dst, ht); err != nil {
+			return errors.WithMessagef(err, "failed compute %s of [%s]", ht.Name, dstPath)
+		}
+	}
+	return compareHash(dstPath, ht, srcSum, dstSum)
+}
+
+func compareHash(path string, ht *utils.HashType, srcSum, dstSum string) error {
+	if !strings.EqualFold(srcSum, dstSum) {
+		return errors.Wrapf(errs.VerifyFailed, "%s mismatch of [%s]: source %s, destination %s", ht.Name, path, srcSum, dstSum)
+	}
+	return nil
+}
+
+// getPut gets the object just put, the list cache may not contain it yet
+func getPut(ctx context.Context, storage driver.Driver, path string) (model.Obj, error) {
+	if _, ok := storage.(driver.Getter); !ok {
+		if _, err := op.List(ctx, storage, stdpath.Dir(path), model.ListArgs{Refresh: true}); err != nil {
+			return nil, err
+		}
+	}
+	return op.Get(ctx, storage, path)
+}
+
+// hashObj computes the hash of the file by reading it through its link
+func hashObj(ctx context.Context, storage driver.Driver, path string, obj model.Obj, ht *utils.HashType) (string, error) {
+	link, _, err := op.Link(ctx, storage, path, model.LinkArgs{})
+	if err != nil {
+		return "", errors.WithMessage(err, "failed get link")
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{
+		Obj: obj,
+		Ctx: ctx,
+	}, link)
+	if err != nil {
+		_ = link.Close()
+		return "", errors.WithMessage(err, "failed get stream")
+	}
+	defer ss.Close()
+	return utils.HashReader(ht, ss)
+}
+
+// uploadedObj returns the uploaded file with a hash to verify it, the md5 is computed
+// from the file cached in the temp file if the client provided none of computableHashes
+func uploadedObj(file model.FileStreamer) (model.Obj, error) {
+	obj := &model.Object{
+		Name:     file.GetName(),
+		Size:     file.GetSize(),
+		HashInfo: file.GetHash(),
+	}
+	for _, ht := range computableHashes {
+		if obj.HashInfo.GetHash(ht) != "" {
+			return obj, nil
+		}
+	}
+	f, err := file.CacheFullInTempFile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create temp file")
+	}
+	sum, err := utils.HashFile(utils.MD5, f)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed compute md5")
+	}
+	obj.Size = file.GetSize()
+	obj.HashInfo = utils.NewHashInfo(utils.MD5, sum)
+	return obj, nil
+}
@@ -0,0 +1,83 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func TestVerifyTransfer(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeFiles(t, src, map[string]string{"a.txt": "hello"}, time.Now())
+	for mountPath, root := range map[string]string{"/verify_src": src, "/verify_dst": dst} {
+		_, err := op.CreateStorage(context.Background(), model.Storage{
+			Driver:    "Local",
+			MountPath: mountPath,
+			Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+		})
+		if err != nil {
+			t.Fatalf("failed create storage: %+v", err)
+		}
+	}
+	ctx := context.WithValue(context.Background(), conf.NoTaskKey, struct{}{})
+	ctx = context.WithValue(ctx, conf.VerifyTransferKey, struct{}{})
+
+	// neither side provides a hash, both are computed
+	if _, err := transfer(ctx, copy, "/verify_src/a.txt", "/verify_dst"); err != nil {
+		t.Fatalf("failed copy: %+v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "a.txt")); string(data) != "hello" {
+		t.Fatalf("unexpected content: %s", data)
+	}
+
+	dstStorage, _, err := op.GetStorageAndActualPath("/verify_dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct {
+		obj  model.Obj
+		fail bool
+	}{
+		"same md5":       {&model.Object{Name: "a.txt", Size: 5, HashInfo: utils.NewHashInfo(utils.MD5, utils.HashData(utils.MD5, []byte("hello")))}, false},
+		"different md5":  {&model.Object{Name: "a.txt", Size: 5, HashInfo: utils.NewHashInfo(utils.MD5, utils.HashData(utils.MD5, []byte("world")))}, true},
+		"different size": {&model.Object{Name: "a.txt", Size: 6}, true},
+	} {
+		err = verifyTransfer(ctx, tc.obj, nil, "", dstStorage, "/a.txt")
+		if tc.fail != errors.Is(err, errs.VerifyFailed) || !tc.fail && err != nil {
+			t.Errorf("%s: unexpected result: %v", name, err)
+		}
+	}
+
+	// the client provides a wrong hash of the uploaded file
+	err = putDirectly(ctx, "/verify_dst", &stream.FileStream{
+		Obj: &model.Object{
+			Name:     "b.txt",
+			Size:     5,
+			HashInfo: utils.NewHashInfo(utils.SHA1, utils.HashData(utils.SHA1, []byte("world"))),
+		},
+		Reader: strings.NewReader("hello"),
+	})
+	if !errors.Is(err, errs.VerifyFailed) {
+		t.Fatalf("expect verification failed, got %v", err)
+	}
+	// no hash provided, the md5 of the uploaded file is computed
+	err = putDirectly(ctx, "/verify_dst", &stream.FileStream{
+		Obj:    &model.Object{Name: "c.txt", Size: 5},
+		Reader: strings.NewReader("hello"),
+	})
+	if err != nil {
+		t.Fatalf("failed put: %+v", err)
+	}
+}
@@ -1,170 +1,574 @@
+//go:build fuse
+
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	stdpath "path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/generic_sync"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	pkgerr "github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+const blockSize = 4096
+
+// fileHandle is an opened file. Reads are served through a range request
+// on the object link kept open while the offsets are sequential, writes are
+// buffered in a temp file and uploaded on release.
+type fileHandle struct {
+	mu     sync.Mutex
+	path   string
+	obj    model.Obj
+	link   *model.Link
+	reader model.RangeReaderIF
+	rc     io.ReadCloser
+	offset int64
+	buffer *os.File
+	dirty  bool
+}
 
 type Fs struct {
 	RootFolder string
 	fuse.FileSystemBase
-}
-
-func (fs *Fs) Init() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Destroy() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mknod(path string, mode uint32, dev uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mkdir(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Unlink(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rmdir(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Link(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Symlink(target string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readlink(path string) (int, string) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rename(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chmod(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Access(path string, mask uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Open(path string, flags int) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Truncate(path string, size int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Flush(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Release(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsync(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Opendir(path string) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Releasedir(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Setxattr(path string, name string, value []byte, flags int) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getxattr(path string, name string) (int, []byte) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Removexattr(path string, name string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Listxattr(path string, fill func(name string) bool) int {
-	//TODO implement me
-	panic("implement me")
+	ctx     context.Context
+	handles generic_sync.MapOf[uint64, *fileHandle]
+	nextFh  atomic.Uint64
+}
+
+func NewFs(ctx context.Context, rootFolder string) *Fs {
+	return &Fs{
+		RootFolder: utils.FixAndCleanPath(rootFolder),
+		ctx:        ctx,
+	}
+}
+
+func (f *Fs) Init() {
+	log.Infof("fuse: serving %s", f.RootFolder)
+}
+
+func (f *Fs) Destroy() {
+	f.handles.Range(func(fh uint64, h *fileHandle) bool {
+		_ = f.release(h)
+		return true
+	})
+	f.handles.Clear()
+}
+
+func (f *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
+	// a storage not reporting its capacity is taken as a 1 TiB free one
+	var total, free uint64 = 1 << 40, 1 << 40
+	if details, err := op.GetStorageDetailsByPath(f.ctx, f.join(path), common.MountReadable(f.user())); err == nil && details.FreeSpace() >= 0 {
+		total, free = uint64(details.TotalSpace), uint64(details.FreeSpace())
+	}
+	*stat = fuse.Statfs_t{
+		Bsize:   blockSize,
+		Frsize:  blockSize,
+		Blocks:  total / blockSize,
+		Bfree:   free / blockSize,
+		Bavail:  free / blockSize,
+		Files:   1 << 20,
+		Ffree:   1 << 20,
+		Namemax: 255,
+	}
+	return 0
+}
+
+func (f *Fs) Mkdir(path string, mode uint32) int {
+	if !f.canWrite(f.join(path)) {
+		return -fuse.EACCES
+	}
+	return toErrno(fs.MakeDir(f.ctx, f.join(path)))
+}
+
+func (f *Fs) Unlink(path string) int {
+	if !common.HasPermission(f.user(), model.ACLRemove, f.user().CanRemove(), f.join(path)) {
+		return -fuse.EACCES
+	}
+	return toErrno(fs.Remove(f.ctx, f.join(path)))
+}
+
+func (f *Fs) Rmdir(path string) int {
+	if !common.HasPermission(f.user(), model.ACLRemove, f.user().CanRemove(), f.join(path)) {
+		return -fuse.EACCES
+	}
+	objs, err := fs.List(f.ctx, f.join(path), &fs.ListArgs{NoLog: true})
+	if err != nil {
+		return toErrno(err)
+	}
+	if len(objs) > 0 {
+		return -fuse.ENOTEMPTY
+	}
+	return toErrno(fs.Remove(f.ctx, f.join(path)))
+}
+
+func (f *Fs) Rename(oldpath string, newpath string) int {
+	srcPath, dstPath := f.join(oldpath), f.join(newpath)
+	srcDir, srcName := stdpath.Split(srcPath)
+	dstDir, dstName := stdpath.Split(dstPath)
+	user := f.user()
+	if !utils.PathEqual(srcDir, dstDir) && !common.HasPermission(user, model.ACLMove, user.CanMove(), srcPath, dstDir) ||
+		srcName != dstName && !common.HasPermission(user, model.ACLRename, user.CanRename(), srcPath) {
+		return -fuse.EACCES
+	}
+	// put the existing destination aside, it's removed only after the source takes its place
+	var aside string
+	if _, err := fs.Get(f.ctx, dstPath, &fs.GetArgs{NoLog: true}); err == nil {
+		if !common.HasPermission(user, model.ACLRemove, user.CanRemove(), dstPath) {
+			return -fuse.EACCES
+		}
+		aside = "." + dstName + ".fuse-replaced-" + random.String(8)
+		if err = fs.Rename(f.ctx, dstPath, aside); err != nil {
+			return toErrno(err)
+		}
+	}
+	err := f.rename(srcPath, dstPath)
+	if aside == "" {
+		return toErrno(err)
+	}
+	asidePath := stdpath.Join(dstDir, aside)
+	if err != nil {
+		if restoreErr := fs.Rename(f.ctx, asidePath, dstName); restoreErr != nil {
+			log.Errorf("fuse: failed restore %s from %s: %+v", dstPath, asidePath, restoreErr)
+		}
+		return toErrno(err)
+	}
+	if err = fs.Remove(f.ctx, asidePath); err != nil {
+		log.Warnf("fuse: failed remove the replaced %s: %+v", asidePath, err)
+	}
+	return 0
+}
+
+// rename moves the source to the destination path, it's reverted if only a part of it is done
+func (f *Fs) rename(srcPath, dstPath string) error {
+	srcDir, srcName := stdpath.Split(srcPath)
+	dstDir, dstName := stdpath.Split(dstPath)
+	if utils.PathEqual(srcDir, dstDir) {
+		return fs.Rename(f.ctx, srcPath, dstName)
+	}
+	ctx := context.WithValue(f.ctx, conf.NoTaskKey, struct{}{})
+	if srcName == dstName {
+		_, err := fs.Move(ctx, srcPath, dstDir)
+		return err
+	}
+	if _, err := fs.Get(f.ctx, stdpath.Join(dstDir, srcName), &fs.GetArgs{NoLog: true}); err != nil {
+		// move first, then rename in the destination dir
+		if _, err = fs.Move(ctx, srcPath, dstDir); err != nil {
+			return err
+		}
+		movedPath := stdpath.Join(dstDir, srcName)
+		if err = fs.Rename(f.ctx, movedPath, dstName); err != nil {
+			if _, revertErr := fs.Move(ctx, movedPath, srcDir); revertErr != nil {
+				log.Errorf("fuse: failed move %s back to %s: %+v", movedPath, srcDir, revertErr)
+			}
+			return err
+		}
+		return nil
+	}
+	// the name is taken in the destination dir, rename in place first
+	if err := fs.Rename(f.ctx, srcPath, dstName); err != nil {
+		return err
+	}
+	renamedPath := stdpath.Join(srcDir, dstName)
+	if _, err := fs.Move(ctx, renamedPath, dstDir); err != nil {
+		if revertErr := fs.Rename(f.ctx, renamedPath, srcName); revertErr != nil {
+			log.Errorf("fuse: failed rename %s back to %s: %+v", renamedPath, srcName, revertErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
+	// modification time is owned by the storage, accept and ignore
+	return 0
+}
+
+func (f *Fs) Chmod(path string, mode uint32) int {
+	return 0
+}
+
+func (f *Fs) Chown(path string, uid uint32, gid uint32) int {
+	return 0
+}
+
+func (f *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
+	if !f.canWrite(f.join(path)) {
+		return -fuse.EACCES, ^uint64(0)
+	}
+	buffer, err := os.CreateTemp(conf.Conf.TempDir, "fuse-*")
+	if err != nil {
+		return toErrno(err), ^uint64(0)
+	}
+	h := &fileHandle{path: f.join(path), buffer: buffer, dirty: true}
+	return 0, f.store(h)
+}
+
+func (f *Fs) Open(path string, flags int) (int, uint64) {
+	reqPath := f.join(path)
+	if !f.canRead(reqPath) {
+		return -fuse.EACCES, ^uint64(0)
+	}
+	obj, err := fs.Get(f.ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return toErrno(err), ^uint64(0)
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR, ^uint64(0)
+	}
+	h := &fileHandle{path: reqPath, obj: obj}
+	if flags&fuse.O_ACCMODE == fuse.O_RDONLY {
+		return 0, f.store(h)
+	}
+	if !f.canWrite(reqPath) {
+		return -fuse.EACCES, ^uint64(0)
+	}
+	h.buffer, err = os.CreateTemp(conf.Conf.TempDir, "fuse-*")
+	if err != nil {
+		return toErrno(err), ^uint64(0)
+	}
+	if flags&fuse.O_TRUNC != 0 {
+		h.dirty = true
+	} else if err = f.fill(h, obj.GetSize()); err != nil {
+		_ = h.close()
+		return toErrno(err), ^uint64(0)
+	}
+	return 0, f.store(h)
+}
+
+func (f *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
+	if h, ok := f.handles.Load(fh); ok && h.buffer != nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		info, err := h.buffer.Stat()
+		if err != nil {
+			return toErrno(err)
+		}
+		fillStat(stat, &model.Object{Size: info.Size(), Modified: info.ModTime()})
+		return 0
+	}
+	if !f.canRead(f.join(path)) {
+		return -fuse.EACCES
+	}
+	obj, err := fs.Get(f.ctx, f.join(path), &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return toErrno(err)
+	}
+	fillStat(stat, obj)
+	return 0
+}
+
+func (f *Fs) Truncate(path string, size int64, fh uint64) int {
+	if h, ok := f.handles.Load(fh); ok && h.buffer != nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.dirty = true
+		return toErrno(h.buffer.Truncate(size))
+	}
+	errc, fh := f.Open(path, fuse.O_RDWR)
+	if errc != 0 {
+		return errc
+	}
+	defer f.Release(path, fh)
+	return f.Truncate(path, size, fh)
+}
+
+func (f *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
+	h, ok := f.handles.Load(fh)
+	if !ok {
+		return -fuse.EBADF
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.buffer != nil {
+		n, err := h.buffer.ReadAt(buff, ofst)
+		if err != nil && err != io.EOF {
+			return toErrno(err)
+		}
+		return n
+	}
+	size := h.obj.GetSize()
+	if ofst >= size {
+		return 0
+	}
+	length := min(int64(len(buff)), size-ofst)
+	if err := f.prepareReader(h); err != nil {
+		return toErrno(err)
+	}
+	// continue the open range on sequential reads, reopen it on a seek
+	if h.rc == nil || h.offset != ofst {
+		h.closeRange()
+		rc, err := h.reader.RangeRead(f.ctx, http_range.Range{Start: ofst, Length: size - ofst})
+		if err != nil {
+			return toErrno(err)
+		}
+		h.rc, h.offset = rc, ofst
+	}
+	n, err := io.ReadFull(h.rc, buff[:length])
+	h.offset += int64(n)
+	if err != nil {
+		h.closeRange()
+		if err != io.ErrUnexpectedEOF && err != io.EOF {
+			return toErrno(err)
+		}
+	}
+	metrics.AddServedBytes("fuse", n)
+	if err = stream.ClientDownloadLimit.WaitN(f.ctx, n); err != nil {
+		return toErrno(err)
+	}
+	return n
+}
+
+func (f *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
+	h, ok := f.handles.Load(fh)
+	if !ok || h.buffer == nil {
+		return -fuse.EBADF
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n, err := h.buffer.WriteAt(buff, ofst)
+	if err != nil {
+		return toErrno(err)
+	}
+	h.dirty = true
+	return n
+}
+
+func (f *Fs) Flush(path string, fh uint64) int {
+	h, ok := f.handles.Load(fh)
+	if !ok {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return toErrno(f.upload(h))
+}
+
+func (f *Fs) Fsync(path string, datasync bool, fh uint64) int {
+	return f.Flush(path, fh)
+}
+
+func (f *Fs) Release(path string, fh uint64) int {
+	h, ok := f.handles.Load(fh)
+	if !ok {
+		return -fuse.EBADF
+	}
+	f.handles.Delete(fh)
+	return toErrno(f.release(h))
+}
+
+func (f *Fs) Opendir(path string) (int, uint64) {
+	if !f.canRead(f.join(path)) {
+		return -fuse.EACCES, ^uint64(0)
+	}
+	obj, err := fs.Get(f.ctx, f.join(path), &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return toErrno(err), ^uint64(0)
+	}
+	if !obj.IsDir() {
+		return -fuse.ENOTDIR, ^uint64(0)
+	}
+	return 0, 0
+}
+
+func (f *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
+	if !f.canRead(f.join(path)) {
+		return -fuse.EACCES
+	}
+	objs, err := fs.List(f.ctx, f.join(path), &fs.ListArgs{})
+	if err != nil {
+		return toErrno(err)
+	}
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	for _, obj := range objs {
+		stat := &fuse.Stat_t{}
+		fillStat(stat, obj)
+		if !fill(obj.GetName(), stat, 0) {
+			break
+		}
+	}
+	return 0
+}
+
+func (f *Fs) join(path string) string {
+	return stdpath.Join(f.RootFolder, path)
+}
+
+func (f *Fs) user() *model.User {
+	return f.ctx.Value(conf.UserKey).(*model.User)
+}
+
+// canRead checks the acl entries, the hidden and the password protected paths of the metas
+func (f *Fs) canRead(reqPath string) bool {
+	meta, _ := op.GetNearestMeta(reqPath)
+	return common.CanAccess(f.user(), meta, reqPath, "")
+}
+
+// canWrite checks the permission of the user, the acl entries and the write rules of the metas
+func (f *Fs) canWrite(reqPath string) bool {
+	user := f.user()
+	meta, _ := op.GetNearestMeta(reqPath)
+	return common.CanAccess(user, meta, reqPath, "") &&
+		common.HasPermission(user, model.ACLWrite, user.CanWrite() || common.CanWrite(meta, stdpath.Dir(reqPath)), reqPath)
+}
+
+func (f *Fs) store(h *fileHandle) uint64 {
+	fh := f.nextFh.Add(1)
+	f.handles.Store(fh, h)
+	return fh
+}
+
+func (f *Fs) prepareReader(h *fileHandle) error {
+	if h.reader != nil {
+		return nil
+	}
+	link, obj, err := fs.Link(f.ctx, h.path, model.LinkArgs{Header: http.Header{}})
+	if err != nil {
+		return err
+	}
+	reader, err := stream.GetRangeReaderFromLink(obj.GetSize(), link)
+	if err != nil {
+		_ = link.Close()
+		return err
+	}
+	h.link, h.reader = link, reader
+	return nil
+}
+
+// fill copies the current content of the object into the write buffer
+func (f *Fs) fill(h *fileHandle, size int64) error {
+	if size == 0 {
+		return nil
+	}
+	if err := f.prepareReader(h); err != nil {
+		return err
+	}
+	rc, err := h.reader.RangeRead(f.ctx, http_range.Range{Length: -1})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	_, err = utils.CopyWithBuffer(h.buffer, rc)
+	return err
+}
+
+func (f *Fs) upload(h *fileHandle) error {
+	if h.buffer == nil || !h.dirty {
+		return nil
+	}
+	info, err := h.buffer.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = h.buffer.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	dir, name := stdpath.Split(h.path)
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     info.Size(),
+			Modified: time.Now(),
+		},
+		Mimetype: utils.GetMimeType(name),
+		Reader:   h.buffer,
+	}
+	if err = fs.PutDirectly(f.ctx, dir, s); err != nil {
+		return err
+	}
+	h.dirty = false
+	return nil
+}
+
+func (f *Fs) release(h *fileHandle) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err := f.upload(h)
+	return errors.Join(err, h.close())
+}
+
+func (h *fileHandle) closeRange() {
+	if h.rc != nil {
+		_ = h.rc.Close()
+		h.rc = nil
+	}
+}
+
+func (h *fileHandle) close() error {
+	h.closeRange()
+	var err error
+	if h.link != nil {
+		err = h.link.Close()
+		h.link, h.reader = nil, nil
+	}
+	if h.buffer != nil {
+		name := h.buffer.Name()
+		err = errors.Join(err, h.buffer.Close(), os.Remove(name))
+		h.buffer = nil
+	}
+	return err
+}
+
+func fillStat(stat *fuse.Stat_t, obj model.Obj) {
+	*stat = fuse.Stat_t{}
+	mtime := fuse.NewTimespec(obj.ModTime())
+	ctime := mtime
+	if !obj.CreateTime().IsZero() {
+		ctime = fuse.NewTimespec(obj.CreateTime())
+	}
+	stat.Mtim, stat.Atim, stat.Ctim, stat.Birthtim = mtime, mtime, mtime, ctime
+	stat.Size = obj.GetSize()
+	stat.Blksize = blockSize
+	stat.Blocks = (stat.Size + 511) / 512
+	stat.Nlink = 1
+	if obj.IsDir() {
+		stat.Mode = fuse.S_IFDIR | 0755
+		stat.Nlink = 2
+	} else {
+		stat.Mode = fuse.S_IFREG | 0644
+	}
+}
+
+func toErrno(err error) int {
+	if err == nil {
+		return 0
+	}
+	switch cause := pkgerr.Cause(err); {
+	case errs.IsNotFoundError(err), os.IsNotExist(cause):
+		return -fuse.ENOENT
+	case errors.Is(cause, errs.PermissionDenied), os.IsPermission(cause):
+		return -fuse.EACCES
+	case errors.Is(cause, errs.UploadNotSupported):
+		return -fuse.EROFS
+	case errors.Is(cause, errs.NotFolder):
+		return -fuse.ENOTDIR
+	case errs.IsNotImplement(err), errs.IsNotSupportError(err):
+		return -fuse.ENOSYS
+	case errors.Is(cause, context.Canceled):
+		return -fuse.EINTR
+	}
+	log.Errorf("fuse: %+v", err)
+	return -fuse.EIO
 }
 
 var _ fuse.FileSystemInterface = (*Fs)(nil)
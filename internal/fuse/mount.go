@@ -1,9 +1,21 @@
+//go:build fuse
+
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"context"
+
+	"github.com/winfsp/cgofuse/fuse"
+)
 
-func Mount(mountSrc, mountDst string, opts []string) {
-	fs := &Fs{RootFolder: mountSrc}
-	host := fuse.NewFileSystemHost(fs)
-	go host.Mount(mountDst, opts)
+// Mount serves mountSrc of the openlist tree at mountDst.
+// It blocks until the filesystem is unmounted.
+func Mount(ctx context.Context, mountSrc, mountDst string, opts []string) bool {
+	host := fuse.NewFileSystemHost(NewFs(ctx, mountSrc))
+	host.SetCapReaddirPlus(true)
+	go func() {
+		<-ctx.Done()
+		host.Unmount()
+	}()
+	return host.Mount(mountDst, opts)
 }
@@ -0,0 +1,208 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/tache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/time/rate"
+)
+
+const namespace = "openlist"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	storageRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "storage_requests_total",
+		Help:      "Requests sent to storage drivers by the op layer.",
+	}, []string{"storage", "driver", "method"})
+	storageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_request_duration_seconds",
+		Help:      "Latency of requests sent to storage drivers.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"storage", "driver", "method"})
+	driverErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "driver_errors_total",
+		Help:      "Failed requests of storage drivers.",
+	}, []string{"driver", "method"})
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by result.",
+	}, []string{"cache", "result"})
+	servedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "served_bytes_total",
+		Help:      "Bytes sent to clients by endpoint, such as d, p, webdav, ftp, sftp and s3.",
+	}, []string{"endpoint"})
+	auditDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_logs_dropped_total",
+		Help:      "Audit logs lost because neither the queue nor the database took them.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		storageRequests, storageDuration, driverErrors, cacheRequests, servedBytes, auditDropped,
+		taskCollector{}, limiterCollector{},
+	)
+}
+
+// Handler serves the metrics in the prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveStorageRequest records a request sent to the driver of the storage mounted at mountPath
+func ObserveStorageRequest(mountPath, driver, method string, d time.Duration, err error) {
+	storageRequests.WithLabelValues(mountPath, driver, method).Inc()
+	storageDuration.WithLabelValues(mountPath, driver, method).Observe(d.Seconds())
+	if err != nil && !errors.Is(err, errs.NotImplement) && !errs.IsObjectNotFound(err) {
+		driverErrors.WithLabelValues(driver, method).Inc()
+	}
+}
+
+// DeleteStorage drops the series of a removed storage
+func DeleteStorage(mountPath string) {
+	storageRequests.DeletePartialMatch(prometheus.Labels{"storage": mountPath})
+	storageDuration.DeletePartialMatch(prometheus.Labels{"storage": mountPath})
+}
+
+func CacheHit(cache string) {
+	cacheRequests.WithLabelValues(cache, "hit").Inc()
+}
+
+func CacheMiss(cache string) {
+	cacheRequests.WithLabelValues(cache, "miss").Inc()
+}
+
+func AddServedBytes(endpoint string, n int) {
+	if n > 0 {
+		servedBytes.WithLabelValues(endpoint).Add(float64(n))
+	}
+}
+
+func AddAuditLogsDropped(n int) {
+	if n > 0 {
+		auditDropped.Add(float64(n))
+	}
+}
+
+var taskStateNames = map[tache.State]string{
+	tache.StatePending:      "pending",
+	tache.StateRunning:      "running",
+	tache.StateSucceeded:    "succeeded",
+	tache.StateCanceling:    "canceling",
+	tache.StateCanceled:     "canceled",
+	tache.StateErrored:      "errored",
+	tache.StateFailing:      "failing",
+	tache.StateFailed:       "failed",
+	tache.StateWaitingRetry: "waiting_retry",
+	tache.StateBeforeRetry:  "before_retry",
+}
+
+var (
+	taskDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "tasks"),
+		"Tasks held by the task managers by type and state.", []string{"type", "state"}, nil)
+	taskMu       sync.RWMutex
+	taskManagers = map[string]func() []tache.State{}
+)
+
+// RegisterTaskManager exposes the tasks of m under the given type
+func RegisterTaskManager[T tache.Task](typ string, m *tache.Manager[T]) {
+	taskMu.Lock()
+	defer taskMu.Unlock()
+	taskManagers[typ] = func() []tache.State {
+		tasks := m.GetAll()
+		states := make([]tache.State, 0, len(tasks))
+		for _, t := range tasks {
+			states = append(states, t.GetState())
+		}
+		return states
+	}
+}
+
+type taskCollector struct{}
+
+func (taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- taskDesc
+}
+
+func (taskCollector) Collect(ch chan<- prometheus.Metric) {
+	taskMu.RLock()
+	defer taskMu.RUnlock()
+	for typ, states := range taskManagers {
+		count := make(map[tache.State]int, len(taskStateNames))
+		for _, s := range states() {
+			count[s]++
+		}
+		for s, name := range taskStateNames {
+			ch <- prometheus.MustNewConstMetric(taskDesc, prometheus.GaugeValue, float64(count[s]), typ, name)
+		}
+	}
+}
+
+// Limiter is the part of stream.Limiter the metrics need
+type Limiter interface {
+	Limit() rate.Limit
+	Burst() int
+	Tokens() float64
+}
+
+var (
+	limitDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "rate_limit_bytes_per_second"),
+		"Configured rate of the limiter, -1 means unlimited.", []string{"limiter"}, nil)
+	limitUsageDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "rate_limit_usage_ratio"),
+		"Part of the limiter burst that is currently consumed.", []string{"limiter"}, nil)
+	limiterMu sync.RWMutex
+	limiters  = map[string]func() Limiter{}
+)
+
+// RegisterLimiter exposes the limiter returned by get, it is a func because limiters may be replaced
+func RegisterLimiter(name string, get func() Limiter) {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+	limiters[name] = get
+}
+
+type limiterCollector struct{}
+
+func (limiterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- limitDesc
+	ch <- limitUsageDesc
+}
+
+func (limiterCollector) Collect(ch chan<- prometheus.Metric) {
+	limiterMu.RLock()
+	defer limiterMu.RUnlock()
+	for name, get := range limiters {
+		l := get()
+		if l == nil {
+			continue
+		}
+		limit, usage := -1.0, 0.0
+		if l.Limit() != rate.Inf {
+			limit = float64(l.Limit())
+			if burst := float64(l.Burst()); burst > 0 {
+				usage = 1 - l.Tokens()/burst
+				if usage < 0 {
+					usage = 0
+				}
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(limitDesc, prometheus.GaugeValue, limit, name)
+		ch <- prometheus.MustNewConstMetric(limitUsageDesc, prometheus.GaugeValue, usage, name)
+	}
+}
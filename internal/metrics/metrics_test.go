@@ -0,0 +1,39 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"golang.org/x/time/rate"
+)
+
+func TestHandler(t *testing.T) {
+	metrics.ObserveStorageRequest("/local", "Local", "list", time.Millisecond, nil)
+	metrics.ObserveStorageRequest("/local", "Local", "link", time.Millisecond, errors.New("boom"))
+	metrics.CacheHit("list")
+	metrics.CacheMiss("list")
+	metrics.AddServedBytes("d", 1024)
+	metrics.AddAuditLogsDropped(1)
+	metrics.RegisterLimiter("test", func() metrics.Limiter { return rate.NewLimiter(rate.Inf, 0) })
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, want := range []string{
+		`openlist_storage_requests_total{driver="Local",method="list",storage="/local"} 1`,
+		`openlist_driver_errors_total{driver="Local",method="link"} 1`,
+		`openlist_cache_requests_total{cache="list",result="hit"} 1`,
+		`openlist_served_bytes_total{endpoint="d"} 1024`,
+		`openlist_audit_logs_dropped_total 1`,
+		`openlist_rate_limit_bytes_per_second{limiter="test"} -1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
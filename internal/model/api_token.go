@@ -0,0 +1,119 @@
+package model
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// APITokenPrefix starts the key id of every api token, which tells them from the login tokens
+const APITokenPrefix = "OLT"
+
+// the scopes of an api token
+const (
+	TokenScopeFsRead int32 = 1 << iota
+	TokenScopeFsWrite
+	TokenScopeTasks
+	TokenScopeAdmin
+)
+
+// the permission bits of the user an api token without the scope can't use
+const (
+	fsReadPermission  int32 = 1<<8 | 1<<10 | 1<<12
+	fsWritePermission int32 = 1<<2 | 1<<3 | 1<<4 | 1<<5 | 1<<6 | 1<<7 | 1<<9 | 1<<11 | 1<<13
+)
+
+// APIToken is a named token of a user for automations, acting as the user restricted by its
+// scopes, path and ips. The token is only shown on creation, the hash of it is stored.
+type APIToken struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"index"`
+	Name   string `json:"name" binding:"required"`
+	// the public part of the token, also the access key id of s3
+	KeyID string `json:"key_id" gorm:"unique"`
+	Hash  string `json:"-"`
+	// the secret access key of s3, encrypted like the confidential fields of the storages
+	S3Secret string `json:"-" gorm:"type:text"`
+	Scopes   int32  `json:"scopes"`
+	// the path the token is restricted to, relative to the base path of the user
+	PathPrefix string `json:"path_prefix"`
+	// ips or cidrs separated by commas, empty means any
+	AllowedIPs   string     `json:"allowed_ips"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	CreatedTime  time.Time  `json:"created_time"`
+	LastUsedTime time.Time  `json:"last_used_time"`
+	LastUsedIP   string     `json:"last_used_ip"`
+}
+
+func (t *APIToken) HasScope(scope int32) bool {
+	return t.Scopes&scope == scope
+}
+
+func (t *APIToken) IsExpired() bool {
+	return t.ExpiresAt != nil && !t.ExpiresAt.IsZero() && time.Now().After(*t.ExpiresAt)
+}
+
+// AllowIP reports whether the token can be used from the ip, the port is ignored
+func (t *APIToken) AllowIP(ip string) bool {
+	if t.AllowedIPs == "" {
+		return true
+	}
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, item := range strings.Split(t.AllowedIPs, ",") {
+		item = strings.TrimSpace(item)
+		if _, cidr, err := net.ParseCIDR(item); err == nil {
+			if cidr.Contains(addr) {
+				return true
+			}
+		} else if allowed := net.ParseIP(item); allowed != nil && allowed.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Restrict returns a copy of the user the token acts as. The permission bits of the missing
+// scopes are cleared, and an admin without the admin scope is taken as a general user.
+func (t *APIToken) Restrict(u *User) *User {
+	user := *u
+	user.Token = t
+	if !t.HasScope(TokenScopeFsRead) {
+		user.Permission &^= fsReadPermission
+	}
+	if !t.HasScope(TokenScopeFsWrite) {
+		user.Permission &^= fsWritePermission
+	}
+	if user.IsAdmin() && !t.HasScope(TokenScopeAdmin) {
+		user.Role = GENERAL
+	}
+	return &user
+}
+
+// Allows reports whether the token lets the user do the operation on the full path. Reading
+// the parents of the path prefix is allowed so that it can be reached.
+func (t *APIToken) Allows(u *User, reqPath string, perm int32) bool {
+	if perm == ACLRead {
+		if !t.HasScope(TokenScopeFsRead) {
+			return false
+		}
+	} else if !t.HasScope(TokenScopeFsWrite) {
+		return false
+	}
+	if t.PathPrefix == "" || t.PathPrefix == "/" {
+		return true
+	}
+	prefix, err := u.JoinPath(t.PathPrefix)
+	if err != nil {
+		return false
+	}
+	reqPath = utils.FixAndCleanPath(reqPath)
+	return utils.IsSubPath(prefix, reqPath) || perm == ACLRead && utils.IsSubPath(reqPath, prefix)
+}
@@ -73,8 +73,49 @@ type ArchiveListArgs struct {
 
 type ArchiveDecompressArgs struct {
 	ArchiveInnerArgs
-	CacheFull     bool
-	PutIntoNewDir bool
+	CacheFull      bool
+	PutIntoNewDir  bool
+	ConflictPolicy ConflictPolicy
+}
+
+// ConflictPolicy decides what to do when a file being put exists in the destination,
+// the empty policy overwrites it as the storage does
+type ConflictPolicy string
+
+const (
+	ConflictOverwrite   ConflictPolicy = "overwrite"
+	ConflictSkip        ConflictPolicy = "skip"
+	ConflictRename      ConflictPolicy = "rename"       // put with a suffix like "name (1).ext"
+	ConflictNewer       ConflictPolicy = "newer"        // overwrite only if the source is newer
+	ConflictSizeDiffers ConflictPolicy = "size_differs" // overwrite only if the sizes differ
+)
+
+func (p ConflictPolicy) Valid() bool {
+	switch p {
+	case "", ConflictOverwrite, ConflictSkip, ConflictRename, ConflictNewer, ConflictSizeDiffers:
+		return true
+	}
+	return false
+}
+
+// Checked reports whether the destination must be checked before putting
+func (p ConflictPolicy) Checked() bool {
+	return p != "" && p != ConflictOverwrite
+}
+
+type SyncArgs struct {
+	// delete the objects in the destination which are not in the source
+	Delete bool `json:"delete"`
+	// refresh the listings instead of using the cache
+	Refresh bool `json:"refresh"`
+	// compare the hashes of the copied files, also done if the destination storage requires it
+	Verify bool `json:"verify"`
+}
+
+type ArchiveCompressArgs struct {
+	// zip, tar or tar.gz
+	Format   string
+	Password string
 }
 
 type RangeReaderIF interface {
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+type AuditLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Time      time.Time `json:"time" gorm:"index"`
+	UserId    uint      `json:"user_id"`
+	Username  string    `json:"username" gorm:"index"`
+	Protocol  string    `json:"protocol"`  // http, webdav, ftp, sftp, s3, share, fuse
+	Operation string    `json:"operation"` // mkdir, rename, move, copy, remove, upload, ...
+	SrcPath   string    `json:"src_path" gorm:"index"`
+	DstPath   string    `json:"dst_path"`
+	Size      int64     `json:"size"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error"`
+	IP        string    `json:"ip"`
+}
+
+type AuditQuery struct {
+	Username  string    `json:"username" form:"username"`
+	Path      string    `json:"path" form:"path"` // prefix of the source or destination path
+	Operation string    `json:"operation" form:"operation"`
+	Protocol  string    `json:"protocol" form:"protocol"`
+	IP        string    `json:"ip" form:"ip"`
+	Start     time.Time `json:"start" form:"start"`
+	End       time.Time `json:"end" form:"end"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// DeadProp is a property set on an object by the webdav PROPPATCH, kept by the full path
+type DeadProp struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Path     string `json:"path" gorm:"index"`
+	Space    string `json:"space"`
+	Local    string `json:"local"`
+	Lang     string `json:"lang"`
+	InnerXML string `json:"inner_xml" gorm:"type:text"`
+}
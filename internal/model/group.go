@@ -0,0 +1,39 @@
+package model
+
+type Group struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"unique" binding:"required"`
+	Description string `json:"description"`
+	// the members of the group, stored in GroupMember
+	UserIds []uint `json:"user_ids" gorm:"-"`
+}
+
+// GroupMember puts a user in a group
+type GroupMember struct {
+	GroupID uint `gorm:"primaryKey"`
+	UserID  uint `gorm:"primaryKey;index"`
+}
+
+// the operations an ACL entry grants or denies
+const (
+	ACLRead int32 = 1 << iota
+	ACLWrite
+	ACLRename
+	ACLMove
+	ACLCopy
+	ACLRemove
+	ACLOfflineDownload
+)
+
+// ACL grants or denies operations on a path and its sub paths to a user or a group.
+// The entries of the nearest path decide, the ones of the user over the ones of its groups,
+// and deny over allow. The operations no entry decides are left to the permission of the user.
+type ACL struct {
+	ID   uint   `json:"id" gorm:"primaryKey"`
+	Path string `json:"path" gorm:"index" binding:"required"`
+	// exactly one of UserID and GroupID is set
+	UserID  uint  `json:"user_id" gorm:"index"`
+	GroupID uint  `json:"group_id" gorm:"index"`
+	Allow   int32 `json:"allow"`
+	Deny    int32 `json:"deny"`
+}
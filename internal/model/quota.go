@@ -0,0 +1,26 @@
+package model
+
+// UserUsage is the upload accounting of a user, it is compared against User.MaxBytes and User.MaxFiles
+type UserUsage struct {
+	UserId    uint  `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	UsedBytes int64 `json:"used_bytes"`
+	UsedFiles int64 `json:"used_files"`
+}
+
+type UserUsageReport struct {
+	UserUsage
+	Username string `json:"username"`
+	MaxBytes int64  `json:"max_bytes"`
+	MaxFiles int64  `json:"max_files"`
+}
+
+// UsageRecord is a file accounted in the usage of the user who uploaded it, so that the usage
+// is released from that user whoever removes the file. The records under the recycle bin of a
+// storage are released already, and accounted again when restored.
+type UsageRecord struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	StorageId uint   `json:"storage_id" gorm:"index"`
+	Path      string `json:"path" gorm:"index"`
+	UserId    uint   `json:"user_id" gorm:"index"`
+	Bytes     int64  `json:"bytes"`
+}
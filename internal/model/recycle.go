@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// RecycleItem is an object removed into the recycle bin of a storage
+type RecycleItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	MountPath string    `json:"mount_path" gorm:"index"`
+	Path      string    `json:"path"` // original full path
+	TrashPath string    `json:"-"`    // actual path of the folder holding the object in the storage
+	Name      string    `json:"name"`
+	IsDir     bool      `json:"is_dir"`
+	Size      int64     `json:"size"`
+	UserId    uint      `json:"user_id" gorm:"index"`
+	Username  string    `json:"username"`
+	Deleted   time.Time `json:"deleted" gorm:"index"`
+}
@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// S3KeyPrefix starts the access key id of every s3 key of the users
+const S3KeyPrefix = "OLS"
+
+// S3Key is an access key of the s3 server owned by a user, the requests signed by it act as
+// the user. The secret access key is random, it's only shown on creation.
+type S3Key struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	UserID      uint   `json:"user_id" gorm:"index"`
+	Name        string `json:"name"`
+	AccessKeyID string `json:"access_key_id" gorm:"unique"`
+	// the secret access key, encrypted like the confidential fields of the storages
+	Secret       string    `json:"-" gorm:"type:text"`
+	CreatedTime  time.Time `json:"created_time"`
+	LastUsedTime time.Time `json:"last_used_time"`
+	LastUsedIP   string    `json:"last_used_ip"`
+}
+
+// S3Bucket is a bucket of the s3 server defined by a user, only the user sees it
+type S3Bucket struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"uniqueIndex:idx_s3_bucket_user_name"`
+	Name   string `json:"name" gorm:"uniqueIndex:idx_s3_bucket_user_name;size:63"`
+	// relative to the base path of the user
+	Path string `json:"path"`
+}
+
+// S3MultipartUpload is a multipart upload of the s3 server in progress. The parts are spooled in
+// the temp dir, the instances serving the same uploads must share it.
+// The active time is unix nanoseconds so that it compares the same way in every database.
+type S3MultipartUpload struct {
+	ID     string `json:"id" gorm:"primaryKey;size:32"`
+	Bucket string `json:"bucket" gorm:"index"`
+	Object string `json:"object" gorm:"type:text"`
+	UserID uint   `json:"user_id"`
+	// the headers of the initiation stored with the object, in json
+	Meta      string    `json:"meta" gorm:"type:text"`
+	Initiated time.Time `json:"initiated"`
+	Active    int64     `json:"active" gorm:"index"`
+	// whether the parts are being handed to the driver, they can't be changed meanwhile
+	Completing bool `json:"completing"`
+}
+
+// S3MultipartPart is an uploaded part of a multipart upload
+type S3MultipartPart struct {
+	UploadID string `json:"upload_id" gorm:"primaryKey;size:32"`
+	Number   int    `json:"number" gorm:"primaryKey;autoIncrement:false"`
+	// the name of the spool file in the dir of the upload
+	File     string    `json:"file"`
+	Size     int64     `json:"size"`
+	MD5      string    `json:"md5"`
+	Modified time.Time `json:"modified"`
+}
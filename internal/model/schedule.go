@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+const (
+	ScheduleCopy            = "copy"
+	ScheduleMove            = "move"
+	ScheduleIndex           = "index"
+	ScheduleOfflineDownload = "offline_download"
+	ScheduleSync            = "sync"
+)
+
+// ScheduledJob adds tasks of the type periodically, as the user who created it
+type ScheduledJob struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name"`
+	Cron     string `json:"cron" binding:"required"` // cron expression, see pkg/cron.Parse
+	Type     string `json:"type" binding:"required"` // copy, move, index, offline_download, sync
+	Args     string `json:"args" gorm:"type:text"`   // json of the ScheduleArgs
+	Disabled bool   `json:"disabled"`
+	UserId   uint   `json:"user_id"`
+
+	LastRunTime *time.Time `json:"last_run_time"`
+	LastSuccess bool       `json:"last_success"`
+	LastError   string     `json:"last_error"`
+	NextRunTime *time.Time `json:"next_run_time" gorm:"-"`
+}
+
+// ScheduleArgs are the arguments of a scheduled job, only the fields of its type are used
+type ScheduleArgs struct {
+	// copy, move, sync
+	SrcDir    string   `json:"src_dir,omitempty"`
+	DstDir    string   `json:"dst_dir,omitempty"`
+	Names     []string `json:"names,omitempty"`
+	Overwrite bool     `json:"overwrite,omitempty"`
+	Delete    bool     `json:"delete,omitempty"` // sync only
+	Verify    bool     `json:"verify,omitempty"`
+	// copy and move only, the job fails for the existing names if empty and not overwrite
+	ConflictPolicy ConflictPolicy `json:"conflict_policy,omitempty"`
+	// index
+	Paths []string `json:"paths,omitempty"`
+	// offline_download
+	Urls         []string `json:"urls,omitempty"`
+	Path         string   `json:"path,omitempty"`
+	Tool         string   `json:"tool,omitempty"`
+	DeletePolicy string   `json:"delete_policy,omitempty"`
+}
+
+type ScheduledJobRun struct {
+	ID      uint      `json:"id" gorm:"primaryKey"`
+	JobId   uint      `json:"job_id" gorm:"index"`
+	Start   time.Time `json:"start" gorm:"index"`
+	End     time.Time `json:"end"`
+	Manual  bool      `json:"manual"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error"`
+	TaskIds string    `json:"task_ids"` // comma separated ids of the added tasks
+}
@@ -2,6 +2,9 @@ package model
 
 import (
 	"fmt"
+	"path"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -17,14 +20,41 @@ type SearchReq struct {
 	Keywords string `json:"keywords"`
 	// 0 for all, 1 for dir, 2 for file
 	Scope int `json:"scope"`
+	// size range in bytes, 0 means no limit
+	MinSize int64 `json:"min_size"`
+	MaxSize int64 `json:"max_size"`
+	// modification time range
+	ModifiedAfter  *time.Time `json:"modified_after"`
+	ModifiedBefore *time.Time `json:"modified_before"`
+	// file extensions without the leading dot, case-insensitive
+	Exts []string `json:"exts"`
+	// pattern matching the whole name, interpreted according to PatternType,
+	// only supported by the database backends
+	Pattern string `json:"pattern"`
+	// glob or regex, defaults to glob
+	PatternType string `json:"pattern_type"`
+	// name, size or modified, defaults to name
+	OrderBy string `json:"order_by"`
+	// asc or desc, defaults to asc
+	OrderDirection string `json:"order_direction"`
 	PageReq
+
+	nameRegexp *regexp.Regexp
 }
 
+const (
+	PatternGlob  = "glob"
+	PatternRegex = "regex"
+)
+
 type SearchNode struct {
-	Parent string `json:"parent" gorm:"index"`
-	Name   string `json:"name"`
-	IsDir  bool   `json:"is_dir"`
-	Size   int64  `json:"size"`
+	Parent    string    `json:"parent" gorm:"index"`
+	Name      string    `json:"name"`
+	IsDir     bool      `json:"is_dir"`
+	Size      int64     `json:"size" gorm:"index"`
+	Modified  time.Time `json:"modified" gorm:"index"`
+	Ext       string    `json:"ext" gorm:"index"`
+	MountPath string    `json:"mount_path" gorm:"index"`
 }
 
 func (p *SearchReq) Validate() error {
@@ -34,9 +64,70 @@ func (p *SearchReq) Validate() error {
 	if p.PerPage < 1 {
 		return fmt.Errorf("per_page can't < 1")
 	}
+	if p.MinSize < 0 || p.MaxSize < 0 {
+		return fmt.Errorf("size can't < 0")
+	}
+	if p.MaxSize > 0 && p.MinSize > p.MaxSize {
+		return fmt.Errorf("min_size can't > max_size")
+	}
+	if p.ModifiedAfter != nil && p.ModifiedBefore != nil && p.ModifiedAfter.After(*p.ModifiedBefore) {
+		return fmt.Errorf("modified_after can't be later than modified_before")
+	}
+	for i := range p.Exts {
+		p.Exts[i] = NormalizeExt(p.Exts[i])
+	}
+	if p.Pattern != "" {
+		switch p.PatternType {
+		case "", PatternGlob:
+			p.PatternType = PatternGlob
+			if _, err := path.Match(p.Pattern, ""); err != nil {
+				return fmt.Errorf("invalid glob pattern: %w", err)
+			}
+		case PatternRegex:
+			re, err := regexp.Compile("^(?:" + p.Pattern + ")$")
+			if err != nil {
+				return fmt.Errorf("invalid regex pattern: %w", err)
+			}
+			p.nameRegexp = re
+		default:
+			return fmt.Errorf("invalid pattern_type: %s", p.PatternType)
+		}
+	}
+	switch p.OrderBy {
+	case "":
+		p.OrderBy = "name"
+	case "name", "size", "modified":
+	default:
+		return fmt.Errorf("invalid order_by: %s", p.OrderBy)
+	}
+	switch p.OrderDirection {
+	case "":
+		p.OrderDirection = "asc"
+	case "asc", "desc":
+	default:
+		return fmt.Errorf("invalid order_direction: %s", p.OrderDirection)
+	}
 	return nil
 }
 
+// MatchName reports whether name matches the pattern of the request.
+// Validate must have been called before.
+func (p *SearchReq) MatchName(name string) bool {
+	if p.Pattern == "" {
+		return true
+	}
+	if p.nameRegexp != nil {
+		return p.nameRegexp.MatchString(name)
+	}
+	ok, _ := path.Match(p.Pattern, name)
+	return ok
+}
+
+// NormalizeExt returns the lowercase extension without the leading dot.
+func NormalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
 func (s *SearchNode) Type() string {
 	return "SearchNode"
 }
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Session is a login of a user, the tokens issued on it are only accepted until it is
+// revoked or expired. The refresh token of it is stored hashed.
+type Session struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// put in the claims of the tokens
+	SID         string `json:"-" gorm:"unique"`
+	UserID      uint   `json:"user_id" gorm:"index"`
+	Device      string `json:"device"`
+	IP          string `json:"ip"`
+	UserAgent   string `json:"user_agent"`
+	RefreshHash string `json:"-"`
+	// the password timestamp of the user on login, a password change revokes the session
+	PwdTS       int64     `json:"-"`
+	CreatedTime time.Time `json:"created_time"`
+	LastSeen    time.Time `json:"last_seen"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// whether it is the session of the request, only set on listing
+	Current bool `json:"current" gorm:"-"`
+}
+
+func (s *Session) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+var (
+	deviceOSes = []struct{ key, name string }{
+		{"Android", "Android"}, {"iPhone", "iPhone"}, {"iPad", "iPad"}, {"Windows", "Windows"},
+		{"Mac OS X", "macOS"}, {"Macintosh", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	}
+	deviceBrowsers = []struct{ key, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"},
+		{"Safari/", "Safari"}, {"curl/", "curl"}, {"okhttp/", "OkHttp"}, {"Go-http-client/", "Go"},
+	}
+)
+
+// DeviceOf names the device by the user agent, like "Chrome on Windows"
+func DeviceOf(userAgent string) string {
+	var os, browser string
+	for _, o := range deviceOSes {
+		if strings.Contains(userAgent, o.key) {
+			os = o.name
+			break
+		}
+	}
+	for _, b := range deviceBrowsers {
+		if strings.Contains(userAgent, b.key) {
+			browser = b.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	}
+	return "Unknown"
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+)
+
+type Share struct {
+	ID          string     `json:"id" gorm:"primaryKey;size:32"`
+	UserId      uint       `json:"user_id" gorm:"index"`
+	Path        string     `json:"path" binding:"required"` // full path, already joined with the creator's base path
+	Password    string     `json:"password"`
+	Expires     *time.Time `json:"expires"`
+	MaxAccessed int64      `json:"max_accessed"` // 0 means unlimited
+	Accessed    int64      `json:"accessed"`     // downloads and uploads through the share
+	AllowUpload bool       `json:"allow_upload"`
+	Disabled    bool       `json:"disabled"`
+	Remark      string     `json:"remark"`
+	Created     time.Time  `json:"created"`
+}
+
+func (s *Share) IsExpired() bool {
+	return s.Expires != nil && !s.Expires.IsZero() && time.Now().After(*s.Expires)
+}
+
+func (s *Share) IsExhausted() bool {
+	return s.MaxAccessed > 0 && s.Accessed >= s.MaxAccessed
+}
+
+// Valid reports why the share can't be used, if any
+func (s *Share) Valid() error {
+	if s.Disabled {
+		return errs.ShareDisabled
+	}
+	if s.IsExpired() {
+		return errs.ShareExpired
+	}
+	if s.IsExhausted() {
+		return errs.ShareExhausted
+	}
+	return nil
+}
+
+// Visitor returns the user that anonymous visitors of the share act as.
+// It is scoped to the shared path and can never do more than the creator,
+// uploads through the share are counted in the creator's quota.
+func (s *Share) Visitor(creator *User) *User {
+	visitor := *creator
+	visitor.Role = GENERAL
+	visitor.BasePath = s.Path
+	visitor.OtpSecret = ""
+	visitor.Authn = ""
+	// the password of the share is the only one skipped, visitors see hides applied
+	// and are asked for the passwords of the metas under the shared path
+	visitor.Permission = 0
+	if s.AllowUpload && creator.CanWrite() {
+		visitor.Permission |= 1 << 3
+	}
+	return &visitor
+}
+
+// ShareDownload is a download of a shared file counted for a client, so that its ranged requests
+// seeking or resuming the file are not counted again on any instance. The ID is the hash of the
+// share, the client ip and the path, the expiry is in unix nanoseconds.
+type ShareDownload struct {
+	ID      string `json:"id" gorm:"primaryKey;size:64"`
+	ShareID string `json:"share_id" gorm:"index;size:32"`
+	Expiry  int64  `json:"expiry" gorm:"index"`
+}
@@ -16,7 +16,11 @@ type Storage struct {
 	Modified        time.Time `json:"modified"`
 	Disabled        bool      `json:"disabled"` // if disabled
 	DisableIndex    bool      `json:"disable_index"`
+	IndexInterval   int       `json:"index_interval"` // minutes between incremental index updates, 0 to disable
+	IndexDepth      int       `json:"index_depth"`    // max depth of incremental index updates, 0 to use the global setting
 	EnableSign      bool      `json:"enable_sign"`
+	RecycleBin      bool      `json:"recycle_bin"`     // move removed objects into the recycle bin
+	VerifyTransfer  bool      `json:"verify_transfer"` // verify the files copied, moved or uploaded to the storage
 	Sort
 	Proxy
 }
@@ -55,3 +59,17 @@ func (p Proxy) Webdav302() bool {
 func (p Proxy) WebdavProxyURL() bool {
 	return p.WebdavPolicy == "use_proxy_url"
 }
+
+// StorageDetails is the capacity of a storage in bytes, a zero TotalSpace means it's unknown
+type StorageDetails struct {
+	TotalSpace int64 `json:"total_space"`
+	UsedSpace  int64 `json:"used_space"`
+}
+
+// FreeSpace returns the space left, or -1 if the total space is unknown
+func (d StorageDetails) FreeSpace() int64 {
+	if d.TotalSpace <= 0 {
+		return -1
+	}
+	return max(d.TotalSpace-d.UsedSpace, 0)
+}
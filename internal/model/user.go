@@ -58,6 +58,11 @@ type User struct {
 	OtpSecret  string `json:"-"`
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
+	// upload quota, 0 means unlimited, the usage is tracked in UserUsage
+	MaxBytes int64 `json:"max_bytes"`
+	MaxFiles int64 `json:"max_files"`
+	// the api token the request is authenticated by, nil for the login ones
+	Token *APIToken `json:"-" gorm:"-"`
 }
 
 func (u *User) IsGuest() bool {
@@ -0,0 +1,24 @@
+package model
+
+// WebDAVLock is a lock of the webdav LOCK method, shared by all the instances on the database.
+// The times are unix nanoseconds so that they compare the same way in every database.
+type WebDAVLock struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	Token     string `json:"token" gorm:"unique"`
+	Root      string `json:"root" gorm:"unique"`
+	ZeroDepth bool   `json:"zero_depth"`
+	OwnerXML  string `json:"owner_xml" gorm:"type:text"`
+	// negative means the lock never expires
+	Duration int64 `json:"duration"`
+	Expiry   int64 `json:"expiry" gorm:"index"`
+	// whether the lock is held by a request, and since when
+	Held   bool  `json:"held"`
+	HeldAt int64 `json:"held_at"`
+}
+
+// WebDAVLockGuard is the single row updated first by the creation of a webdav lock,
+// so that the instances check the conflicts and create the locks one at a time
+type WebDAVLockGuard struct {
+	ID      uint  `json:"id" gorm:"primaryKey"`
+	Version int64 `json:"version"`
+}
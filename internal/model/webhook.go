@@ -0,0 +1,62 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+type Webhook struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	Name   string `json:"name"`
+	URL    string `json:"url" binding:"required"`
+	Secret string `json:"secret"` // key of the HMAC-SHA256 signature of the body, empty means unsigned
+	// comma separated events like fs.put or task.failed, task.* matches all task events, empty means all
+	Events     string `json:"events"`
+	PathPrefix string `json:"path_prefix"` // only applies to fs events
+	Disabled   bool   `json:"disabled"`
+}
+
+// Match reports whether the event on the paths should be delivered to the webhook
+func (w *Webhook) Match(event string, paths ...string) bool {
+	if w.Disabled || !w.matchEvent(event) {
+		return false
+	}
+	if w.PathPrefix == "" || w.PathPrefix == "/" || !strings.HasPrefix(event, "fs.") {
+		return true
+	}
+	prefix := strings.TrimSuffix(w.PathPrefix, "/")
+	for _, p := range paths {
+		if p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Webhook) matchEvent(event string) bool {
+	if strings.TrimSpace(w.Events) == "" {
+		return true
+	}
+	for _, e := range strings.Split(w.Events, ",") {
+		e = strings.TrimSpace(e)
+		if e == "*" || e == event || (strings.HasSuffix(e, ".*") && strings.HasPrefix(event, e[:len(e)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is an attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	WebhookId  uint      `json:"webhook_id" gorm:"index"`
+	Event      string    `json:"event"`
+	Payload    string    `json:"payload"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Response   string    `json:"response"` // truncated response body
+	Success    bool      `json:"success"`
+	Error      string    `json:"error"`
+	Duration   int64     `json:"duration"` // in milliseconds
+	Time       time.Time `json:"time" gorm:"index"`
+}
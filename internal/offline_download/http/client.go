@@ -91,15 +91,47 @@ func (s SimpleHttp) Run(task *tool.DownloadTask) error {
 	// save to temp dir
 	_ = os.MkdirAll(task.TempDir, os.ModePerm)
 	filePath := filepath.Join(task.TempDir, filename)
-	file, err := os.Create(filePath)
+	body, offset := resp.Body, int64(0)
+	if info, err := os.Stat(filePath); err == nil && info.Size() > 0 && info.Size() < fileSize {
+		// the download is paused or interrupted, continue from the end of the partial file
+		if rangeResp, err := s.requestFrom(task, info.Size()); err == nil {
+			defer rangeResp.Body.Close()
+			body, offset = rangeResp.Body, info.Size()
+		}
+	}
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flag = os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(filePath, flag, 0666)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-	err = utils.CopyWithCtx(task.Ctx(), file, resp.Body, fileSize, task.SetProgress)
+	err = utils.CopyWithCtx(task.Ctx(), file, body, fileSize-offset, func(percentage float64) {
+		task.SetProgress((float64(offset) + percentage*float64(fileSize-offset)/100) / float64(fileSize) * 100)
+	})
 	return err
 }
 
+// requestFrom requests the file from the offset, it fails if the server doesn't support the range
+func (s SimpleHttp) requestFrom(task *tool.DownloadTask, offset int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(task.Ctx(), http.MethodGet, task.Url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if start, _, err := http_range.ParseContentRange(resp.Header.Get("Content-Range")); resp.StatusCode != http.StatusPartialContent || err != nil || start != offset {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("range from %d not supported", offset)
+	}
+	return resp, nil
+}
+
 func init() {
 	tool.Tools.Add(&SimpleHttp{})
 }
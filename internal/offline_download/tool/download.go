@@ -12,7 +12,6 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
-	"github.com/OpenListTeam/tache"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
@@ -218,4 +217,4 @@ func (t *DownloadTask) GetStatus() string {
 	return t.Status
 }
 
-var DownloadTaskManager *tache.Manager[*DownloadTask]
+var DownloadTaskManager *task.PriorityManager[*DownloadTask]
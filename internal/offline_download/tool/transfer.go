@@ -109,7 +109,7 @@ func (t *TransferTask) SetRetry(retry int, maxRetry int) {
 }
 
 var (
-	TransferTaskManager *tache.Manager[*TransferTask]
+	TransferTaskManager *task.PriorityManager[*TransferTask]
 )
 
 func transferStd(ctx context.Context, tempDir, dstDirPath string, deletePolicy DeletePolicy) error {
@@ -326,7 +326,7 @@ func removeObjTemp(t *TransferTask) {
 	if err != nil || srcObj.IsDir() {
 		return
 	}
-	if err := op.Remove(t.Ctx(), t.SrcStorage, t.SrcActualPath); err != nil {
+	if err := op.Remove(context.WithValue(t.Ctx(), conf.NoRecycleKey, struct{}{}), t.SrcStorage, t.SrcActualPath); err != nil {
 		log.Errorf("failed to delete temp obj %s, error: %s", t.SrcActualPath, err.Error())
 	}
 }
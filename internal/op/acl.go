@@ -0,0 +1,192 @@
+package op
+
+import (
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const aclAll = model.ACLRead | model.ACLWrite | model.ACLRename | model.ACLMove | model.ACLCopy | model.ACLRemove | model.ACLOfflineDownload
+
+// aclState holds all acl entries and group memberships, they are few and checked on every request
+type aclState struct {
+	acls       []model.ACL
+	userGroups map[uint][]uint
+}
+
+var (
+	aclMu     sync.Mutex
+	aclLoaded *aclState
+)
+
+func loadACLState() (*aclState, error) {
+	aclMu.Lock()
+	defer aclMu.Unlock()
+	if aclLoaded != nil {
+		return aclLoaded, nil
+	}
+	acls, err := db.GetAllACLs()
+	if err != nil {
+		return nil, err
+	}
+	members, err := db.GetAllGroupMembers()
+	if err != nil {
+		return nil, err
+	}
+	s := &aclState{acls: acls, userGroups: make(map[uint][]uint)}
+	for i := range s.acls {
+		s.acls[i].Path = utils.FixAndCleanPath(s.acls[i].Path)
+	}
+	for _, m := range members {
+		s.userGroups[m.UserID] = append(s.userGroups[m.UserID], m.GroupID)
+	}
+	aclLoaded = s
+	return s, nil
+}
+
+func clearACLState() {
+	aclMu.Lock()
+	defer aclMu.Unlock()
+	aclLoaded = nil
+}
+
+// CheckACL decides the operation of the user on the path by the acl entries,
+// decided is false if no entry covers it. The operation is denied if the
+// entries can't be loaded, since any of them might deny it.
+func CheckACL(user *model.User, reqPath string, perm int32) (allowed, decided bool) {
+	s, err := loadACLState()
+	if err != nil {
+		log.Errorf("failed load acl entries, deny [%s] of %s: %+v", reqPath, user.Username, err)
+		return false, true
+	}
+	if len(s.acls) == 0 {
+		return false, false
+	}
+	reqPath = utils.FixAndCleanPath(reqPath)
+	groups := s.userGroups[user.ID]
+	// 0: unset, 1: group allows, 2: group denies, 3: user allows, 4: user denies
+	best, bestLen := 0, -1
+	for _, a := range s.acls {
+		if (a.Allow|a.Deny)&perm == 0 || !utils.IsSubPath(a.Path, reqPath) {
+			continue
+		}
+		var rank int
+		switch {
+		case a.UserID != 0 && a.UserID == user.ID:
+			rank = 3
+		case a.GroupID != 0 && utils.SliceContains(groups, a.GroupID):
+			rank = 1
+		default:
+			continue
+		}
+		if a.Deny&perm != 0 {
+			rank++
+		}
+		if l := len(a.Path); l > bestLen || l == bestLen && rank > best {
+			best, bestLen = rank, l
+		}
+	}
+	if best == 0 {
+		return false, false
+	}
+	return best%2 == 1, true
+}
+
+// HasPermission reports whether the user can do the operation on the path. The acl entries
+// decide it if they cover it, otherwise fallback does, the check of the permission of the user
+// and the metas. The acl entries don't apply to the admin. An api token restricts it further.
+func HasPermission(user *model.User, reqPath string, perm int32, fallback bool) bool {
+	if user != nil && user.Token != nil && !user.Token.Allows(user, reqPath, perm) {
+		return false
+	}
+	if user == nil || user.IsAdmin() {
+		return fallback
+	}
+	if allowed, decided := CheckACL(user, reqPath, perm); decided {
+		return allowed
+	}
+	return fallback
+}
+
+func validateACL(a *model.ACL) error {
+	if (a.UserID == 0) == (a.GroupID == 0) {
+		return errors.WithStack(errs.InvalidACLSubject)
+	}
+	if (a.Allow|a.Deny)&^aclAll != 0 || a.Allow|a.Deny == 0 {
+		return errors.WithStack(errs.InvalidACLPerm)
+	}
+	var err error
+	if a.UserID != 0 {
+		_, err = db.GetUserById(a.UserID)
+	} else {
+		_, err = db.GetGroupById(a.GroupID)
+	}
+	if err != nil {
+		return err
+	}
+	a.Path = utils.FixAndCleanPath(a.Path)
+	return nil
+}
+
+func GetACLs(pageIndex, pageSize int) ([]model.ACL, int64, error) {
+	return db.GetACLs(pageIndex, pageSize)
+}
+
+func GetACLById(id uint) (*model.ACL, error) {
+	return db.GetACLById(id)
+}
+
+func CreateACL(a *model.ACL) error {
+	if err := validateACL(a); err != nil {
+		return err
+	}
+	defer clearACLState()
+	return db.CreateACL(a)
+}
+
+func UpdateACL(a *model.ACL) error {
+	if err := validateACL(a); err != nil {
+		return err
+	}
+	if _, err := db.GetACLById(a.ID); err != nil {
+		return err
+	}
+	defer clearACLState()
+	return db.UpdateACL(a)
+}
+
+func DeleteACLById(id uint) error {
+	defer clearACLState()
+	return db.DeleteACLById(id)
+}
+
+func GetGroups(pageIndex, pageSize int) ([]model.Group, int64, error) {
+	return db.GetGroups(pageIndex, pageSize)
+}
+
+func GetGroupById(id uint) (*model.Group, error) {
+	return db.GetGroupById(id)
+}
+
+func CreateGroup(g *model.Group) error {
+	defer clearACLState()
+	return db.CreateGroup(g)
+}
+
+func UpdateGroup(g *model.Group) error {
+	if _, err := db.GetGroupById(g.ID); err != nil {
+		return err
+	}
+	defer clearACLState()
+	return db.UpdateGroup(g)
+}
+
+func DeleteGroupById(id uint) error {
+	defer clearACLState()
+	return db.DeleteGroupById(id)
+}
@@ -0,0 +1,66 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestCheckACL(t *testing.T) {
+	user := &model.User{Username: "acl_user", Role: model.GENERAL, BasePath: "/"}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	group := &model.Group{Name: "acl_group", UserIds: []uint{user.ID}}
+	if err := op.CreateGroup(group); err != nil {
+		t.Fatalf("failed create group: %+v", err)
+	}
+	acls := []model.ACL{
+		{Path: "/team", GroupID: group.ID, Allow: model.ACLRead | model.ACLWrite},
+		{Path: "/team/private", GroupID: group.ID, Deny: model.ACLRead},
+		{Path: "/team/private/mine", UserID: user.ID, Allow: model.ACLRead},
+		{Path: "/team/shared", GroupID: group.ID, Allow: model.ACLRemove},
+		{Path: "/team/shared", UserID: user.ID, Deny: model.ACLRemove},
+	}
+	for i := range acls {
+		if err := op.CreateACL(&acls[i]); err != nil {
+			t.Fatalf("failed create acl: %+v", err)
+		}
+	}
+	if err := op.CreateACL(&model.ACL{Path: "/", Allow: model.ACLRead}); err == nil {
+		t.Errorf("expect acl without subject rejected")
+	}
+	tests := []struct {
+		path             string
+		perm             int32
+		allowed, decided bool
+	}{
+		{"/team/a.txt", model.ACLRead, true, true},
+		{"/team/a.txt", model.ACLWrite, true, true},
+		{"/team/a.txt", model.ACLRename, false, false},
+		{"/team/private/b.txt", model.ACLRead, false, true},
+		{"/team/private/b.txt", model.ACLWrite, true, true},
+		{"/team/private/mine/c.txt", model.ACLRead, true, true},
+		{"/team/shared/d.txt", model.ACLRemove, false, true},
+		{"/other", model.ACLRead, false, false},
+	}
+	for _, tt := range tests {
+		allowed, decided := op.CheckACL(user, tt.path, tt.perm)
+		if allowed != tt.allowed || decided != tt.decided {
+			t.Errorf("CheckACL(%s, %d) = %v, %v; expect %v, %v", tt.path, tt.perm, allowed, decided, tt.allowed, tt.decided)
+		}
+	}
+	if !op.HasPermission(user, "/other", model.ACLRead, true) || op.HasPermission(user, "/team/private", model.ACLRead, true) {
+		t.Errorf("unexpected HasPermission result")
+	}
+	if err := op.DeleteGroupById(group.ID); err != nil {
+		t.Fatalf("failed delete group: %+v", err)
+	}
+	if _, decided := op.CheckACL(user, "/team/a.txt", model.ACLRead); decided {
+		t.Errorf("expect acl entries of the deleted group dropped")
+	}
+	if allowed, _ := op.CheckACL(user, "/team/private/mine", model.ACLRead); !allowed {
+		t.Errorf("expect acl entry of the user kept")
+	}
+}
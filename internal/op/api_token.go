@@ -0,0 +1,148 @@
+package op
+
+import (
+	"crypto/subtle"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const apiTokenScopes = model.TokenScopeFsRead | model.TokenScopeFsWrite | model.TokenScopeTasks | model.TokenScopeAdmin
+
+func hashAPIToken(token string) string {
+	return utils.HashData(utils.SHA256, []byte(token))
+}
+
+// CreateAPIToken creates the api token for the user, the returned token and secret access key
+// of s3 are only known here
+func CreateAPIToken(user *model.User, t *model.APIToken) (string, string, error) {
+	if t.Scopes == 0 || t.Scopes&^apiTokenScopes != 0 || t.HasScope(model.TokenScopeAdmin) && !user.IsAdmin() {
+		return "", "", errors.WithStack(errs.InvalidAPITokenScope)
+	}
+	if t.AllowedIPs != "" {
+		ips := strings.Split(t.AllowedIPs, ",")
+		for i, ip := range ips {
+			ips[i] = strings.TrimSpace(ip)
+			if _, _, err := net.ParseCIDR(ips[i]); err != nil && net.ParseIP(ips[i]) == nil {
+				return "", "", errors.Wrapf(errs.InvalidAPITokenIPs, "%s", ips[i])
+			}
+		}
+		t.AllowedIPs = strings.Join(ips, ",")
+	}
+	t.ID = 0
+	t.UserID = user.ID
+	t.PathPrefix = utils.FixAndCleanPath(t.PathPrefix)
+	s3Secret, stored, err := newS3Secret()
+	if err != nil {
+		return "", "", err
+	}
+	t.KeyID = model.APITokenPrefix + random.String(17)
+	token := t.KeyID + "_" + random.String(40)
+	t.Hash = hashAPIToken(token)
+	t.S3Secret = stored
+	t.CreatedTime = time.Now()
+	t.LastUsedTime = time.Time{}
+	t.LastUsedIP = ""
+	return token, s3Secret, db.CreateAPIToken(t)
+}
+
+// IsAPIToken tells the api tokens from the login ones
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, model.APITokenPrefix) && strings.Contains(token, "_")
+}
+
+// AuthAPIToken returns the user the api token acts as when used from the ip
+func AuthAPIToken(token, ip string) (*model.User, error) {
+	keyID, _, _ := strings.Cut(token, "_")
+	t, err := db.GetAPITokenByKeyID(keyID)
+	if err != nil {
+		return nil, errors.WithStack(errs.InvalidAPIToken)
+	}
+	if subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hashAPIToken(token))) != 1 {
+		return nil, errors.WithStack(errs.InvalidAPIToken)
+	}
+	return authAPIToken(t, ip)
+}
+
+// AuthAPITokenKey returns the user the api token of the key id acts as when used from the ip,
+// the caller has to verify the client holds the token
+func AuthAPITokenKey(keyID, ip string) (*model.User, error) {
+	t, err := db.GetAPITokenByKeyID(keyID)
+	if err != nil {
+		return nil, errors.WithStack(errs.InvalidAPIToken)
+	}
+	return authAPIToken(t, ip)
+}
+
+// AuthAPITokenS3Key is AuthAPITokenKey for s3, it also returns the secret access key of the
+// token the caller has to verify the request is signed with
+func AuthAPITokenS3Key(keyID, ip string) (*model.User, string, error) {
+	t, err := db.GetAPITokenByKeyID(keyID)
+	if err != nil {
+		return nil, "", errors.WithStack(errs.InvalidAPIToken)
+	}
+	secret, err := decryptSecret(t.S3Secret)
+	if err != nil || secret == "" {
+		return nil, "", errors.Wrap(errs.InvalidAPIToken, "the s3 secret can't be read")
+	}
+	user, err := authAPIToken(t, ip)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, secret, nil
+}
+
+func authAPIToken(t *model.APIToken, ip string) (*model.User, error) {
+	if t.IsExpired() {
+		return nil, errors.WithStack(errs.APITokenExpired)
+	}
+	if !t.AllowIP(ip) {
+		return nil, errors.WithStack(errs.APITokenIPNotAllowed)
+	}
+	user, err := db.GetUserById(t.UserID)
+	if err != nil {
+		return nil, errors.WithStack(errs.InvalidAPIToken)
+	}
+	if user.Disabled {
+		return nil, errors.Wrap(errs.InvalidAPIToken, "user is disabled")
+	}
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	// don't write the db on every request
+	if time.Since(t.LastUsedTime) > time.Minute || t.LastUsedIP != ip {
+		t.LastUsedTime = time.Now()
+		t.LastUsedIP = ip
+		if err = db.UpdateAPITokenLastUsed(t); err != nil {
+			log.Warnf("failed update last used of api token %s: %+v", t.KeyID, err)
+		}
+	}
+	return t.Restrict(user), nil
+}
+
+func GetAPITokensByUserId(userId uint, pageIndex, pageSize int) ([]model.APIToken, int64, error) {
+	return db.GetAPITokensByUserId(userId, pageIndex, pageSize)
+}
+
+func GetAPITokenByIdAndUserId(id uint, userId uint) (*model.APIToken, error) {
+	t, err := db.GetAPITokenById(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.UserID != userId {
+		return nil, errors.WithStack(errs.InvalidAPIToken)
+	}
+	return t, nil
+}
+
+func DeleteAPITokenById(id uint) error {
+	return db.DeleteAPITokenById(id)
+}
@@ -0,0 +1,78 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestAPIToken(t *testing.T) {
+	user := &model.User{Username: "token_user", Role: model.GENERAL, BasePath: "/home", Permission: 0x31FF}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	if _, _, err := op.CreateAPIToken(user, &model.APIToken{Name: "admin", Scopes: model.TokenScopeAdmin}); !errors.Is(err, errs.InvalidAPITokenScope) {
+		t.Errorf("expect admin scope rejected for general user, got %v", err)
+	}
+	apiToken := &model.APIToken{Name: "backup", Scopes: model.TokenScopeFsRead, PathPrefix: "/docs", AllowedIPs: "10.0.0.0/8, 127.0.0.1"}
+	token, s3Secret, err := op.CreateAPIToken(user, apiToken)
+	if err != nil {
+		t.Fatalf("failed create api token: %+v", err)
+	}
+	if _, secret, err := op.AuthAPITokenS3Key(apiToken.KeyID, "127.0.0.1"); err != nil || secret == "" || secret != s3Secret {
+		t.Errorf("expect the s3 secret of the token returned, got %q: %v", secret, err)
+	}
+	if !op.IsAPIToken(token) {
+		t.Fatalf("expect %s taken as api token", token)
+	}
+	scoped, err := op.AuthAPIToken(token, "10.1.2.3:2121")
+	if err != nil {
+		t.Fatalf("failed auth api token: %+v", err)
+	}
+	if scoped.CanWrite() || !scoped.CanWebdavRead() || user.Token != nil {
+		t.Errorf("expect the permission of a copy of the user restricted")
+	}
+	tests := []struct {
+		path    string
+		perm    int32
+		allowed bool
+	}{
+		{"/home/docs/a.txt", model.ACLRead, true},
+		{"/home", model.ACLRead, true},
+		{"/home/music", model.ACLRead, false},
+		{"/home/docs/a.txt", model.ACLWrite, false},
+	}
+	for _, tt := range tests {
+		if allowed := op.HasPermission(scoped, tt.path, tt.perm, true); allowed != tt.allowed {
+			t.Errorf("HasPermission(%s, %d) = %v; expect %v", tt.path, tt.perm, allowed, tt.allowed)
+		}
+	}
+	if _, err = op.AuthAPIToken(token, "192.168.1.1"); !errors.Is(err, errs.APITokenIPNotAllowed) {
+		t.Errorf("expect ip rejected, got %v", err)
+	}
+	if _, err = op.AuthAPIToken(token+"x", "127.0.0.1"); !errors.Is(err, errs.InvalidAPIToken) {
+		t.Errorf("expect wrong token rejected, got %v", err)
+	}
+	tokens, total, err := op.GetAPITokensByUserId(user.ID, 1, 10)
+	if err != nil || total != 1 || tokens[0].LastUsedIP != "10.1.2.3" {
+		t.Fatalf("expect the last used ip tracked, got %+v: %v", tokens, err)
+	}
+	expired := time.Now().Add(-time.Minute)
+	expiredToken, _, err := op.CreateAPIToken(user, &model.APIToken{Name: "old", Scopes: model.TokenScopeFsRead, ExpiresAt: &expired})
+	if err != nil {
+		t.Fatalf("failed create api token: %+v", err)
+	}
+	if _, err = op.AuthAPIToken(expiredToken, "127.0.0.1"); !errors.Is(err, errs.APITokenExpired) {
+		t.Errorf("expect expired token rejected, got %v", err)
+	}
+	if err = op.DeleteAPITokenById(apiToken.ID); err != nil {
+		t.Fatalf("failed delete api token: %+v", err)
+	}
+	if _, err = op.AuthAPIToken(token, "127.0.0.1"); !errors.Is(err, errs.InvalidAPIToken) {
+		t.Errorf("expect revoked token rejected, got %v", err)
+	}
+}
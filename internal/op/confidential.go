@@ -0,0 +1,313 @@
+package op
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// the confidential fields of the additions are stored as encryptedPrefix + key id + ":" + base64 of
+// nonce and the sealed value with aes-256-gcm, the key id tells which master key sealed it
+const encryptedPrefix = "enc:v1:"
+
+// masterKey is the key encrypting the confidential fields, nil keeps them plain
+var masterKey *EncryptionKey
+
+type EncryptionKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewEncryptionKey derives the aes key from the master key
+func NewEncryptionKey(key string) (*EncryptionKey, error) {
+	if key == "" {
+		return nil, nil
+	}
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	id := sha256.Sum256(sum[:])
+	return &EncryptionKey{id: hex.EncodeToString(id[:4]), aead: aead}, nil
+}
+
+// SetEncryptionKey sets the master key of the confidential fields, empty disables the encryption
+func SetEncryptionKey(key string) error {
+	k, err := NewEncryptionKey(key)
+	if err != nil {
+		return err
+	}
+	masterKey = k
+	return nil
+}
+
+func (k *EncryptionKey) encrypt(value string) (string, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+	sealed := k.aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + k.id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// encryptedKeyId returns the id of the key sealing the encrypted value
+func encryptedKeyId(value string) string {
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	return id
+}
+
+func (k *EncryptionKey) decrypt(value string) (string, error) {
+	id, data, _ := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if k == nil {
+		return "", errors.New("the value is encrypted but no encryption key is set")
+	}
+	if id != k.id {
+		return "", errors.Errorf("the value is encrypted by another key %s", id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < k.aead.NonceSize() {
+		return "", errors.New("the encrypted value is malformed")
+	}
+	plain, err := k.aead.Open(nil, sealed[:k.aead.NonceSize()], sealed[k.aead.NonceSize():], nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed decrypt the value")
+	}
+	return string(plain), nil
+}
+
+func confidentialFields(driverName string) []string {
+	var fields []string
+	for _, item := range driverInfoMap[driverName].Additional {
+		if item.Confidential {
+			fields = append(fields, item.Name)
+		}
+	}
+	return fields
+}
+
+// convertAddition applies convert to the string values of the confidential fields of the addition
+func convertAddition(driverName, addition string, convert func(string) (string, error)) (string, error) {
+	fields := confidentialFields(driverName)
+	if len(fields) == 0 || addition == "" {
+		return addition, nil
+	}
+	var m map[string]json.RawMessage
+	if err := utils.Json.UnmarshalFromString(addition, &m); err != nil {
+		return "", errors.Wrap(err, "error while unmarshal addition")
+	}
+	changed := false
+	for _, name := range fields {
+		var value string
+		if raw, ok := m[name]; !ok || utils.Json.Unmarshal(raw, &value) != nil || value == "" {
+			continue
+		}
+		converted, err := convert(value)
+		if err != nil {
+			return "", errors.WithMessagef(err, "field %s", name)
+		}
+		if converted == value {
+			continue
+		}
+		raw, err := utils.Json.Marshal(converted)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		m[name] = raw
+		changed = true
+	}
+	if !changed {
+		return addition, nil
+	}
+	return utils.Json.MarshalToString(m)
+}
+
+// EncryptAddition encrypts the plain confidential fields of the addition with the key,
+// it's unchanged without a key
+func EncryptAddition(driverName, addition string, key *EncryptionKey) (string, error) {
+	if key == nil {
+		return addition, nil
+	}
+	return convertAddition(driverName, addition, func(value string) (string, error) {
+		if strings.HasPrefix(value, encryptedPrefix) {
+			return value, nil
+		}
+		return key.encrypt(value)
+	})
+}
+
+// DecryptAddition decrypts the encrypted confidential fields of the addition with the key
+func DecryptAddition(driverName, addition string, key *EncryptionKey) (string, error) {
+	return convertAddition(driverName, addition, func(value string) (string, error) {
+		if !strings.HasPrefix(value, encryptedPrefix) {
+			return value, nil
+		}
+		return key.decrypt(value)
+	})
+}
+
+// DecryptStorage decrypts the addition of the storage loaded from the database in place
+func DecryptStorage(storage *model.Storage) error {
+	addition, err := DecryptAddition(storage.Driver, storage.Addition, masterKey)
+	if err != nil {
+		return errors.WithMessagef(err, "failed decrypt addition of storage %s", storage.MountPath)
+	}
+	storage.Addition = addition
+	return nil
+}
+
+// encryptedStorage returns a copy of the storage to be saved to the database. The values
+// encrypted already are decrypted first, so that they are never passed to the driver.
+func encryptedStorage(storage *model.Storage) (*model.Storage, error) {
+	if err := DecryptStorage(storage); err != nil {
+		return nil, err
+	}
+	addition, err := EncryptAddition(storage.Driver, storage.Addition, masterKey)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed encrypt addition of storage %s", storage.MountPath)
+	}
+	s := *storage
+	s.Addition = addition
+	return &s, nil
+}
+
+// reencrypter returns the func encrypting a value with the new key after decrypting it
+// with the current one, a nil new key decrypts it
+func reencrypter(newKey *EncryptionKey) func(string) (string, error) {
+	return func(value string) (string, error) {
+		if strings.HasPrefix(value, encryptedPrefix) {
+			if newKey != nil && newKey.id == encryptedKeyId(value) {
+				return value, nil
+			}
+			plain, err := masterKey.decrypt(value)
+			if err != nil {
+				return "", err
+			}
+			value = plain
+		}
+		if newKey == nil {
+			return value, nil
+		}
+		return newKey.encrypt(value)
+	}
+}
+
+// ReencryptStorages encrypts the confidential fields of all the storages in the database with
+// the new key, after decrypting them with the current one. A nil new key decrypts them.
+// The values encrypted by the new key already are kept, and all the storages are updated
+// in one transaction, so that a failed rotation can be run again.
+// It returns the count of the storages changed.
+func ReencryptStorages(newKey *EncryptionKey) (int, error) {
+	storages, _, err := db.GetStorages(1, -1)
+	if err != nil {
+		return 0, err
+	}
+	reencrypt := reencrypter(newKey)
+	var changed []model.Storage
+	for _, storage := range storages {
+		addition, err := convertAddition(storage.Driver, storage.Addition, reencrypt)
+		if err != nil {
+			return 0, errors.WithMessagef(err, "failed re-encrypt addition of storage %s", storage.MountPath)
+		}
+		if addition == storage.Addition {
+			continue
+		}
+		storage.Addition = addition
+		changed = append(changed, storage)
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+	if err = db.UpdateStorages(changed); err != nil {
+		return 0, errors.WithMessage(err, "failed update storages in database")
+	}
+	return len(changed), nil
+}
+
+// EncryptStorages encrypts the plain confidential fields of the storages in the database with
+// the current key, for the rows saved before the encryption was enabled
+func EncryptStorages() (int, error) {
+	if masterKey == nil {
+		return 0, nil
+	}
+	return ReencryptStorages(masterKey)
+}
+
+// encryptSecret encrypts the secret to be saved to the database, it's unchanged without a key
+func encryptSecret(value string) (string, error) {
+	if masterKey == nil {
+		return value, nil
+	}
+	return masterKey.encrypt(value)
+}
+
+// decryptSecret decrypts the secret loaded from the database
+func decryptSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	return masterKey.decrypt(value)
+}
+
+// ReencryptS3Secrets encrypts the s3 secrets of the s3 keys and the api tokens with the new key
+// like ReencryptStorages. It returns the count of the secrets changed.
+func ReencryptS3Secrets(newKey *EncryptionKey) (int, error) {
+	keys, tokens, err := db.GetS3Secrets()
+	if err != nil {
+		return 0, err
+	}
+	reencrypt := reencrypter(newKey)
+	var (
+		changedKeys   []model.S3Key
+		changedTokens []model.APIToken
+	)
+	for _, k := range keys {
+		secret, err := reencrypt(k.Secret)
+		if err != nil {
+			return 0, errors.WithMessagef(err, "failed re-encrypt secret of s3 key %s", k.AccessKeyID)
+		}
+		if k.Secret != "" && secret != k.Secret {
+			k.Secret = secret
+			changedKeys = append(changedKeys, k)
+		}
+	}
+	for _, t := range tokens {
+		secret, err := reencrypt(t.S3Secret)
+		if err != nil {
+			return 0, errors.WithMessagef(err, "failed re-encrypt s3 secret of api token %s", t.KeyID)
+		}
+		if t.S3Secret != "" && secret != t.S3Secret {
+			t.S3Secret = secret
+			changedTokens = append(changedTokens, t)
+		}
+	}
+	if len(changedKeys)+len(changedTokens) == 0 {
+		return 0, nil
+	}
+	if err = db.UpdateS3Secrets(changedKeys, changedTokens); err != nil {
+		return 0, errors.WithMessage(err, "failed update s3 secrets in database")
+	}
+	return len(changedKeys) + len(changedTokens), nil
+}
+
+// EncryptS3Secrets encrypts the plain s3 secrets in the database with the current key
+func EncryptS3Secrets() (int, error) {
+	if masterKey == nil {
+		return 0, nil
+	}
+	return ReencryptS3Secrets(masterKey)
+}
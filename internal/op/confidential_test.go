@@ -0,0 +1,140 @@
+package op_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func TestEncryptAddition(t *testing.T) {
+	key, err := op.NewEncryptionKey("master key")
+	if err != nil {
+		t.Fatalf("failed create key: %+v", err)
+	}
+	addition := `{"remote_path":"/local","password":"secret","salt":""}`
+	encrypted, err := op.EncryptAddition("Crypt", addition, key)
+	if err != nil {
+		t.Fatalf("failed encrypt addition: %+v", err)
+	}
+	var m map[string]string
+	if err = utils.Json.UnmarshalFromString(encrypted, &m); err != nil {
+		t.Fatalf("failed unmarshal addition: %+v", err)
+	}
+	if m["remote_path"] != "/local" || m["salt"] != "" {
+		t.Errorf("expect the other fields unchanged, got %s", encrypted)
+	}
+	if !strings.HasPrefix(m["password"], "enc:v1:") {
+		t.Errorf("expect the password encrypted, got %s", encrypted)
+	}
+	if again, _ := op.EncryptAddition("Crypt", encrypted, key); again != encrypted {
+		t.Errorf("expect the encrypted addition unchanged, got %s", again)
+	}
+	decrypted, err := op.DecryptAddition("Crypt", encrypted, key)
+	if err != nil {
+		t.Fatalf("failed decrypt addition: %+v", err)
+	}
+	if err = utils.Json.UnmarshalFromString(decrypted, &m); err != nil || m["password"] != "secret" {
+		t.Errorf("unexpected decrypted addition: %s", decrypted)
+	}
+	other, _ := op.NewEncryptionKey("other key")
+	if _, err = op.DecryptAddition("Crypt", encrypted, other); err == nil {
+		t.Errorf("expect decrypting with another key failed")
+	}
+	if _, err = op.DecryptAddition("Crypt", encrypted, nil); err == nil {
+		t.Errorf("expect decrypting without a key failed")
+	}
+}
+
+func TestReencryptStorages(t *testing.T) {
+	storage := model.Storage{
+		Driver:    "Crypt",
+		MountPath: "/confidential_test",
+		Addition:  `{"password":"secret","remote_path":"/local"}`,
+		Disabled:  true,
+	}
+	if err := db.CreateStorage(&storage); err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	defer func() {
+		_ = op.SetEncryptionKey("")
+		_ = db.DeleteStorageById(storage.ID)
+	}()
+	if err := op.SetEncryptionKey("master key"); err != nil {
+		t.Fatalf("failed set key: %+v", err)
+	}
+	if count, err := op.EncryptStorages(); err != nil || count != 1 {
+		t.Fatalf("expect 1 storage encrypted, got %d, %+v", count, err)
+	}
+	if count, _ := op.EncryptStorages(); count != 0 {
+		t.Errorf("expect the encrypted storages unchanged, got %d", count)
+	}
+	saved, err := db.GetStorageById(storage.ID)
+	if err != nil {
+		t.Fatalf("failed get storage: %+v", err)
+	}
+	if strings.Contains(saved.Addition, "secret") {
+		t.Errorf("expect the password encrypted, got %s", saved.Addition)
+	}
+	newKey, _ := op.NewEncryptionKey("new key")
+	if count, err := op.ReencryptStorages(newKey); err != nil || count != 1 {
+		t.Fatalf("expect 1 storage re-encrypted, got %d, %+v", count, err)
+	}
+	// running the rotation again before the key is switched keeps the rows done
+	if count, err := op.ReencryptStorages(newKey); err != nil || count != 0 {
+		t.Fatalf("expect the re-encrypted storages unchanged, got %d, %+v", count, err)
+	}
+	if err = op.SetEncryptionKey("new key"); err != nil {
+		t.Fatalf("failed set key: %+v", err)
+	}
+	saved, _ = db.GetStorageById(storage.ID)
+	if err = op.DecryptStorage(saved); err != nil {
+		t.Fatalf("failed decrypt storage: %+v", err)
+	}
+	if saved.Addition != storage.Addition {
+		t.Errorf("unexpected decrypted addition: %s", saved.Addition)
+	}
+}
+
+func TestReencryptS3Secrets(t *testing.T) {
+	user := &model.User{Username: "confidential_s3_user", Role: model.GENERAL, BasePath: "/", Permission: 0x31FF}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	defer func() {
+		_ = op.SetEncryptionKey("")
+		_ = op.DeleteUserById(user.ID)
+	}()
+	if err := op.SetEncryptionKey("master key"); err != nil {
+		t.Fatalf("failed set key: %+v", err)
+	}
+	k := &model.S3Key{Name: "rclone"}
+	secret, err := op.CreateS3Key(user, k)
+	if err != nil {
+		t.Fatalf("failed create s3 key: %+v", err)
+	}
+	saved, err := db.GetS3KeyByAccessKeyID(k.AccessKeyID)
+	if err != nil {
+		t.Fatalf("failed get s3 key: %+v", err)
+	}
+	if saved.Secret == secret || strings.Contains(saved.Secret, secret) {
+		t.Errorf("expect the secret encrypted, got %s", saved.Secret)
+	}
+	newKey, _ := op.NewEncryptionKey("new key")
+	// the plain secrets of the other tests are encrypted too
+	if count, err := op.ReencryptS3Secrets(newKey); err != nil || count < 1 {
+		t.Fatalf("expect the secret re-encrypted, got %d, %+v", count, err)
+	}
+	if count, err := op.ReencryptS3Secrets(newKey); err != nil || count != 0 {
+		t.Fatalf("expect the re-encrypted secrets unchanged, got %d, %+v", count, err)
+	}
+	if err = op.SetEncryptionKey("new key"); err != nil {
+		t.Fatalf("failed set key: %+v", err)
+	}
+	if _, got, err := op.AuthS3Key(k.AccessKeyID, "127.0.0.1"); err != nil || got != secret {
+		t.Errorf("expect the secret kept after the rotation, got %q: %v", got, err)
+	}
+}
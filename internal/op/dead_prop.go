@@ -0,0 +1,65 @@
+package op
+
+import (
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetDeadProps returns the webdav dead props of the object at the full path
+func GetDeadProps(path string) ([]model.DeadProp, error) {
+	return db.GetDeadPropsByPath(utils.FixAndCleanPath(path))
+}
+
+// PatchDeadProps sets and removes the dead props of the object at the full path atomically
+func PatchDeadProps(path string, set, remove []model.DeadProp) error {
+	return db.PatchDeadProps(utils.FixAndCleanPath(path), set, remove)
+}
+
+// MoveDeadProps makes the dead props of src follow the object moved to dst,
+// the objects in it are included if recursive
+func MoveDeadProps(src, dst string, recursive bool) error {
+	src, dst = utils.FixAndCleanPath(src), utils.FixAndCleanPath(dst)
+	if src == dst {
+		return nil
+	}
+	return db.MoveDeadProps(src, dst, recursive)
+}
+
+// CopyDeadProps gives the object copied to dst the dead props of src,
+// the objects in it are included if recursive
+func CopyDeadProps(src, dst string, recursive bool) error {
+	src, dst = utils.FixAndCleanPath(src), utils.FixAndCleanPath(dst)
+	if src == dst {
+		return nil
+	}
+	return db.CopyDeadProps(src, dst, recursive)
+}
+
+// DeleteDeadProps deletes the dead props of the removed object and the objects in it
+func DeleteDeadProps(path string) error {
+	return db.DeleteDeadPropsByPath(utils.FixAndCleanPath(path))
+}
+
+// deadPropsFollowObj keeps the dead props with the objects moved, renamed and removed in a storage
+func deadPropsFollowObj(typ string, storage driver.Driver, srcPath, dstPath string, obj model.Obj) {
+	mountPath := storage.GetStorage().MountPath
+	var err error
+	switch typ {
+	case "move":
+		err = MoveDeadProps(stdpath.Join(mountPath, srcPath), stdpath.Join(mountPath, dstPath), true)
+	case "del":
+		err = DeleteDeadProps(stdpath.Join(mountPath, srcPath))
+	}
+	if err != nil {
+		log.Errorf("failed %s dead props of [%s]: %+v", typ, stdpath.Join(mountPath, srcPath), err)
+	}
+}
+
+func init() {
+	RegisterObjChangeHook(deadPropsFollowObj)
+}
@@ -0,0 +1,74 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func deadPropValues(t *testing.T, path string) map[string]string {
+	props, err := op.GetDeadProps(path)
+	if err != nil {
+		t.Fatalf("failed get dead props of %s: %+v", path, err)
+	}
+	m := make(map[string]string, len(props))
+	for _, p := range props {
+		m[p.Space+" "+p.Local] = p.InnerXML
+	}
+	return m
+}
+
+func TestDeadProps(t *testing.T) {
+	set := []model.DeadProp{
+		{Space: "urn:schemas-microsoft-com:", Local: "Win32LastModifiedTime", InnerXML: "Mon, 01 Jan 2024 00:00:00 GMT"},
+		{Space: "http://example.com/ns", Local: "color", InnerXML: "red"},
+	}
+	if err := op.PatchDeadProps("/dav/dir", set, nil); err != nil {
+		t.Fatalf("failed patch dead props: %+v", err)
+	}
+	if err := op.PatchDeadProps("/dav/dir/file", []model.DeadProp{{Space: "http://example.com/ns", Local: "color", InnerXML: "blue"}}, nil); err != nil {
+		t.Fatalf("failed patch dead props: %+v", err)
+	}
+	if err := op.PatchDeadProps("/dav/dir_other", []model.DeadProp{{Space: "http://example.com/ns", Local: "color", InnerXML: "green"}}, nil); err != nil {
+		t.Fatalf("failed patch dead props: %+v", err)
+	}
+	err := op.PatchDeadProps("/dav/dir",
+		[]model.DeadProp{{Space: "http://example.com/ns", Local: "size", InnerXML: "big"}},
+		[]model.DeadProp{{Space: "http://example.com/ns", Local: "color"}})
+	if err != nil {
+		t.Fatalf("failed patch dead props: %+v", err)
+	}
+	if props := deadPropValues(t, "/dav/dir"); len(props) != 2 || props["http://example.com/ns size"] != "big" {
+		t.Errorf("unexpected dead props after patch: %v", props)
+	}
+
+	if err = op.CopyDeadProps("/dav/dir", "/dav/copied", true); err != nil {
+		t.Fatalf("failed copy dead props: %+v", err)
+	}
+	if err = op.MoveDeadProps("/dav/dir", "/dav/moved", true); err != nil {
+		t.Fatalf("failed move dead props: %+v", err)
+	}
+	if props := deadPropValues(t, "/dav/dir/file"); len(props) != 0 {
+		t.Errorf("expect no dead props left at the source, got %v", props)
+	}
+	if props := deadPropValues(t, "/dav/moved/file"); props["http://example.com/ns color"] != "blue" {
+		t.Errorf("expect the dead props of the children moved, got %v", props)
+	}
+	if props := deadPropValues(t, "/dav/copied/file"); props["http://example.com/ns color"] != "blue" {
+		t.Errorf("expect the dead props of the children copied, got %v", props)
+	}
+	if props := deadPropValues(t, "/dav/dir_other"); props["http://example.com/ns color"] != "green" {
+		t.Errorf("expect the dead props of the sibling untouched, got %v", props)
+	}
+
+	if err = op.DeleteDeadProps("/dav/moved"); err != nil {
+		t.Fatalf("failed delete dead props: %+v", err)
+	}
+	if props := deadPropValues(t, "/dav/moved/file"); len(props) != 0 {
+		t.Errorf("expect the dead props deleted, got %v", props)
+	}
+	if props := deadPropValues(t, "/dav/copied"); len(props) != 2 {
+		t.Errorf("expect the copied dead props kept, got %v", props)
+	}
+}
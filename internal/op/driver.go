@@ -145,12 +145,38 @@ func getMainItems(config driver.Config) []driver.Item {
 		Default:  "false",
 		Required: true,
 	})
+	items = append(items, driver.Item{
+		Name:    "index_interval",
+		Type:    conf.TypeNumber,
+		Default: "0",
+		Help:    "Minutes between incremental search index updates, 0 to disable",
+	})
+	items = append(items, driver.Item{
+		Name:    "index_depth",
+		Type:    conf.TypeNumber,
+		Default: "0",
+		Help:    "Max depth of incremental search index updates, 0 to use the global max index depth",
+	})
 	items = append(items, driver.Item{
 		Name:     "enable_sign",
 		Type:     conf.TypeBool,
 		Default:  "false",
 		Required: true,
 	})
+	if !config.NoUpload {
+		items = append(items, driver.Item{
+			Name:    "recycle_bin",
+			Type:    conf.TypeBool,
+			Default: "false",
+			Help:    "Move removed objects into a hidden recycle bin folder so they can be restored",
+		})
+		items = append(items, driver.Item{
+			Name:    "verify_transfer",
+			Type:    conf.TypeBool,
+			Default: "false",
+			Help:    "Compare the hashes of the files copied, moved or uploaded to this storage with the sources",
+		})
+	}
 	return items
 }
 func getAdditionalItems(t reflect.Type, defaultRoot string) []driver.Item {
@@ -168,12 +194,13 @@ func getAdditionalItems(t reflect.Type, defaultRoot string) []driver.Item {
 			continue
 		}
 		item := driver.Item{
-			Name:     name,
-			Type:     strings.ToLower(field.Type.Name()),
-			Default:  tag.Get("default"),
-			Options:  tag.Get("options"),
-			Required: tag.Get("required") == "true",
-			Help:     tag.Get("help"),
+			Name:         name,
+			Type:         strings.ToLower(field.Type.Name()),
+			Default:      tag.Get("default"),
+			Options:      tag.Get("options"),
+			Required:     tag.Get("required") == "true",
+			Help:         tag.Get("help"),
+			Confidential: tag.Get("confidential") == "true",
 		}
 		if tag.Get("type") != "" {
 			item.Type = tag.Get("type")
@@ -10,6 +10,7 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/pkg/generic_sync"
@@ -124,8 +125,10 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 	if !args.Refresh {
 		if files, ok := listCache.Get(key); ok {
 			log.Debugf("use cache when list %s", path)
+			metrics.CacheHit("list")
 			return files, nil
 		}
+		metrics.CacheMiss("list")
 	}
 	dir, err := GetUnwrap(ctx, storage, path)
 	if err != nil {
@@ -136,7 +139,9 @@ func List(ctx context.Context, storage driver.Driver, path string, args model.Li
 		return nil, errors.WithStack(errs.NotFolder)
 	}
 	objs, err, _ := listG.Do(key, func() ([]model.Obj, error) {
+		start := time.Now()
 		files, err := storage.List(ctx, dir, args)
+		observe(storage, "list", start, err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to list objs")
 		}
@@ -180,7 +185,9 @@ func Get(ctx context.Context, storage driver.Driver, path string) (model.Obj, er
 
 	// get the obj directly without list so that we can reduce the io
 	if g, ok := storage.(driver.Getter); ok {
+		start := time.Now()
 		obj, err := g.Get(ctx, path)
+		observe(storage, "get", start, err)
 		if err == nil {
 			return model.WrapObjName(obj), nil
 		}
@@ -290,13 +297,17 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 
 	key := stdpath.Join(Key(storage, path), args.Type)
 	if link, ok := linkCache.Get(key); ok {
+		metrics.CacheHit("link")
 		return link, file, nil
 	}
+	metrics.CacheMiss("link")
 
 	var forget any
 	var linkM *model.Link
 	fn := func() (*model.Link, error) {
+		start := time.Now()
 		link, err := storage.Link(ctx, file, args)
+		observe(storage, "link", start, err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed get link")
 		}
@@ -389,6 +400,7 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 					return nil, errors.WithMessagef(err, "failed to get parent dir [%s]", parentPath)
 				}
 
+				start := time.Now()
 				switch s := storage.(type) {
 				case driver.MkdirResult:
 					var newObj model.Obj
@@ -408,6 +420,14 @@ func MakeDir(ctx context.Context, storage driver.Driver, path string, lazyCache
 				default:
 					return nil, errs.NotImplement
 				}
+				observe(storage, "mkdir", start, err)
+				if err == nil {
+					callObjChangeHooks("add", storage, "", path, &model.Object{
+						Name:     dirName,
+						Modified: start,
+						IsFolder: true,
+					})
+				}
 				return nil, errors.WithStack(err)
 			}
 			return nil, errors.WithMessage(err, "failed to check if dir exists")
@@ -439,6 +459,7 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	}
 	srcDirPath := stdpath.Dir(srcPath)
 
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.MoveResult:
 		var newObj model.Obj
@@ -462,6 +483,11 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	default:
 		return errs.NotImplement
 	}
+	observe(storage, "move", start, err)
+	if err == nil {
+		moveUsage(ctx, storage, srcPath, stdpath.Join(dstDirPath, srcObj.GetName()))
+		callObjChangeHooks("move", storage, srcPath, stdpath.Join(dstDirPath, srcObj.GetName()), srcObj)
+	}
 	return errors.WithStack(err)
 }
 
@@ -477,6 +503,7 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 	srcObj := model.UnwrapObj(srcRawObj)
 	srcDirPath := stdpath.Dir(srcPath)
 
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.RenameResult:
 		var newObj model.Obj
@@ -496,6 +523,11 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string,
 	default:
 		return errs.NotImplement
 	}
+	observe(storage, "rename", start, err)
+	if err == nil {
+		moveUsage(ctx, storage, srcPath, stdpath.Join(srcDirPath, dstName))
+		callObjChangeHooks("move", storage, srcPath, stdpath.Join(srcDirPath, dstName), srcObj)
+	}
 	return errors.WithStack(err)
 }
 
@@ -515,6 +547,7 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 		return errors.WithMessage(err, "failed to get dst dir")
 	}
 
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.CopyResult:
 		var newObj model.Obj
@@ -534,6 +567,10 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	default:
 		return errs.NotImplement
 	}
+	observe(storage, "copy", start, err)
+	if err == nil {
+		callObjChangeHooks("add", storage, "", stdpath.Join(dstDirPath, srcObj.GetName()), srcObj)
+	}
 	return errors.WithStack(err)
 }
 
@@ -554,8 +591,12 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 		}
 		return errors.WithMessage(err, "failed to get object")
 	}
+	if shouldRecycle(ctx, storage, path) {
+		return recycle(ctx, storage, path, rawObj)
+	}
 	dirPath := stdpath.Dir(path)
 
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.Remove:
 		err = s.Remove(ctx, model.UnwrapObj(rawObj))
@@ -569,6 +610,11 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 	default:
 		return errs.NotImplement
 	}
+	observe(storage, "remove", start, err)
+	if err == nil {
+		releaseUsage(ctx, storage, path)
+		callObjChangeHooks("del", storage, path, "", rawObj)
+	}
 	return errors.WithStack(err)
 }
 
@@ -582,6 +628,9 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
 		return errors.Errorf("storage not init: %s", storage.GetStorage().Status)
 	}
+	if err := CheckQuotaByCtx(ctx, file.GetSize()); err != nil {
+		return err
+	}
 	// UrlTree PUT
 	if storage.GetStorage().Driver == "UrlTree" {
 		var link string
@@ -596,7 +645,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	fi, err := GetUnwrap(ctx, storage, dstPath)
 	if err == nil {
 		if fi.GetSize() == 0 {
-			err = Remove(ctx, storage, dstPath)
+			err = Remove(noRecycle(ctx), storage, dstPath)
 			if err != nil {
 				return errors.WithMessagef(err, "while uploading, failed remove existing file which size = 0")
 			}
@@ -624,6 +673,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 		up = func(p float64) {}
 	}
 
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.PutResult:
 		var newObj model.Obj
@@ -643,7 +693,12 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	default:
 		return errs.NotImplement
 	}
+	observe(storage, "put", start, err)
 	log.Debugf("put file [%s] done", file.GetName())
+	if err == nil {
+		recordUsage(ctx, storage, dstPath, file.GetSize())
+		callObjChangeHooks("add", storage, "", dstPath, file)
+	}
 	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
 			// upload failed, recover old obj
@@ -653,7 +708,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 			}
 		} else {
 			// upload success, remove old obj
-			err := Remove(ctx, storage, tempPath)
+			err := Remove(noRecycle(ctx), storage, tempPath)
 			if err != nil {
 				return err
 			} else {
@@ -682,6 +737,7 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 	if err != nil {
 		return errors.WithMessagef(err, "failed to put url")
 	}
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.PutURLResult:
 		var newObj model.Obj
@@ -701,6 +757,13 @@ func PutURL(ctx context.Context, storage driver.Driver, dstDirPath, dstName, url
 	default:
 		return errs.NotImplement
 	}
+	observe(storage, "put_url", start, err)
 	log.Debugf("put url [%s](%s) done", dstName, url)
+	if err == nil {
+		callObjChangeHooks("add", storage, "", stdpath.Join(dstDirPath, dstName), &model.Object{
+			Name:     dstName,
+			Modified: start,
+		})
+	}
 	return errors.WithStack(err)
 }
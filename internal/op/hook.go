@@ -29,6 +29,22 @@ func HandleObjsUpdateHook(parent string, objs []model.Obj) {
 	}
 }
 
+// ObjChange is called after an object of a storage is added, removed or moved,
+// typ is one of "add", "del" and "move", paths are actual paths in the storage.
+type ObjChangeHook func(typ string, storage driver.Driver, srcPath, dstPath string, obj model.Obj)
+
+var objChangeHooks = make([]ObjChangeHook, 0)
+
+func RegisterObjChangeHook(hook ObjChangeHook) {
+	objChangeHooks = append(objChangeHooks, hook)
+}
+
+func callObjChangeHooks(typ string, storage driver.Driver, srcPath, dstPath string, obj model.Obj) {
+	for _, hook := range objChangeHooks {
+		hook(typ, storage, srcPath, dstPath, obj)
+	}
+}
+
 // Setting
 type SettingItemHook func(item *model.SettingItem) error
 
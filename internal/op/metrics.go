@@ -0,0 +1,13 @@
+package op
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+)
+
+// observe records a request sent to the driver of storage since start
+func observe(storage driver.Driver, method string, start time.Time, err error) {
+	metrics.ObserveStorageRequest(storage.GetStorage().MountPath, storage.Config().Name, method, time.Since(start), err)
+}
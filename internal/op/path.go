@@ -12,7 +12,8 @@ import (
 )
 
 // GetStorageAndActualPath Get the corresponding storage and actual path
-// for path: remove the mount path prefix and join the actual root folder if exists
+// for path: remove the mount path prefix and join the actual root folder if exists.
+// The recycle bin can't be reached by a mount path, only by the recycle api
 func GetStorageAndActualPath(rawPath string) (storage driver.Driver, actualPath string, err error) {
 	rawPath = utils.FixAndCleanPath(rawPath)
 	storage = GetBalancedStorage(rawPath)
@@ -27,6 +28,9 @@ func GetStorageAndActualPath(rawPath string) (storage driver.Driver, actualPath
 	log.Debugln("use storage: ", storage.GetStorage().MountPath)
 	mountPath := utils.GetActualMountPath(storage.GetStorage().MountPath)
 	actualPath = utils.FixAndCleanPath(strings.TrimPrefix(rawPath, mountPath))
+	if IsInRecycleBin(actualPath) {
+		err = errs.NewErr(errs.ObjectNotFound, "rawPath: %s", rawPath)
+	}
 	return
 }
 
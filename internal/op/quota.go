@@ -0,0 +1,139 @@
+package op
+
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// CheckQuota fails with errs.QuotaExceeded if the user can't upload one more file of the given size
+func CheckQuota(user *model.User, size int64) error {
+	if user == nil || (user.MaxBytes <= 0 && user.MaxFiles <= 0) {
+		return nil
+	}
+	usage, err := db.GetUserUsage(user.ID)
+	if err != nil {
+		return err
+	}
+	if user.MaxFiles > 0 && usage.UsedFiles+1 > user.MaxFiles {
+		return errors.WithStack(errs.QuotaExceeded)
+	}
+	if user.MaxBytes > 0 && usage.UsedBytes+max(size, 0) > user.MaxBytes {
+		return errors.WithStack(errs.QuotaExceeded)
+	}
+	return nil
+}
+
+// CheckQuotaByCtx is CheckQuota for the user of ctx, which is the task creator in tasks
+func CheckQuotaByCtx(ctx context.Context, size int64) error {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	return CheckQuota(user, size)
+}
+
+// AddUsageByCtx adds the deltas of the bytes and files to the usage of the user of ctx, they are
+// negative for the removed objects
+func AddUsageByCtx(ctx context.Context, bytes, files int64) error {
+	user, _ := ctx.Value(conf.UserKey).(*model.User)
+	if user == nil || ctx.Value(conf.NoUsageKey) != nil || bytes == 0 && files == 0 {
+		return nil
+	}
+	return db.AddUserUsage(user.ID, bytes, files)
+}
+
+func noUsage(ctx context.Context) context.Context {
+	return context.WithValue(ctx, conf.NoUsageKey, struct{}{})
+}
+
+// recordUsage accounts the file put at path to the user of ctx, the file it overwrites is
+// released from the user who uploaded that one
+func recordUsage(ctx context.Context, storage driver.Driver, path string, bytes int64) {
+	if ctx.Value(conf.NoUsageKey) != nil {
+		return
+	}
+	var userId uint
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		userId = user.ID
+	}
+	if err := db.RecordUsage(storage.GetStorage().ID, path, userId, max(bytes, 0)); err != nil {
+		log.Errorf("failed to account upload of [%s]: %+v", path, err)
+	}
+}
+
+// releaseUsage releases the files at and under the removed path from the users who uploaded them
+func releaseUsage(ctx context.Context, storage driver.Driver, path string) {
+	if ctx.Value(conf.NoUsageKey) != nil {
+		return
+	}
+	if err := db.DeleteUsageRecords(storage.GetStorage().ID, path, !IsInRecycleBin(path)); err != nil {
+		log.Errorf("failed to release usage of [%s]: %+v", path, err)
+	}
+}
+
+// moveUsage follows the files moved from srcPath to dstPath, they are released when moved into
+// the recycle bin and accounted again when moved out of it
+func moveUsage(ctx context.Context, storage driver.Driver, srcPath, dstPath string) {
+	if ctx.Value(conf.NoUsageKey) != nil || utils.PathEqual(srcPath, dstPath) {
+		return
+	}
+	var sign int64
+	if srcIn, dstIn := IsInRecycleBin(srcPath), IsInRecycleBin(dstPath); !srcIn && dstIn {
+		sign = -1
+	} else if srcIn && !dstIn {
+		sign = 1
+	}
+	if err := db.MoveUsageRecords(storage.GetStorage().ID, srcPath, dstPath, sign); err != nil {
+		log.Errorf("failed to move usage of [%s] to [%s]: %+v", srcPath, dstPath, err)
+	}
+}
+
+func GetUserUsage(userId uint) (*model.UserUsage, error) {
+	return db.GetUserUsage(userId)
+}
+
+func GetUsageReport(pageIndex, pageSize int) ([]model.UserUsageReport, int64, error) {
+	users, total, err := db.GetUsers(pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]uint, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	usages, err := db.GetUserUsages(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	usageMap := make(map[uint]model.UserUsage, len(usages))
+	for _, u := range usages {
+		usageMap[u.UserId] = u
+	}
+	report := make([]model.UserUsageReport, len(users))
+	for i, u := range users {
+		usage, ok := usageMap[u.ID]
+		if !ok {
+			usage.UserId = u.ID
+		}
+		report[i] = model.UserUsageReport{
+			UserUsage: usage,
+			Username:  u.Username,
+			MaxBytes:  u.MaxBytes,
+			MaxFiles:  u.MaxFiles,
+		}
+	}
+	return report, total, nil
+}
+
+// ResetUserUsage zeroes the usage of the user, the files uploaded so far are no longer accounted
+func ResetUserUsage(userId uint) error {
+	if err := db.DeleteUsageRecordsByUserId(userId); err != nil {
+		return err
+	}
+	return db.DeleteUserUsage(userId)
+}
@@ -0,0 +1,111 @@
+package op_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+)
+
+func TestQuota(t *testing.T) {
+	user := &model.User{ID: 100, Username: "quota", MaxBytes: 100, MaxFiles: 2}
+	ctx := context.WithValue(context.Background(), conf.UserKey, user)
+	if err := op.CheckQuotaByCtx(ctx, 60); err != nil {
+		t.Fatalf("expect quota not exceeded: %+v", err)
+	}
+	if err := op.AddUsageByCtx(ctx, 60, 1); err != nil {
+		t.Fatalf("failed add usage: %+v", err)
+	}
+	if err := op.CheckQuota(user, 60); !errors.Is(err, errs.QuotaExceeded) {
+		t.Errorf("expect bytes quota exceeded, got %v", err)
+	}
+	if err := op.AddUsageByCtx(ctx, 10, 1); err != nil {
+		t.Fatalf("failed add usage: %+v", err)
+	}
+	usage, err := op.GetUserUsage(user.ID)
+	if err != nil {
+		t.Fatalf("failed get usage: %+v", err)
+	}
+	if usage.UsedBytes != 70 || usage.UsedFiles != 2 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+	if err = op.CheckQuota(user, 0); !errors.Is(err, errs.QuotaExceeded) {
+		t.Errorf("expect files quota exceeded, got %v", err)
+	}
+	if err = op.ResetUserUsage(user.ID); err != nil {
+		t.Fatalf("failed reset usage: %+v", err)
+	}
+	if err = op.CheckQuota(user, 100); err != nil {
+		t.Errorf("expect quota not exceeded after reset: %+v", err)
+	}
+}
+
+func TestQuotaRelease(t *testing.T) {
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/quota",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/quota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: 101, Username: "quota_release", MaxBytes: 100}
+	other := &model.User{ID: 102, Username: "quota_other"}
+	ctx := context.WithValue(context.Background(), conf.UserKey, user)
+	otherCtx := context.WithValue(context.Background(), conf.UserKey, other)
+	put := func(dir, name, content string) {
+		file := &stream.FileStream{
+			Obj:    &model.Object{Name: name, Size: int64(len(content))},
+			Reader: strings.NewReader(content),
+		}
+		if err := op.Put(ctx, storage, dir, file, nil); err != nil {
+			t.Fatalf("failed put %s: %+v", name, err)
+		}
+	}
+	expect := func(bytes, files int64) {
+		t.Helper()
+		expectUsage(t, user.ID, bytes, files)
+	}
+	put("/", "a.txt", "hello")
+	put("/dir", "b.txt", "world!")
+	expect(11, 2)
+	// overwriting counts only the difference
+	put("/", "a.txt", "hello world")
+	expect(17, 2)
+	if err = op.Remove(ctx, storage, "/a.txt"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	expect(6, 1)
+	// the files follow renames, and are released from the uploader whoever removes them
+	if err = op.Rename(ctx, storage, "/dir", "moved"); err != nil {
+		t.Fatalf("failed rename: %+v", err)
+	}
+	if err = op.Remove(otherCtx, storage, "/moved"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	expect(0, 0)
+	expectUsage(t, other.ID, 0, 0)
+}
+
+func expectUsage(t *testing.T, userId uint, bytes, files int64) {
+	t.Helper()
+	usage, err := op.GetUserUsage(userId)
+	if err != nil {
+		t.Fatalf("failed get usage: %+v", err)
+	}
+	if usage.UsedBytes != bytes || usage.UsedFiles != files {
+		t.Errorf("expect usage %d bytes %d files, got %+v", bytes, files, usage)
+	}
+}
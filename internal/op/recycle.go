@@ -0,0 +1,159 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// RecycleBinName is the hidden folder at the root of a storage holding removed objects
+const RecycleBinName = ".openlist_recycle"
+
+// IsInRecycleBin reports whether the actual path is the recycle bin or inside it
+func IsInRecycleBin(actualPath string) bool {
+	p := utils.FixAndCleanPath(actualPath)
+	return p == "/"+RecycleBinName || strings.HasPrefix(p, "/"+RecycleBinName+"/")
+}
+
+func shouldRecycle(ctx context.Context, storage driver.Driver, path string) bool {
+	return storage.GetStorage().RecycleBin && ctx.Value(conf.NoRecycleKey) == nil && !IsInRecycleBin(path)
+}
+
+func noRecycle(ctx context.Context) context.Context {
+	return context.WithValue(ctx, conf.NoRecycleKey, struct{}{})
+}
+
+// transferIn moves the object at srcPath into dstDirPath of the same storage,
+// falling back to copy then remove if the driver can't move
+func transferIn(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string) error {
+	switch storage.(type) {
+	case driver.Move, driver.MoveResult:
+		return Move(ctx, storage, srcPath, dstDirPath)
+	case driver.Copy, driver.CopyResult:
+		if err := Copy(ctx, storage, srcPath, dstDirPath); err != nil {
+			return err
+		}
+		return Remove(noRecycle(ctx), storage, srcPath)
+	default:
+		return errors.WithStack(errs.RecycleBinNotSupported)
+	}
+}
+
+// recycle moves the object into a new folder of the recycle bin and records it,
+// the files of it are released from the usages of the users who uploaded them
+func recycle(ctx context.Context, storage driver.Driver, path string, obj model.Obj) error {
+	trashPath := stdpath.Join("/", RecycleBinName, fmt.Sprintf("%d_%s", time.Now().UnixNano(), random.String(6)))
+	if err := MakeDir(ctx, storage, trashPath); err != nil {
+		return errors.WithMessage(err, "failed make recycle bin folder")
+	}
+	if err := transferIn(noUsage(ctx), storage, path, trashPath); err != nil {
+		if e := Remove(ctx, storage, trashPath); e != nil {
+			log.Errorf("failed remove recycle bin folder %s: %+v", trashPath, e)
+		}
+		return errors.WithMessage(err, "failed move object into recycle bin")
+	}
+	moveUsage(ctx, storage, path, stdpath.Join(trashPath, obj.GetName()))
+	item := &model.RecycleItem{
+		MountPath: storage.GetStorage().MountPath,
+		Path:      utils.GetFullPath(storage.GetStorage().MountPath, path),
+		TrashPath: trashPath,
+		Name:      obj.GetName(),
+		IsDir:     obj.IsDir(),
+		Size:      obj.GetSize(),
+		Deleted:   time.Now(),
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		item.UserId = user.ID
+		item.Username = user.Username
+	}
+	return errors.WithMessage(db.CreateRecycleItem(item), "failed record recycle item")
+}
+
+func GetRecycleItemById(id uint) (*model.RecycleItem, error) {
+	item, err := db.GetRecycleItemById(id)
+	if err != nil {
+		if errors.Is(errors.Cause(err), gorm.ErrRecordNotFound) {
+			return nil, errors.WithStack(errs.RecycleItemNotFound)
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+func GetRecycleItems(pageIndex, pageSize int) ([]model.RecycleItem, int64, error) {
+	return db.GetRecycleItems(pageIndex, pageSize)
+}
+
+func GetRecycleItemsByUserId(userId uint, pageIndex, pageSize int) ([]model.RecycleItem, int64, error) {
+	return db.GetRecycleItemsByUserId(userId, pageIndex, pageSize)
+}
+
+// RestoreRecycleItem moves the object back to its original path
+func RestoreRecycleItem(ctx context.Context, item *model.RecycleItem) error {
+	storage, err := GetStorageByMountPath(item.MountPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	dstPath := utils.FixAndCleanPath(strings.TrimPrefix(item.Path, item.MountPath))
+	if _, err := Get(ctx, storage, dstPath); err == nil {
+		return errors.WithStack(errs.RestoreTargetExists)
+	} else if !errs.IsObjectNotFound(err) {
+		return errors.WithMessage(err, "failed check original path")
+	}
+	dstDirPath := stdpath.Dir(dstPath)
+	if err = MakeDir(ctx, storage, dstDirPath); err != nil {
+		return errors.WithMessage(err, "failed make original dir")
+	}
+	srcPath := stdpath.Join(item.TrashPath, item.Name)
+	if err = transferIn(noUsage(ctx), storage, srcPath, dstDirPath); err != nil {
+		return errors.WithMessage(err, "failed move object out of recycle bin")
+	}
+	// the files are accounted to the users who uploaded them again
+	moveUsage(ctx, storage, srcPath, dstPath)
+	if err = Remove(ctx, storage, item.TrashPath); err != nil {
+		log.Errorf("failed remove recycle bin folder %s: %+v", item.TrashPath, err)
+	}
+	return db.DeleteRecycleItemById(item.ID)
+}
+
+// PurgeRecycleItem removes the object from the recycle bin permanently
+func PurgeRecycleItem(ctx context.Context, item *model.RecycleItem) error {
+	storage, err := GetStorageByMountPath(item.MountPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	if err = Remove(ctx, storage, item.TrashPath); err != nil {
+		return errors.WithMessage(err, "failed remove object in recycle bin")
+	}
+	return db.DeleteRecycleItemById(item.ID)
+}
+
+// CleanExpiredRecycleItems purges the items removed more than days ago
+func CleanExpiredRecycleItems(ctx context.Context, days int) {
+	if days <= 0 {
+		return
+	}
+	items, err := db.GetRecycleItemsBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Errorf("failed get expired recycle items: %+v", err)
+		return
+	}
+	for i := range items {
+		if err = PurgeRecycleItem(ctx, &items[i]); err != nil {
+			log.Warnf("failed purge expired recycle item %s: %+v", items[i].Path, err)
+		}
+	}
+}
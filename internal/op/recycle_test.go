@@ -0,0 +1,85 @@
+package op_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+)
+
+func TestRecycleBin(t *testing.T) {
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:     "Local",
+		MountPath:  "/recycle",
+		RecycleBin: true,
+		Addition:   fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/recycle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: 7, Username: "recycler"}
+	ctx := context.WithValue(context.Background(), conf.UserKey, user)
+	file := &stream.FileStream{
+		Obj:    &model.Object{Name: "a.txt", Size: 5},
+		Reader: strings.NewReader("hello"),
+	}
+	if err = op.Put(ctx, storage, "/", file, nil); err != nil {
+		t.Fatalf("failed put: %+v", err)
+	}
+	expectUsage(t, user.ID, 5, 1)
+	if err = op.Remove(ctx, storage, "/a.txt"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	// the recycled file is released, and accounted again when restored
+	expectUsage(t, user.ID, 0, 0)
+	if _, err = os.Stat(filepath.Join(root, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expect a.txt moved away, got %v", err)
+	}
+	items, total, err := op.GetRecycleItemsByUserId(user.ID, 1, 10)
+	if err != nil || total != 1 {
+		t.Fatalf("expect 1 recycle item, got %d: %+v", total, err)
+	}
+	if items[0].Path != "/recycle/a.txt" || items[0].Size != 5 || items[0].Username != "recycler" {
+		t.Errorf("unexpected recycle item: %+v", items[0])
+	}
+	if err = op.RestoreRecycleItem(ctx, &items[0]); err != nil {
+		t.Fatalf("failed restore: %+v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "a.txt")); err != nil || string(data) != "hello" {
+		t.Fatalf("expect a.txt restored, got %q: %v", data, err)
+	}
+	if _, total, _ = op.GetRecycleItemsByUserId(user.ID, 1, 10); total != 0 {
+		t.Errorf("expect recycle item dropped after restore, got %d", total)
+	}
+	expectUsage(t, user.ID, 5, 1)
+	if err = op.Remove(ctx, storage, "/a.txt"); err != nil {
+		t.Fatalf("failed remove: %+v", err)
+	}
+	items, _, _ = op.GetRecycleItemsByUserId(user.ID, 1, 10)
+	for _, p := range []string{"/recycle/" + op.RecycleBinName, "/recycle" + items[0].TrashPath + "/a.txt"} {
+		if _, _, err = op.GetStorageAndActualPath(p); !errs.IsObjectNotFound(err) {
+			t.Errorf("expect %s unreachable by mount path, got %v", p, err)
+		}
+	}
+	if err = op.PurgeRecycleItem(ctx, &items[0]); err != nil {
+		t.Fatalf("failed purge: %+v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, op.RecycleBinName))
+	if len(entries) != 0 {
+		t.Errorf("expect recycle bin empty after purge, got %d entries", len(entries))
+	}
+	expectUsage(t, user.ID, 0, 0)
+}
@@ -0,0 +1,141 @@
+package op
+
+import (
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the naming rules of the buckets of aws, so that the clients accept them
+var s3BucketNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// newS3Secret returns a random secret access key of s3 and the value of it saved to the database
+func newS3Secret() (string, string, error) {
+	secret := random.String(40)
+	stored, err := encryptSecret(secret)
+	if err != nil {
+		return "", "", errors.WithMessage(err, "failed encrypt s3 secret")
+	}
+	return secret, stored, nil
+}
+
+// CreateS3Key creates an s3 key for the user and returns the secret access key of it,
+// only the encrypted secret is saved
+func CreateS3Key(user *model.User, k *model.S3Key) (string, error) {
+	secret, stored, err := newS3Secret()
+	if err != nil {
+		return "", err
+	}
+	k.ID = 0
+	k.UserID = user.ID
+	k.AccessKeyID = model.S3KeyPrefix + random.String(17)
+	k.Secret = stored
+	k.CreatedTime = time.Now()
+	k.LastUsedTime = time.Time{}
+	k.LastUsedIP = ""
+	return secret, db.CreateS3Key(k)
+}
+
+// AuthS3Key returns the user the s3 key acts as and the secret access key of it, the caller
+// has to verify the request is signed with the secret
+func AuthS3Key(accessKeyID, ip string) (*model.User, string, error) {
+	k, err := db.GetS3KeyByAccessKeyID(accessKeyID)
+	if err != nil {
+		return nil, "", errors.WithStack(errs.InvalidS3Key)
+	}
+	secret, err := decryptSecret(k.Secret)
+	if err != nil || secret == "" {
+		return nil, "", errors.Wrap(errs.InvalidS3Key, "the secret can't be read")
+	}
+	user, err := db.GetUserById(k.UserID)
+	if err != nil {
+		return nil, "", errors.WithStack(errs.InvalidS3Key)
+	}
+	if user.Disabled {
+		return nil, "", errors.Wrap(errs.InvalidS3Key, "user is disabled")
+	}
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	// don't write the db on every request
+	if time.Since(k.LastUsedTime) > time.Minute || k.LastUsedIP != ip {
+		k.LastUsedTime = time.Now()
+		k.LastUsedIP = ip
+		if err = db.UpdateS3KeyLastUsed(k); err != nil {
+			log.Warnf("failed update last used of s3 key %s: %+v", k.AccessKeyID, err)
+		}
+	}
+	return user, secret, nil
+}
+
+func GetS3KeysByUserId(userId uint, pageIndex, pageSize int) ([]model.S3Key, int64, error) {
+	return db.GetS3KeysByUserId(userId, pageIndex, pageSize)
+}
+
+func GetS3KeyByIdAndUserId(id uint, userId uint) (*model.S3Key, error) {
+	k, err := db.GetS3KeyById(id)
+	if err != nil {
+		return nil, err
+	}
+	if k.UserID != userId {
+		return nil, errors.WithStack(errs.InvalidS3Key)
+	}
+	return k, nil
+}
+
+func DeleteS3KeyById(id uint) error {
+	return db.DeleteS3KeyById(id)
+}
+
+func GetS3BucketsByUserId(userId uint) ([]model.S3Bucket, error) {
+	return db.GetS3BucketsByUserId(userId)
+}
+
+func GetS3BucketByIdAndUserId(id uint, userId uint) (*model.S3Bucket, error) {
+	b, err := db.GetS3BucketById(id)
+	if err != nil {
+		return nil, err
+	}
+	if b.UserID != userId {
+		return nil, errors.New("s3 bucket not found")
+	}
+	return b, nil
+}
+
+func checkS3Bucket(b *model.S3Bucket) error {
+	if !s3BucketNameRe.MatchString(b.Name) {
+		return errors.Wrapf(errs.InvalidS3BucketName, "%s", b.Name)
+	}
+	b.Path = utils.FixAndCleanPath(b.Path)
+	if old, err := db.GetS3BucketByUserName(b.UserID, b.Name); err == nil && old.ID != b.ID {
+		return errors.WithStack(errs.S3BucketExists)
+	}
+	return nil
+}
+
+func CreateS3Bucket(b *model.S3Bucket) error {
+	b.ID = 0
+	if err := checkS3Bucket(b); err != nil {
+		return err
+	}
+	return db.CreateS3Bucket(b)
+}
+
+func UpdateS3Bucket(b *model.S3Bucket) error {
+	if err := checkS3Bucket(b); err != nil {
+		return err
+	}
+	return db.UpdateS3Bucket(b)
+}
+
+func DeleteS3BucketById(id uint) error {
+	return db.DeleteS3BucketById(id)
+}
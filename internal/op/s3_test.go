@@ -0,0 +1,75 @@
+package op_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestS3Key(t *testing.T) {
+	user := &model.User{Username: "s3_user", Role: model.GENERAL, BasePath: "/home", Permission: 0x31FF}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	k := &model.S3Key{Name: "rclone"}
+	secret, err := op.CreateS3Key(user, k)
+	if err != nil {
+		t.Fatalf("failed create s3 key: %+v", err)
+	}
+	if !strings.HasPrefix(k.AccessKeyID, model.S3KeyPrefix) || k.UserID != user.ID {
+		t.Errorf("unexpected s3 key: %+v", k)
+	}
+	owner, stored, err := op.AuthS3Key(k.AccessKeyID, "127.0.0.1:9000")
+	if err != nil {
+		t.Fatalf("failed auth s3 key: %+v", err)
+	}
+	if owner.ID != user.ID || owner.BasePath != "/home" {
+		t.Errorf("expect the key resolved to the owner, got %+v", owner)
+	}
+	if secret == "" || stored != secret {
+		t.Errorf("expect the secret of the key returned, got %q", stored)
+	}
+	other, err := op.CreateS3Key(user, &model.S3Key{Name: "s3cmd"})
+	if err != nil {
+		t.Fatalf("failed create s3 key: %+v", err)
+	}
+	if other == secret {
+		t.Errorf("expect the secrets of the keys different")
+	}
+	if err = op.DeleteUserById(user.ID); err != nil {
+		t.Fatalf("failed delete user: %+v", err)
+	}
+	if _, _, err = op.AuthS3Key(k.AccessKeyID, "127.0.0.1:9000"); !errors.Is(err, errs.InvalidS3Key) {
+		t.Errorf("expect the key of the deleted user rejected, got %v", err)
+	}
+}
+
+func TestS3Bucket(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "photos", err: nil},
+		{name: "photos", err: errs.S3BucketExists},
+		{name: "Photos", err: errs.InvalidS3BucketName},
+		{name: "a", err: errs.InvalidS3BucketName},
+		{name: "-photos", err: errs.InvalidS3BucketName},
+	}
+	for _, tt := range tests {
+		err := op.CreateS3Bucket(&model.S3Bucket{UserID: 1000, Name: tt.name, Path: "pictures/"})
+		if tt.err == nil && err != nil || tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("create bucket %s: expect %v, got %v", tt.name, tt.err, err)
+		}
+	}
+	buckets, err := op.GetS3BucketsByUserId(1000)
+	if err != nil || len(buckets) != 1 || buckets[0].Path != "/pictures" {
+		t.Fatalf("unexpected buckets: %+v, %v", buckets, err)
+	}
+	if err = op.CreateS3Bucket(&model.S3Bucket{UserID: 1001, Name: "photos"}); err != nil {
+		t.Errorf("expect the same bucket name allowed for another user, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package op
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/OpenListTeam/go-cache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var sessionCache = cache.NewMemCache(cache.WithShards[*model.Session](2))
+
+func sessionExpiresIn() time.Duration {
+	hours := conf.Conf.RefreshTokenExpiresIn
+	if hours <= 0 {
+		hours = conf.Conf.TokenExpiresIn
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// renewRefreshToken replaces the refresh token of the session, the old one is no longer accepted
+func renewRefreshToken(s *model.Session) string {
+	secret := random.String(48)
+	s.RefreshHash = utils.HashData(utils.SHA256, []byte(secret))
+	return s.SID + "." + secret
+}
+
+// CreateSession starts a session of the user, the returned refresh token is only known here
+func CreateSession(user *model.User, ip, userAgent string) (*model.Session, string, error) {
+	if err := db.DeleteExpiredSessions(user.ID); err != nil {
+		log.Warnf("failed delete expired sessions of user %s: %+v", user.Username, err)
+	}
+	now := time.Now()
+	s := &model.Session{
+		SID:         random.String(32),
+		UserID:      user.ID,
+		Device:      model.DeviceOf(userAgent),
+		IP:          ip,
+		UserAgent:   userAgent,
+		PwdTS:       user.PwdTS,
+		CreatedTime: now,
+		LastSeen:    now,
+		ExpiresAt:   now.Add(sessionExpiresIn()),
+	}
+	refreshToken := renewRefreshToken(s)
+	if err := db.CreateSession(s); err != nil {
+		return nil, "", err
+	}
+	return s, refreshToken, nil
+}
+
+// GetSessionBySID returns the session if it is neither revoked nor expired
+func GetSessionBySID(sid string) (*model.Session, error) {
+	if sid == "" {
+		return nil, errors.WithStack(errs.SessionRevoked)
+	}
+	s, ok := sessionCache.Get(sid)
+	if !ok {
+		var err error
+		if s, err = db.GetSessionBySID(sid); err != nil {
+			return nil, errors.WithStack(errs.SessionRevoked)
+		}
+		sessionCache.Set(sid, s, cache.WithEx[*model.Session](time.Minute))
+	}
+	if s.IsExpired() {
+		return nil, errors.WithStack(errs.SessionExpired)
+	}
+	return s, nil
+}
+
+// TouchSession tracks the last seen of the session, the db is written at most once a minute
+func TouchSession(sid, ip string) {
+	s, err := GetSessionBySID(sid)
+	if err != nil || time.Since(s.LastSeen) < time.Minute && s.IP == ip {
+		return
+	}
+	// the cached one may be read meanwhile
+	touched := *s
+	touched.LastSeen = time.Now()
+	touched.IP = ip
+	if err = db.UpdateSessionLastSeen(&touched); err != nil {
+		log.Warnf("failed update last seen of session %d: %+v", s.ID, err)
+		return
+	}
+	sessionCache.Set(sid, &touched, cache.WithEx[*model.Session](time.Minute))
+}
+
+// RefreshSession takes the refresh token of a session, extends the session and renews its
+// refresh token. It returns the session, the user of it and the new refresh token.
+func RefreshSession(refreshToken, ip, userAgent string) (*model.Session, *model.User, string, error) {
+	sid, secret, _ := strings.Cut(refreshToken, ".")
+	if sid == "" || secret == "" {
+		return nil, nil, "", errors.WithStack(errs.InvalidRefreshToken)
+	}
+	s, err := db.GetSessionBySID(sid)
+	if err != nil {
+		return nil, nil, "", errors.WithStack(errs.SessionRevoked)
+	}
+	if subtle.ConstantTimeCompare([]byte(s.RefreshHash), []byte(utils.HashData(utils.SHA256, []byte(secret)))) != 1 {
+		return nil, nil, "", errors.WithStack(errs.InvalidRefreshToken)
+	}
+	if s.IsExpired() {
+		return nil, nil, "", errors.WithStack(errs.SessionExpired)
+	}
+	user, err := db.GetUserById(s.UserID)
+	if err != nil || user.PwdTS != s.PwdTS || user.Disabled {
+		return nil, nil, "", errors.WithStack(errs.SessionRevoked)
+	}
+	now := time.Now()
+	s.IP = ip
+	s.UserAgent = userAgent
+	s.Device = model.DeviceOf(userAgent)
+	s.LastSeen = now
+	s.ExpiresAt = now.Add(sessionExpiresIn())
+	newToken := renewRefreshToken(s)
+	if err = db.UpdateSession(s); err != nil {
+		return nil, nil, "", err
+	}
+	sessionCache.Del(sid)
+	return s, user, newToken, nil
+}
+
+func GetSessionsByUserId(userId uint, pageIndex, pageSize int) ([]model.Session, int64, error) {
+	return db.GetSessionsByUserId(userId, pageIndex, pageSize)
+}
+
+func GetSessionByIdAndUserId(id uint, userId uint) (*model.Session, error) {
+	s, err := db.GetSessionById(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.UserID != userId {
+		return nil, errors.WithStack(errs.SessionRevoked)
+	}
+	return s, nil
+}
+
+// RevokeSession deletes the session, the tokens issued on it are no longer accepted
+func RevokeSession(s *model.Session) error {
+	if err := db.DeleteSessionById(s.ID); err != nil {
+		return err
+	}
+	sessionCache.Del(s.SID)
+	return nil
+}
+
+func RevokeSessionBySID(sid string) error {
+	s, err := db.GetSessionBySID(sid)
+	if err != nil {
+		return errors.WithStack(errs.SessionRevoked)
+	}
+	return RevokeSession(s)
+}
+
+// RevokeUserSessions deletes all the sessions of the user
+func RevokeUserSessions(userId uint) error {
+	if err := db.DeleteSessionsByUserId(userId); err != nil {
+		return err
+	}
+	sessionCache.Clear()
+	return nil
+}
@@ -0,0 +1,67 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestSession(t *testing.T) {
+	user := &model.User{Username: "session_user", Role: model.GENERAL, BasePath: "/"}
+	user.SetPassword("password")
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	ua := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+	session, refreshToken, err := op.CreateSession(user, "127.0.0.1", ua)
+	if err != nil {
+		t.Fatalf("failed create session: %+v", err)
+	}
+	if session.Device != "Chrome on Windows" {
+		t.Errorf("unexpected device: %s", session.Device)
+	}
+	if _, err = op.GetSessionBySID(session.SID); err != nil {
+		t.Fatalf("failed get session: %+v", err)
+	}
+	refreshed, _, newRefreshToken, err := op.RefreshSession(refreshToken, "127.0.0.2", "curl/8.0")
+	if err != nil {
+		t.Fatalf("failed refresh session: %+v", err)
+	}
+	if refreshed.SID != session.SID || refreshed.IP != "127.0.0.2" {
+		t.Errorf("unexpected refreshed session: %+v", refreshed)
+	}
+	if _, _, _, err = op.RefreshSession(refreshToken, "127.0.0.1", ua); !errors.Is(err, errs.InvalidRefreshToken) {
+		t.Errorf("expect the old refresh token rejected, got %v", err)
+	}
+	other, _, err := op.CreateSession(user, "127.0.0.1", ua)
+	if err != nil {
+		t.Fatalf("failed create session: %+v", err)
+	}
+	if _, total, _ := op.GetSessionsByUserId(user.ID, 1, 10); total != 2 {
+		t.Errorf("expect 2 sessions, got %d", total)
+	}
+	if err = op.RevokeSession(refreshed); err != nil {
+		t.Fatalf("failed revoke session: %+v", err)
+	}
+	if _, err = op.GetSessionBySID(session.SID); !errors.Is(err, errs.SessionRevoked) {
+		t.Errorf("expect revoked session rejected, got %v", err)
+	}
+	if _, _, _, err = op.RefreshSession(newRefreshToken, "127.0.0.1", ua); !errors.Is(err, errs.SessionRevoked) {
+		t.Errorf("expect refresh token of revoked session rejected, got %v", err)
+	}
+	if _, err = op.GetSessionBySID(other.SID); err != nil {
+		t.Fatalf("expect the other session kept: %+v", err)
+	}
+	// changing the password revokes all the sessions
+	user.SetPassword("new_password")
+	user.PwdTS++
+	if err = op.UpdateUser(user); err != nil {
+		t.Fatalf("failed update user: %+v", err)
+	}
+	if _, err = op.GetSessionBySID(other.SID); !errors.Is(err, errs.SessionRevoked) {
+		t.Errorf("expect sessions revoked by password change, got %v", err)
+	}
+}
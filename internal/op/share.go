@@ -0,0 +1,141 @@
+package op
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const shareIdLength = 10
+
+// shareDownloadExpiry is how long the download counted for a client is remembered, so that its
+// ranged requests seeking or resuming the file are neither counted again nor refused once the
+// share is exhausted
+const shareDownloadExpiry = 12 * time.Hour
+
+func CreateShare(s *model.Share) error {
+	s.Path = utils.FixAndCleanPath(s.Path)
+	s.Accessed = 0
+	s.Created = time.Now()
+	for {
+		s.ID = random.String(shareIdLength)
+		if _, err := db.GetShareById(s.ID); errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	return db.CreateShare(s)
+}
+
+func GetShareById(id string) (*model.Share, error) {
+	s, err := db.GetShareById(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.WithStack(errs.ShareNotFound)
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetShareByIdAndUserId returns the share only if it belongs to the user
+func GetShareByIdAndUserId(id string, userId uint) (*model.Share, error) {
+	s, err := GetShareById(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.UserId != userId {
+		return nil, errors.WithStack(errs.ShareNotFound)
+	}
+	return s, nil
+}
+
+func UpdateShare(s *model.Share) error {
+	old, err := db.GetShareById(s.ID)
+	if err != nil {
+		return err
+	}
+	s.Path = utils.FixAndCleanPath(s.Path)
+	s.UserId = old.UserId
+	s.Created = old.Created
+	s.Accessed = old.Accessed
+	return db.UpdateShare(s)
+}
+
+func GetShares(pageIndex, pageSize int) ([]model.Share, int64, error) {
+	return db.GetShares(pageIndex, pageSize)
+}
+
+func GetSharesByUserId(userId uint, pageIndex, pageSize int) ([]model.Share, int64, error) {
+	return db.GetSharesByUserId(userId, pageIndex, pageSize)
+}
+
+// AccessShare counts one access of the share, failing once it is exhausted
+func AccessShare(s *model.Share) error {
+	ok, err := db.IncreaseShareAccessed(s.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.WithStack(errs.ShareExhausted)
+	}
+	s.Accessed++
+	return nil
+}
+
+func DeleteShareById(id string) error {
+	return db.DeleteShareById(id)
+}
+
+// AccessShareDownload counts a download of the path of the share by the client, unless it's a
+// ranged request not starting at 0 of a download counted before
+func AccessShareDownload(s *model.Share, ip, path, rangeHeader string) error {
+	if IsShareDownloadResumed(s, ip, path, rangeHeader) {
+		return nil
+	}
+	if err := AccessShare(s); err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := db.DeleteExpiredShareDownloads(now.UnixNano()); err != nil {
+		log.Warnf("failed delete expired share downloads: %+v", err)
+	}
+	return db.SaveShareDownload(&model.ShareDownload{
+		ID:      shareDownloadId(s, ip, path),
+		ShareID: s.ID,
+		Expiry:  now.Add(shareDownloadExpiry).UnixNano(),
+	})
+}
+
+// IsShareDownloadResumed reports whether the request seeks or resumes a download of the path
+// counted for the client
+func IsShareDownloadResumed(s *model.Share, ip, path, rangeHeader string) bool {
+	spec, ok := strings.CutPrefix(rangeHeader, "bytes=")
+	if !ok {
+		return false
+	}
+	if start, _, _ := strings.Cut(spec, "-"); strings.TrimSpace(start) == "0" {
+		return false
+	}
+	ok, err := db.HasShareDownload(shareDownloadId(s, ip, path), time.Now().UnixNano())
+	if err != nil {
+		log.Warnf("failed check share download: %+v", err)
+	}
+	return ok
+}
+
+func shareDownloadId(s *model.Share, ip, path string) string {
+	sum := sha256.Sum256([]byte(s.ID + "|" + ip + "|" + path))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,41 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestAccessShareDownload(t *testing.T) {
+	s := &model.Share{UserId: 1, Path: "/share", MaxAccessed: 1}
+	if err := op.CreateShare(s); err != nil {
+		t.Fatalf("failed create share: %+v", err)
+	}
+	const ip, path = "127.0.0.1", "/a.mp4"
+	if err := op.AccessShareDownload(s, ip, path, "bytes=0-"); err != nil {
+		t.Fatalf("failed access share: %+v", err)
+	}
+	// seeking the counted download doesn't count, even though the share is exhausted now
+	for _, r := range []string{"bytes=1024-", "bytes=-512"} {
+		if !op.IsShareDownloadResumed(s, ip, path, r) {
+			t.Errorf("expect %s resuming the download", r)
+		}
+		if err := op.AccessShareDownload(s, ip, path, r); err != nil {
+			t.Errorf("expect %s not counted, got %v", r, err)
+		}
+	}
+	tests := []struct{ ip, path, r string }{
+		{ip: ip, path: path, r: ""},
+		{ip: ip, path: path, r: "bytes=0-1023"},
+		{ip: "127.0.0.2", path: path, r: "bytes=1024-"},
+		{ip: ip, path: "/b.mp4", r: "bytes=1024-"},
+	}
+	for _, tt := range tests {
+		if err := op.AccessShareDownload(s, tt.ip, tt.path, tt.r); !errors.Is(err, errs.ShareExhausted) {
+			t.Errorf("expect %+v counted and refused, got %v", tt, err)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/generic_sync"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -55,8 +56,13 @@ func CreateStorage(ctx context.Context, storage model.Storage) (uint, error) {
 		return 0, errors.WithMessage(err, "failed get driver new")
 	}
 	storageDriver := driverNew()
+	encrypted, err := encryptedStorage(&storage)
+	if err != nil {
+		return 0, err
+	}
 	// insert storage to database
-	err = db.CreateStorage(&storage)
+	err = db.CreateStorage(encrypted)
+	storage.ID = encrypted.ID
 	if err != nil {
 		return storage.ID, errors.WithMessage(err, "failed create storage in database")
 	}
@@ -80,6 +86,13 @@ func LoadStorage(ctx context.Context, storage model.Storage) error {
 		return errors.WithMessage(err, "failed get driver new")
 	}
 	storageDriver := driverNew()
+	// don't init the driver with the encrypted values, but keep it for being updated
+	if err = DecryptStorage(&storage); err != nil {
+		storageDriver.SetStorage(storage)
+		storageDriver.GetStorage().SetStatus(err.Error())
+		storagesMap.Store(storage.MountPath, storageDriver)
+		return err
+	}
 
 	err = initStorage(ctx, storage, storageDriver)
 	go callStorageHooks("add", storageDriver)
@@ -228,7 +241,11 @@ func UpdateStorage(ctx context.Context, storage model.Storage) error {
 	}
 	storage.Modified = time.Now()
 	storage.MountPath = utils.FixAndCleanPath(storage.MountPath)
-	err = db.UpdateStorage(&storage)
+	encrypted, err := encryptedStorage(&storage)
+	if err != nil {
+		return err
+	}
+	err = db.UpdateStorage(encrypted)
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in database")
 	}
@@ -270,12 +287,17 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 		}
 		// delete the storage in the memory
 		storagesMap.Delete(storage.MountPath)
+		metrics.DeleteStorage(storage.MountPath)
 		go callStorageHooks("del", storageDriver)
 	}
 	// delete the storage in the database
 	if err := db.DeleteStorageById(id); err != nil {
 		return errors.WithMessage(err, "failed delete storage in database")
 	}
+	// the objects stay in the storage, only the records are dropped
+	if err := db.DeleteRecycleItemsByMountPath(storage.MountPath); err != nil {
+		log.Errorf("failed delete recycle items of storage %s: %+v", storage.MountPath, err)
+	}
 	return nil
 }
 
@@ -295,7 +317,11 @@ func saveDriverStorage(driver driver.Driver) error {
 		return errors.Wrap(err, "error while marshal addition")
 	}
 	storage.Addition = str
-	err = db.UpdateStorage(storage)
+	encrypted, err := encryptedStorage(storage)
+	if err != nil {
+		return err
+	}
+	err = db.UpdateStorage(encrypted)
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in database")
 	}
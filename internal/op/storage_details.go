@@ -0,0 +1,91 @@
+package op
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/go-cache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the details are asked by every webdav PROPFIND of the quota, while most drivers get them
+// by an api call, so they are cached for a while
+const storageDetailsExpiration = 5 * time.Minute
+
+var storageDetailsCache = cache.NewMemCache(cache.WithShards[*model.StorageDetails](16))
+var storageDetailsG singleflight.Group[*model.StorageDetails]
+
+// GetStorageDetails returns the total and the used space of the storage,
+// errs.NotImplement if the driver can't report them
+func GetStorageDetails(ctx context.Context, storage driver.Driver) (*model.StorageDetails, error) {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	wd, ok := storage.(driver.WithDetails)
+	if !ok {
+		return nil, errs.NotImplement
+	}
+	key := storage.GetStorage().MountPath
+	if details, ok := storageDetailsCache.Get(key); ok {
+		return details, nil
+	}
+	details, err, _ := storageDetailsG.Do(key, func() (*model.StorageDetails, error) {
+		details, err := wd.GetDetails(ctx)
+		if err != nil {
+			return nil, err
+		}
+		storageDetailsCache.Set(key, details, cache.WithEx[*model.StorageDetails](storageDetailsExpiration))
+		return details, nil
+	})
+	return details, err
+}
+
+// GetStorageDetailsByPath returns the details of the storage the path is in. For a virtual
+// folder holding storages, they're the sum of the storages in it readable by the caller, and
+// the total space is unknown if it's unknown for any of them. A nil readable reads all.
+func GetStorageDetailsByPath(ctx context.Context, path string, readable func(mountPath string) bool) (*model.StorageDetails, error) {
+	path = utils.FixAndCleanPath(path)
+	if storages := getStoragesByPath(path); len(storages) > 0 {
+		return GetStorageDetails(ctx, storages[0])
+	}
+	var sum *model.StorageDetails
+	unknown := false
+	for _, storage := range storagesMap.Values() {
+		mountPath := utils.GetActualMountPath(storage.GetStorage().MountPath)
+		if !utils.IsSubPath(path, mountPath) || readable != nil && !readable(mountPath) {
+			continue
+		}
+		details, err := GetStorageDetails(ctx, storage)
+		if err != nil {
+			if !errs.IsNotImplement(err) {
+				log.Warnf("failed get details of storage %s: %+v", storage.GetStorage().MountPath, err)
+			}
+			continue
+		}
+		if sum == nil {
+			sum = &model.StorageDetails{}
+		}
+		sum.TotalSpace += details.TotalSpace
+		sum.UsedSpace += details.UsedSpace
+		unknown = unknown || details.TotalSpace <= 0
+	}
+	if sum == nil {
+		return nil, errs.NotImplement
+	}
+	if unknown {
+		sum.TotalSpace = 0
+	}
+	return sum, nil
+}
+
+func init() {
+	RegisterStorageHook(func(typ string, storage driver.Driver) {
+		storageDetailsCache.Del(storage.GetStorage().MountPath)
+	})
+}
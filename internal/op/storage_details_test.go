@@ -0,0 +1,48 @@
+package op_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func TestGetStorageDetailsByPath(t *testing.T) {
+	ctx := context.Background()
+	for _, mountPath := range []string{"/details/x", "/details/y"} {
+		addition, _ := utils.Json.MarshalToString(map[string]string{"root_folder_path": t.TempDir()})
+		id, err := op.CreateStorage(ctx, model.Storage{Driver: "Local", MountPath: mountPath, Addition: addition})
+		if err != nil {
+			t.Fatalf("failed create storage: %+v", err)
+		}
+		defer func() { _ = op.DeleteStorageById(ctx, id) }()
+	}
+	details, err := op.GetStorageDetailsByPath(ctx, "/details/x/sub", nil)
+	if errs.IsNotImplement(err) {
+		t.Skip("the local driver can't report the disk usage on this platform")
+	}
+	if err != nil {
+		t.Fatalf("failed get details: %+v", err)
+	}
+	if details.TotalSpace <= 0 || details.FreeSpace() < 0 || details.FreeSpace() > details.TotalSpace {
+		t.Errorf("unexpected details of the storage: %+v", details)
+	}
+	// both storages are in the same temp dir, so the virtual folder has twice the space
+	sum, err := op.GetStorageDetailsByPath(ctx, "/details", nil)
+	if err != nil {
+		t.Fatalf("failed get details: %+v", err)
+	}
+	if sum.TotalSpace != 2*details.TotalSpace {
+		t.Errorf("expect total space %d of the virtual folder, got %d", 2*details.TotalSpace, sum.TotalSpace)
+	}
+	only, err := op.GetStorageDetailsByPath(ctx, "/details", func(mountPath string) bool { return mountPath == "/details/x" })
+	if err != nil || only.TotalSpace != details.TotalSpace {
+		t.Errorf("expect only the readable storage summed, got %+v, %+v", only, err)
+	}
+	if _, err = op.GetStorageDetailsByPath(ctx, "/details_none", nil); !errs.IsNotImplement(err) {
+		t.Errorf("expect not implement for the path without storages, got %+v", err)
+	}
+}
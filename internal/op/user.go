@@ -82,6 +82,25 @@ func DeleteUserById(id uint) error {
 		return errs.DeleteAdminOrGuest
 	}
 	userCache.Del(old.Username)
+	if err = db.DeleteSharesByUserId(id); err != nil {
+		return err
+	}
+	if err = ResetUserUsage(id); err != nil {
+		return err
+	}
+	if err = RevokeUserSessions(id); err != nil {
+		return err
+	}
+	if err = db.DeleteAPITokensByUserId(id); err != nil {
+		return err
+	}
+	if err = db.DeleteS3ByUserId(id); err != nil {
+		return err
+	}
+	if err = db.DeleteUserGroupsAndACLs(id); err != nil {
+		return err
+	}
+	clearACLState()
 	return db.DeleteUserById(id)
 }
 
@@ -98,7 +117,14 @@ func UpdateUser(u *model.User) error {
 	}
 	userCache.Del(old.Username)
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
-	return db.UpdateUser(u)
+	if err = db.UpdateUser(u); err != nil {
+		return err
+	}
+	// the sessions on the old password are no longer valid
+	if u.PwdTS != old.PwdTS {
+		return RevokeUserSessions(u.ID)
+	}
+	return nil
 }
 
 func Cancel2FAByUser(u *model.User) error {
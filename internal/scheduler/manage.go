@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/pkg/errors"
+)
+
+func parseCron(expr string) (*cron.Schedule, error) {
+	s, err := cron.Parse(expr)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid cron expression")
+	}
+	return s, nil
+}
+
+func GetJobs(pageIndex, pageSize int) ([]model.ScheduledJob, int64, error) {
+	jobs, total, err := db.GetScheduledJobs(pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range jobs {
+		jobs[i].NextRunTime = nextRunTime(jobs[i].ID)
+	}
+	return jobs, total, nil
+}
+
+func GetJobById(id uint) (*model.ScheduledJob, error) {
+	job, err := db.GetScheduledJobById(id)
+	if err != nil {
+		return nil, err
+	}
+	job.NextRunTime = nextRunTime(job.ID)
+	return job, nil
+}
+
+func CreateJob(job *model.ScheduledJob) error {
+	if err := validate(job); err != nil {
+		return err
+	}
+	if err := db.CreateScheduledJob(job); err != nil {
+		return err
+	}
+	update(job.ID, job)
+	return nil
+}
+
+// UpdateJob saves the settings of the job, the results of the last run are kept
+func UpdateJob(job *model.ScheduledJob) error {
+	if err := validate(job); err != nil {
+		return err
+	}
+	old, err := db.GetScheduledJobById(job.ID)
+	if err != nil {
+		return err
+	}
+	job.UserId = old.UserId
+	job.LastRunTime, job.LastSuccess, job.LastError = old.LastRunTime, old.LastSuccess, old.LastError
+	if err = db.UpdateScheduledJob(job); err != nil {
+		return err
+	}
+	update(job.ID, job)
+	return nil
+}
+
+func DeleteJobById(id uint) error {
+	if err := db.DeleteScheduledJobById(id); err != nil {
+		return err
+	}
+	update(id, nil)
+	return nil
+}
+
+// RunJob runs the job immediately, even if it is disabled
+func RunJob(id uint) (*model.ScheduledJobRun, error) {
+	job, err := db.GetScheduledJobById(id)
+	if err != nil {
+		return nil, err
+	}
+	return run(*job, true)
+}
+
+func GetRuns(jobId uint, pageIndex, pageSize int) ([]model.ScheduledJobRun, int64, error) {
+	return db.GetScheduledJobRuns(jobId, pageIndex, pageSize)
+}
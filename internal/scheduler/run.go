@@ -0,0 +1,175 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/pkg/errors"
+)
+
+func parseArgs(job model.ScheduledJob) (model.ScheduleArgs, error) {
+	var args model.ScheduleArgs
+	if job.Args != "" {
+		if err := utils.Json.UnmarshalFromString(job.Args, &args); err != nil {
+			return args, errors.WithMessage(err, "invalid args")
+		}
+	}
+	return args, nil
+}
+
+// validate checks the cron expression and the args of the job before it is saved
+func validate(job *model.ScheduledJob) error {
+	if _, err := parseCron(job.Cron); err != nil {
+		return err
+	}
+	args, err := parseArgs(*job)
+	if err != nil {
+		return err
+	}
+	switch job.Type {
+	case model.ScheduleCopy, model.ScheduleMove:
+		if args.SrcDir == "" || args.DstDir == "" || len(args.Names) == 0 {
+			return errors.New("src_dir, dst_dir and names are required")
+		}
+		if !args.ConflictPolicy.Valid() {
+			return errs.InvalidConflict
+		}
+	case model.ScheduleSync:
+		if args.SrcDir == "" || args.DstDir == "" {
+			return errors.New("src_dir and dst_dir are required")
+		}
+	case model.ScheduleIndex:
+		if len(args.Paths) == 0 {
+			return errors.New("paths are required")
+		}
+	case model.ScheduleOfflineDownload:
+		if len(args.Urls) == 0 || args.Path == "" || args.Tool == "" {
+			return errors.New("urls, path and tool are required")
+		}
+	default:
+		return errs.InvalidScheduleType
+	}
+	return nil
+}
+
+// runJob adds the tasks of the job as its user and returns the ids of the added tasks
+func runJob(ctx context.Context, job model.ScheduledJob) ([]string, error) {
+	args, err := parseArgs(job)
+	if err != nil {
+		return nil, err
+	}
+	user, err := op.GetUserById(job.UserId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get user of the job")
+	}
+	if user.Disabled {
+		return nil, errors.New("the user of the job is disabled")
+	}
+	ctx = context.WithValue(ctx, conf.UserKey, user)
+	var (
+		taskIds []string
+		errMsgs []string
+	)
+	add := func(t task.TaskExtensionInfo, err error) {
+		if t != nil {
+			taskIds = append(taskIds, t.GetID())
+		}
+		if err != nil {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+	switch job.Type {
+	case model.ScheduleCopy, model.ScheduleMove:
+		srcDir, err := user.JoinPath(args.SrcDir)
+		if err != nil {
+			return nil, err
+		}
+		dstDir, err := user.JoinPath(args.DstDir)
+		if err != nil {
+			return nil, err
+		}
+		if job.Type == model.ScheduleCopy && !common.HasPermission(user, model.ACLCopy, user.CanCopy(), srcDir, dstDir) ||
+			job.Type == model.ScheduleMove && !common.HasPermission(user, model.ACLMove, user.CanMove(), srcDir, dstDir) {
+			return nil, errs.PermissionDenied
+		}
+		if args.Verify {
+			ctx = context.WithValue(ctx, conf.VerifyTransferKey, struct{}{})
+		}
+		if args.ConflictPolicy != "" {
+			ctx = context.WithValue(ctx, conf.ConflictPolicyKey, args.ConflictPolicy)
+		}
+		for i, name := range args.Names {
+			if !args.Overwrite && args.ConflictPolicy == "" {
+				if res, _ := fs.Get(ctx, stdpath.Join(dstDir, name), &fs.GetArgs{NoLog: true}); res != nil {
+					errMsgs = append(errMsgs, fmt.Sprintf("file [%s] exists", name))
+					continue
+				}
+			}
+			lazyCache := len(args.Names) > i+1
+			if job.Type == model.ScheduleCopy {
+				add(fs.Copy(ctx, stdpath.Join(srcDir, name), dstDir, lazyCache))
+			} else {
+				add(fs.Move(ctx, stdpath.Join(srcDir, name), dstDir, lazyCache))
+			}
+		}
+	case model.ScheduleSync:
+		srcDir, err := user.JoinPath(args.SrcDir)
+		if err != nil {
+			return nil, err
+		}
+		dstDir, err := user.JoinPath(args.DstDir)
+		if err != nil {
+			return nil, err
+		}
+		if !common.HasPermission(user, model.ACLCopy, user.CanCopy(), srcDir, dstDir) ||
+			args.Delete && !common.HasPermission(user, model.ACLRemove, user.CanRemove(), dstDir) {
+			return nil, errs.PermissionDenied
+		}
+		add(fs.Sync(ctx, srcDir, dstDir, model.SyncArgs{Delete: args.Delete, Verify: args.Verify}))
+	case model.ScheduleIndex:
+		for _, p := range args.Paths {
+			reqPath, err := user.JoinPath(p)
+			if err == nil {
+				err = search.UpdateIndex(ctx, reqPath)
+			}
+			add(nil, err)
+		}
+	case model.ScheduleOfflineDownload:
+		reqPath, err := user.JoinPath(args.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !common.HasPermission(user, model.ACLOfflineDownload, user.CanAddOfflineDownloadTasks(), reqPath) {
+			return nil, errs.PermissionDenied
+		}
+		for _, url := range args.Urls {
+			if url = strings.TrimSpace(url); url == "" {
+				continue
+			}
+			add(tool.AddURL(ctx, &tool.AddURLArgs{
+				URL:          url,
+				DstDirPath:   reqPath,
+				Tool:         args.Tool,
+				DeletePolicy: tool.DeletePolicy(args.DeletePolicy),
+			}))
+		}
+	default:
+		return nil, errs.InvalidScheduleType
+	}
+	if len(errMsgs) > 0 {
+		return taskIds, errors.New(strings.Join(errMsgs, "; "))
+	}
+	return taskIds, nil
+}
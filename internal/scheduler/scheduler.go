@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+// the number of runs kept for every job
+const keepRuns = 50
+
+type entry struct {
+	job      model.ScheduledJob
+	schedule *cron.Schedule
+	next     time.Time
+}
+
+var (
+	mu      sync.Mutex
+	running bool
+	entries map[uint]*entry
+	// wakeup makes the loop recompute the next run after the jobs are changed
+	wakeup chan struct{}
+	done   chan struct{}
+	// ids of the jobs being run, a job is skipped if its previous run is not finished
+	runningJobs sync.Map
+)
+
+// Start loads the enabled jobs and runs the scheduling loop
+func Start() {
+	mu.Lock()
+	defer mu.Unlock()
+	if running {
+		return
+	}
+	entries = make(map[uint]*entry)
+	wakeup = make(chan struct{}, 1)
+	done = make(chan struct{})
+	running = true
+	jobs, err := db.GetEnabledScheduledJobs()
+	if err != nil {
+		log.Errorf("failed load scheduled jobs: %+v", err)
+	}
+	now := time.Now()
+	for _, job := range jobs {
+		setEntry(job, now)
+	}
+	go loop(wakeup, done)
+}
+
+// Stop stops the scheduling loop, running jobs are not waited
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+	if !running {
+		return
+	}
+	running = false
+	close(done)
+}
+
+// setEntry must be called with mu held
+func setEntry(job model.ScheduledJob, now time.Time) {
+	delete(entries, job.ID)
+	if job.Disabled {
+		return
+	}
+	s, err := cron.Parse(job.Cron)
+	if err != nil {
+		log.Errorf("invalid cron of scheduled job [%s]: %v", job.Name, err)
+		return
+	}
+	entries[job.ID] = &entry{job: job, schedule: s, next: s.Next(now)}
+}
+
+// update refreshes the job in the schedule after it is changed, a nil job removes it
+func update(id uint, job *model.ScheduledJob) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !running {
+		return
+	}
+	if job == nil {
+		delete(entries, id)
+	} else {
+		setEntry(*job, time.Now())
+	}
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// nextRunTime returns the next run time of the job, nil if it is not scheduled
+func nextRunTime(id uint) *time.Time {
+	mu.Lock()
+	defer mu.Unlock()
+	if e, ok := entries[id]; ok && running && !e.next.IsZero() {
+		next := e.next
+		return &next
+	}
+	return nil
+}
+
+func loop(wakeup <-chan struct{}, done <-chan struct{}) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-wakeup:
+		case now := <-timer.C:
+			mu.Lock()
+			for _, e := range entries {
+				if !e.next.IsZero() && !e.next.After(now) {
+					go run(e.job, false)
+					e.next = e.schedule.Next(now)
+				}
+			}
+			mu.Unlock()
+		}
+		mu.Lock()
+		var earliest time.Time
+		for _, e := range entries {
+			if !e.next.IsZero() && (earliest.IsZero() || e.next.Before(earliest)) {
+				earliest = e.next
+			}
+		}
+		mu.Unlock()
+		d := time.Hour
+		if !earliest.IsZero() {
+			d = time.Until(earliest)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(d)
+	}
+}
+
+// run adds the tasks of the job and records the run
+func run(job model.ScheduledJob, manual bool) (*model.ScheduledJobRun, error) {
+	if _, loaded := runningJobs.LoadOrStore(job.ID, struct{}{}); loaded {
+		log.Warnf("skip scheduled job [%s], its last run is not finished", job.Name)
+		return nil, errs.ScheduledJobRunning
+	}
+	defer runningJobs.Delete(job.ID)
+	r := &model.ScheduledJobRun{JobId: job.ID, Start: time.Now(), Manual: manual}
+	taskIds, err := runJob(context.Background(), job)
+	r.End = time.Now()
+	r.TaskIds = strings.Join(taskIds, ",")
+	r.Success = err == nil
+	if err != nil {
+		r.Error = err.Error()
+		log.Errorf("failed run scheduled job [%s]: %+v", job.Name, err)
+	}
+	if e := db.CreateScheduledJobRun(r, keepRuns); e != nil {
+		log.Errorf("failed save run of scheduled job [%s]: %+v", job.Name, e)
+	}
+	job.LastRunTime, job.LastSuccess, job.LastError = &r.Start, r.Success, r.Error
+	if e := db.UpdateScheduledJobResult(&job); e != nil {
+		log.Errorf("failed save result of scheduled job [%s]: %+v", job.Name, e)
+	}
+	return r, nil
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestValidate(t *testing.T) {
+	for _, job := range []model.ScheduledJob{
+		{Cron: "every day", Type: model.ScheduleIndex, Args: `{"paths":["/"]}`},
+		{Cron: "@daily", Type: "unknown"},
+		{Cron: "@daily", Type: model.ScheduleCopy, Args: `{"src_dir":"/a"}`},
+		{Cron: "@daily", Type: model.ScheduleIndex, Args: `{"paths":`},
+	} {
+		if err := CreateJob(&job); err == nil {
+			t.Errorf("expect error for %+v", job)
+		}
+	}
+}
+
+func TestScheduledCopy(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"src", "dst"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "src", "a.txt"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	admin := &model.User{Username: "scheduler-admin", Role: model.ADMIN, BasePath: "/", Permission: 0x30FF}
+	if err = db.CreateUser(admin); err != nil {
+		t.Fatal(err)
+	}
+
+	Start()
+	defer Stop()
+	job := &model.ScheduledJob{
+		Name:   "copy",
+		Cron:   "@every 1s",
+		Type:   model.ScheduleCopy,
+		Args:   `{"src_dir":"/local/src","dst_dir":"/local/dst","names":["a.txt"]}`,
+		UserId: admin.ID,
+	}
+	if err = CreateJob(job); err != nil {
+		t.Fatalf("failed create job: %+v", err)
+	}
+	if next := nextRunTime(job.ID); next == nil || time.Until(*next) > time.Second {
+		t.Errorf("unexpected next run time: %v", next)
+	}
+	dst := filepath.Join(root, "dst", "a.txt")
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if _, err = os.Stat(dst); err == nil {
+			break
+		}
+	}
+	if _, err = os.Stat(dst); err != nil {
+		t.Fatalf("expect a.txt copied by the job: %v", err)
+	}
+	// stop the schedule before running manually, the file exists now
+	job.Disabled = true
+	if err = UpdateJob(job); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if _, ok := runningJobs.Load(job.ID); !ok {
+			break
+		}
+	}
+	r, err := RunJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Manual || r.Success || r.Error != "file [a.txt] exists" {
+		t.Errorf("unexpected run: %+v", r)
+	}
+	runs, total, err := GetRuns(job.ID, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total < 2 || runs[0].ID != r.ID || !runs[total-1].Success {
+		t.Errorf("unexpected runs: %+v", runs)
+	}
+	got, err := GetJobById(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LastRunTime == nil || got.LastSuccess || got.NextRunTime != nil {
+		t.Errorf("unexpected job: %+v", got)
+	}
+}
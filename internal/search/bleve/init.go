@@ -25,6 +25,10 @@ func Init(indexPath *string) (bleve.Index, error) {
 		// TODO: appoint analyzer
 		nameFieldMapping := bleve.NewKeywordFieldMapping()
 		searchNodeMapping.AddFieldMappingsAt("name", nameFieldMapping)
+		searchNodeMapping.AddFieldMappingsAt("size", bleve.NewNumericFieldMapping())
+		searchNodeMapping.AddFieldMappingsAt("modified", bleve.NewDateTimeFieldMapping())
+		searchNodeMapping.AddFieldMappingsAt("ext", bleve.NewKeywordFieldMapping())
+		searchNodeMapping.AddFieldMappingsAt("mount_path", bleve.NewKeywordFieldMapping())
 		indexMapping.AddDocumentMapping("SearchNode", searchNodeMapping)
 		fileIndex, err = bleve.New(*indexPath, indexMapping)
 		if err != nil {
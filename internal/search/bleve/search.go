@@ -3,6 +3,7 @@ package bleve
 import (
 	"context"
 	"os"
+	"time"
 
 	query2 "github.com/blevesearch/bleve/v2/search/query"
 
@@ -27,17 +28,23 @@ func (b *Bleve) Config() searcher.Config {
 
 func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	var queries []query2.Query
-	query := bleve.NewMatchQuery(req.Keywords)
-	query.SetField("name")
-	queries = append(queries, query)
+	if req.Keywords != "" {
+		query := bleve.NewMatchQuery(req.Keywords)
+		query.SetField("name")
+		queries = append(queries, query)
+	} else {
+		queries = append(queries, bleve.NewMatchAllQuery())
+	}
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
 		isDirQuery := bleve.NewBoolFieldQuery(isDir)
+		isDirQuery.SetField("is_dir")
 		queries = append(queries, isDirQuery)
 	}
+	queries = append(queries, filterQueries(req)...)
 	reqQuery := bleve.NewConjunctionQuery(queries...)
 	search := bleve.NewSearchRequest(reqQuery)
-	search.SortBy([]string{"name"})
+	search.SortBy(sortOrder(req))
 	search.From = (req.Page - 1) * req.PerPage
 	search.Size = req.PerPage
 	search.Fields = []string{"*"}
@@ -47,16 +54,87 @@ func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.Search
 		return nil, 0, err
 	}
 	res, err := utils.SliceConvert(searchResults.Hits, func(src *search2.DocumentMatch) (model.SearchNode, error) {
-		return model.SearchNode{
+		node := model.SearchNode{
 			Parent: src.Fields["parent"].(string),
 			Name:   src.Fields["name"].(string),
 			IsDir:  src.Fields["is_dir"].(bool),
 			Size:   int64(src.Fields["size"].(float64)),
-		}, nil
+		}
+		// fields below are missing in documents indexed by older versions
+		if modified, ok := src.Fields["modified"].(string); ok {
+			node.Modified, _ = time.Parse(time.RFC3339, modified)
+		}
+		node.Ext, _ = src.Fields["ext"].(string)
+		node.MountPath, _ = src.Fields["mount_path"].(string)
+		return node, nil
 	})
 	return res, int64(searchResults.Total), nil
 }
 
+func filterQueries(req model.SearchReq) []query2.Query {
+	var queries []query2.Query
+	if req.MinSize > 0 || req.MaxSize > 0 {
+		var min, max *float64
+		if req.MinSize > 0 {
+			v := float64(req.MinSize)
+			min = &v
+		}
+		if req.MaxSize > 0 {
+			v := float64(req.MaxSize)
+			max = &v
+		}
+		inclusive := true
+		query := bleve.NewNumericRangeInclusiveQuery(min, max, &inclusive, &inclusive)
+		query.SetField("size")
+		queries = append(queries, query)
+	}
+	if req.ModifiedAfter != nil || req.ModifiedBefore != nil {
+		var start, end time.Time
+		if req.ModifiedAfter != nil {
+			start = *req.ModifiedAfter
+		}
+		if req.ModifiedBefore != nil {
+			end = *req.ModifiedBefore
+		}
+		inclusive := true
+		query := bleve.NewDateRangeInclusiveQuery(start, end, &inclusive, &inclusive)
+		query.SetField("modified")
+		queries = append(queries, query)
+	}
+	if len(req.Exts) > 0 {
+		var extQueries []query2.Query
+		for _, ext := range req.Exts {
+			query := bleve.NewTermQuery(ext)
+			query.SetField("ext")
+			extQueries = append(extQueries, query)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(extQueries...))
+	}
+	if req.Pattern != "" {
+		if req.PatternType == model.PatternRegex {
+			query := bleve.NewRegexpQuery(req.Pattern)
+			query.SetField("name")
+			queries = append(queries, query)
+		} else {
+			query := bleve.NewWildcardQuery(req.Pattern)
+			query.SetField("name")
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
+
+func sortOrder(req model.SearchReq) []string {
+	field := req.OrderBy
+	if req.OrderDirection == "desc" {
+		field = "-" + field
+	}
+	if req.OrderBy == "name" {
+		return []string{field}
+	}
+	return []string{field, "name"}
+}
+
 func (b *Bleve) Index(ctx context.Context, node model.SearchNode) error {
 	return b.BIndex.Index(uuid.NewString(), node)
 }
@@ -0,0 +1,381 @@
+package search
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// indexMu serializes changes of a directory between object change hooks and UpdateStorageIndex
+	indexMu sync.Mutex
+	// mount paths of storages being updated by UpdateStorageIndex
+	updating sync.Map
+
+	objChanges     = make(chan objChange, 1024)
+	objChangesOnce sync.Once
+	// parent dirs of the changes that didn't fit in the queue, rescanned once it's drained
+	dirtyMu     sync.Mutex
+	dirtyDirs   = make(map[string]dirtyDir)
+	dirtySignal = make(chan struct{}, 1)
+
+	schedulesMu sync.Mutex
+	schedules   = make(map[uint]*cron.Cron)
+)
+
+// enqueueTimeout is how long a change waits for the queue before its dirs are marked dirty
+const enqueueTimeout = 5 * time.Second
+
+type dirtyDir struct {
+	storage driver.Driver
+	dir     string
+}
+
+type objChange struct {
+	typ     string
+	storage driver.Driver
+	srcPath string
+	dstPath string
+	obj     model.Obj
+}
+
+// canUpdateIncrementally reports whether index entries can be changed without a full rebuild
+func canUpdateIncrementally() bool {
+	return instance != nil && instance.Config().AutoUpdate && !Running()
+}
+
+func isUpdating(path string) bool {
+	res := false
+	updating.Range(func(key, _ any) bool {
+		if utils.IsSubPath(key.(string), path) {
+			res = true
+			return false
+		}
+		return true
+	})
+	return res
+}
+
+// maxStorageDepth returns the max depth below the mount path to index for the storage
+func maxStorageDepth(storage driver.Driver) int {
+	if depth := storage.GetStorage().IndexDepth; depth > 0 {
+		return depth
+	}
+	return setting.GetInt(conf.MaxIndexDepth, 20)
+}
+
+func pathDepth(actualPath string) int {
+	if actualPath == "/" {
+		return 0
+	}
+	return strings.Count(actualPath, "/")
+}
+
+func skipIndex(storage driver.Driver, actualPath string) bool {
+	return storage.GetStorage().DisableIndex || op.IsInRecycleBin(actualPath) ||
+		isIgnorePath(path.Join(utils.GetActualMountPath(storage.GetStorage().MountPath), actualPath))
+}
+
+func onObjChange(typ string, storage driver.Driver, srcPath, dstPath string, obj model.Obj) {
+	if !canUpdateIncrementally() {
+		return
+	}
+	objChangesOnce.Do(func() {
+		go handleObjChanges(context.Background())
+	})
+	c := objChange{typ: typ, storage: storage, srcPath: srcPath, dstPath: dstPath, obj: obj}
+	select {
+	case objChanges <- c:
+		return
+	default:
+	}
+	timer := time.NewTimer(enqueueTimeout)
+	defer timer.Stop()
+	select {
+	case objChanges <- c:
+	case <-timer.C:
+		log.Warnf("search index change queue is full, rescan the dirs of %s of [%s%s] later", typ, srcPath, dstPath)
+		markDirty(storage, srcPath, dstPath)
+	}
+}
+
+func handleObjChanges(ctx context.Context) {
+	for {
+		select {
+		case c := <-objChanges:
+			handleObjChange(ctx, c)
+		case <-dirtySignal:
+			// the queued changes are older than the rescans, apply them first
+			if len(objChanges) > 0 {
+				signalDirty()
+				continue
+			}
+			rescanDirtyDirs(ctx)
+		}
+	}
+}
+
+// markDirty marks the parent dirs of the changed paths to be rescanned
+func markDirty(storage driver.Driver, paths ...string) {
+	dirtyMu.Lock()
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		dir := path.Dir(p)
+		dirtyDirs[storage.GetStorage().MountPath+"|"+dir] = dirtyDir{storage: storage, dir: dir}
+	}
+	dirtyMu.Unlock()
+	signalDirty()
+}
+
+func signalDirty() {
+	select {
+	case dirtySignal <- struct{}{}:
+	default:
+	}
+}
+
+func rescanDirtyDirs(ctx context.Context) {
+	dirtyMu.Lock()
+	dirs := dirtyDirs
+	dirtyDirs = make(map[string]dirtyDir)
+	dirtyMu.Unlock()
+	for _, d := range dirs {
+		if !canUpdateIncrementally() || skipIndex(d.storage, d.dir) {
+			continue
+		}
+		if _, _, err := updateDirIndex(ctx, d.storage, d.dir, maxStorageDepth(d.storage)-pathDepth(d.dir)); err != nil {
+			log.Errorf("failed rescan search index of [%s%s]: %+v", d.storage.GetStorage().MountPath, d.dir, err)
+		}
+	}
+}
+
+func handleObjChange(ctx context.Context, c objChange) {
+	var err error
+	switch c.typ {
+	case "del":
+		err = delIndex(ctx, c.storage, c.srcPath)
+	case "move":
+		if err = delIndex(ctx, c.storage, c.srcPath); err == nil {
+			err = addIndex(ctx, c.storage, c.dstPath, c.obj)
+		}
+	case "add":
+		err = addIndex(ctx, c.storage, c.dstPath, c.obj)
+	}
+	if err != nil {
+		log.Errorf("failed update search index for %s of [%s%s]: %+v", c.typ, c.srcPath, c.dstPath, err)
+	}
+}
+
+func delIndex(ctx context.Context, storage driver.Driver, actualPath string) error {
+	if skipIndex(storage, actualPath) {
+		return nil
+	}
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	return instance.Del(ctx, path.Join(utils.GetActualMountPath(storage.GetStorage().MountPath), actualPath))
+}
+
+func addIndex(ctx context.Context, storage driver.Driver, actualPath string, obj model.Obj) error {
+	if skipIndex(storage, actualPath) || pathDepth(actualPath) > maxStorageDepth(storage) {
+		return nil
+	}
+	fullPath := path.Join(utils.GetActualMountPath(storage.GetStorage().MountPath), actualPath)
+	parent, name := path.Split(fullPath)
+	node := newSearchNode(path.Clean(parent), obj)
+	node.Name = name
+	indexMu.Lock()
+	// delete first, the searcher may keep duplicate nodes of the same path
+	err := instance.Del(ctx, fullPath)
+	if err == nil {
+		err = instance.Index(ctx, node)
+	}
+	indexMu.Unlock()
+	if err != nil || !obj.IsDir() {
+		return err
+	}
+	_, _, err = updateDirIndex(ctx, storage, actualPath, maxStorageDepth(storage)-pathDepth(actualPath))
+	return err
+}
+
+// nodeChanged reports whether the index node is outdated, folders are only compared by existence
+// because deleting a folder node deletes all its descendants
+func nodeChanged(node model.SearchNode, obj model.Obj) bool {
+	if node.IsDir != obj.IsDir() {
+		return true
+	}
+	if node.IsDir {
+		return false
+	}
+	diff := node.Modified.Sub(obj.ModTime())
+	return node.Size != obj.GetSize() || diff > time.Second || diff < -time.Second
+}
+
+// updateDirIndex compares the listing of the directory with its index nodes and applies the diffs,
+// descending at most depth levels.
+func updateDirIndex(ctx context.Context, storage driver.Driver, actualDir string, depth int) (added, removed int, err error) {
+	if depth < 1 || utils.IsCanceled(ctx) {
+		return 0, 0, nil
+	}
+	objs, err := op.List(ctx, storage, actualDir, model.ListArgs{Refresh: true})
+	if err != nil {
+		return 0, 0, err
+	}
+	dir := path.Join(utils.GetActualMountPath(storage.GetStorage().MountPath), actualDir)
+	indexMu.Lock()
+	nodes, err := instance.Get(ctx, dir)
+	if err != nil {
+		indexMu.Unlock()
+		return 0, 0, err
+	}
+	old := make(map[string]model.SearchNode, len(nodes))
+	for _, node := range nodes {
+		old[node.Name] = node
+	}
+	var (
+		toIndex []model.SearchNode
+		subDirs []string
+	)
+	for _, obj := range objs {
+		actualPath := path.Join(actualDir, obj.GetName())
+		if skipIndex(storage, actualPath) {
+			continue
+		}
+		node, ok := old[obj.GetName()]
+		delete(old, obj.GetName())
+		if !ok || nodeChanged(node, obj) {
+			if ok {
+				if err = instance.Del(ctx, path.Join(dir, obj.GetName())); err != nil {
+					indexMu.Unlock()
+					return added, removed, err
+				}
+				removed++
+			}
+			toIndex = append(toIndex, newSearchNode(dir, obj))
+		}
+		if obj.IsDir() {
+			subDirs = append(subDirs, actualPath)
+		}
+	}
+	for name := range old {
+		nodePath := path.Join(dir, name)
+		if op.HasStorage(nodePath) {
+			continue
+		}
+		if err = instance.Del(ctx, nodePath); err != nil {
+			indexMu.Unlock()
+			return added, removed, err
+		}
+		removed++
+	}
+	if len(toIndex) > 0 {
+		err = instance.BatchIndex(ctx, toIndex)
+	}
+	indexMu.Unlock()
+	if err != nil {
+		return added, removed, err
+	}
+	added += len(toIndex)
+	for _, subDir := range subDirs {
+		a, r, err := updateDirIndex(ctx, storage, subDir, depth-1)
+		added, removed = added+a, removed+r
+		if err != nil {
+			return added, removed, err
+		}
+	}
+	return added, removed, nil
+}
+
+// UpdateStorageIndex updates the index of the storage incrementally by comparing
+// directory listings with the index nodes.
+func UpdateStorageIndex(ctx context.Context, storage driver.Driver) error {
+	if instance == nil {
+		return errs.SearchNotAvailable
+	}
+	if !instance.Config().AutoUpdate {
+		return errs.NotSupport
+	}
+	if Running() {
+		return errs.BuildIndexIsRunning
+	}
+	if skipIndex(storage, "/") {
+		return nil
+	}
+	mountPath := utils.GetActualMountPath(storage.GetStorage().MountPath)
+	if _, loaded := updating.LoadOrStore(mountPath, struct{}{}); loaded {
+		return errs.UpdateIndexRunning
+	}
+	defer updating.Delete(mountPath)
+	start := time.Now()
+	added, removed, err := updateDirIndex(ctx, storage, "/", maxStorageDepth(storage))
+	log.Infof("update index of [%s] in %s, added: %d, removed: %d", mountPath, time.Since(start), added, removed)
+	return err
+}
+
+// UpdateIndex updates the index of the path and its descendants incrementally
+func UpdateIndex(ctx context.Context, path string) error {
+	if instance == nil {
+		return errs.SearchNotAvailable
+	}
+	if !instance.Config().AutoUpdate {
+		return errs.NotSupport
+	}
+	if Running() {
+		return errs.BuildIndexIsRunning
+	}
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return err
+	}
+	if skipIndex(storage, actualPath) {
+		return nil
+	}
+	path = utils.FixAndCleanPath(path)
+	if _, loaded := updating.LoadOrStore(path, struct{}{}); loaded {
+		return errs.UpdateIndexRunning
+	}
+	defer updating.Delete(path)
+	start := time.Now()
+	added, removed, err := updateDirIndex(ctx, storage, actualPath, maxStorageDepth(storage)-pathDepth(actualPath))
+	log.Infof("update index of [%s] in %s, added: %d, removed: %d", path, time.Since(start), added, removed)
+	return err
+}
+
+func scheduleStorageIndex(typ string, storage driver.Driver) {
+	s := storage.GetStorage()
+	schedulesMu.Lock()
+	defer schedulesMu.Unlock()
+	if c, ok := schedules[s.ID]; ok {
+		c.Stop()
+		delete(schedules, s.ID)
+	}
+	if typ == "del" || s.Disabled || s.DisableIndex || s.IndexInterval <= 0 {
+		return
+	}
+	c := cron.NewCron(time.Duration(s.IndexInterval) * time.Minute)
+	c.Do(func() {
+		err := UpdateStorageIndex(context.Background(), storage)
+		if err != nil && !errs.IsNotSupportError(err) {
+			log.Errorf("failed update index of [%s]: %+v", s.MountPath, err)
+		}
+	})
+	schedules[s.ID] = c
+}
+
+func init() {
+	op.RegisterObjChangeHook(onObjChange)
+	op.RegisterStorageHook(scheduleStorageIndex)
+}
@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func indexedNames(t *testing.T, parent string) map[string]model.SearchNode {
+	nodes, err := instance.Get(context.Background(), parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := make(map[string]model.SearchNode)
+	for _, node := range nodes {
+		res[node.Name] = node
+	}
+	return res
+}
+
+func TestUpdateStorageIndex(t *testing.T) {
+	if err := Init("database_non_full_text"); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "dir/b.mp4"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = UpdateStorageIndex(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+	nodes := indexedNames(t, "/local/dir")
+	if node, ok := nodes["b.mp4"]; !ok || node.Ext != "mp4" || node.MountPath != "/local" {
+		t.Fatalf("expect b.mp4 indexed, got %+v", nodes)
+	}
+
+	if err = os.Remove(filepath.Join(root, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(root, "dir", "b.mp4"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = UpdateStorageIndex(context.Background(), storage); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := indexedNames(t, "/local")["a.txt"]; ok {
+		t.Errorf("expect a.txt removed from index")
+	}
+	if nodes = indexedNames(t, "/local/dir"); len(nodes) != 1 || nodes["b.mp4"].Size != 7 {
+		t.Errorf("expect b.mp4 updated, got %+v", nodes)
+	}
+
+	// object changes are applied by the hooks without a full update
+	if err = op.Rename(context.Background(), storage, "/dir", "moved"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := indexedNames(t, "/local/moved")["b.mp4"]; ok {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if _, ok := indexedNames(t, "/local/moved")["b.mp4"]; !ok {
+		t.Errorf("expect b.mp4 indexed under the renamed folder")
+	}
+	if nodes = indexedNames(t, "/local/dir"); len(nodes) != 0 {
+		t.Errorf("expect old folder removed from index, got %+v", nodes)
+	}
+}
@@ -30,8 +30,10 @@ func init() {
 			),
 			IndexUid: indexUid,
 			FilterableAttributes: []string{"parent", "is_dir", "name",
-				"parent_hash", "parent_path_hashes"},
+				"parent_hash", "parent_path_hashes",
+				"size", "modified_time", "ext", "mount_path"},
 			SearchableAttributes: []string{"name"},
+			SortableAttributes:   []string{"name", "size", "modified_time"},
 		}
 
 		_, err := m.Client.GetIndex(m.IndexUid)
@@ -79,6 +81,17 @@ func init() {
 			}
 		}
 
+		attributes, err = m.Client.Index(m.IndexUid).GetSortableAttributes()
+		if err != nil {
+			return nil, err
+		}
+		if attributes == nil || !utils.SliceAllContains(*attributes, m.SortableAttributes...) {
+			_, err = m.Client.Index(m.IndexUid).UpdateSortableAttributes(&m.SortableAttributes)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		pagination, err := m.Client.Index(m.IndexUid).GetPagination()
 		if err != nil {
 			return nil, err
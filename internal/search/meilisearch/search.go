@@ -7,10 +7,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/meilisearch/meilisearch-go"
+	"github.com/pkg/errors"
 )
 
 type searchDocument struct {
@@ -25,6 +27,8 @@ type searchDocument struct {
 	// Can be used for filtering all descendants exactly.
 	// Storing path hashes instead of plaintext paths benefits disk usage and case-sensitive filter.
 	ParentPathHashes []string `json:"parent_path_hashes"`
+	// Unix time of modified, meilisearch only filters and sorts on numbers.
+	ModifiedTime int64 `json:"modified_time"`
 	model.SearchNode
 }
 
@@ -33,6 +37,7 @@ type Meilisearch struct {
 	IndexUid             string
 	FilterableAttributes []string
 	SearchableAttributes []string
+	SortableAttributes   []string
 }
 
 func (m *Meilisearch) Config() searcher.Config {
@@ -40,10 +45,14 @@ func (m *Meilisearch) Config() searcher.Config {
 }
 
 func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	if req.Pattern != "" {
+		return nil, 0, errors.Wrap(errs.NotSupport, "name pattern is not supported by meilisearch")
+	}
 	mReq := &meilisearch.SearchRequest{
 		AttributesToSearchOn: m.SearchableAttributes,
 		Page:                 int64(req.Page),
 		HitsPerPage:          int64(req.PerPage),
+		Sort:                 sortOrder(req),
 	}
 	var filters []string
 	if req.Scope != 0 {
@@ -54,6 +63,24 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 		parentHash := hashPath(req.Parent)
 		filters = append(filters, fmt.Sprintf("parent_path_hashes = '%s'", parentHash))
 	}
+	if req.MinSize > 0 {
+		filters = append(filters, fmt.Sprintf("size >= %d", req.MinSize))
+	}
+	if req.MaxSize > 0 {
+		filters = append(filters, fmt.Sprintf("size <= %d", req.MaxSize))
+	}
+	if req.ModifiedAfter != nil {
+		filters = append(filters, fmt.Sprintf("modified_time >= %d", req.ModifiedAfter.Unix()))
+	}
+	if req.ModifiedBefore != nil {
+		filters = append(filters, fmt.Sprintf("modified_time <= %d", req.ModifiedBefore.Unix()))
+	}
+	if len(req.Exts) > 0 {
+		exts, _ := utils.SliceConvert(req.Exts, func(ext string) (string, error) {
+			return "'" + strings.ReplaceAll(ext, "'", "\\'") + "'", nil
+		})
+		filters = append(filters, fmt.Sprintf("ext IN [%s]", strings.Join(exts, ", ")))
+	}
 	if len(filters) > 0 {
 		mReq.Filter = strings.Join(filters, " AND ")
 	}
@@ -64,12 +91,19 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 	}
 	nodes, err := utils.SliceConvert(search.Hits, func(src any) (model.SearchNode, error) {
 		srcMap := src.(map[string]any)
-		return model.SearchNode{
+		node := model.SearchNode{
 			Parent: srcMap["parent"].(string),
 			Name:   srcMap["name"].(string),
 			IsDir:  srcMap["is_dir"].(bool),
 			Size:   int64(srcMap["size"].(float64)),
-		}, nil
+		}
+		// fields below are missing in documents indexed by older versions
+		if modified, ok := srcMap["modified"].(string); ok {
+			node.Modified, _ = time.Parse(time.RFC3339, modified)
+		}
+		node.Ext, _ = srcMap["ext"].(string)
+		node.MountPath, _ = srcMap["mount_path"].(string)
+		return node, nil
 	})
 	if err != nil {
 		return nil, 0, err
@@ -95,6 +129,7 @@ func (m *Meilisearch) BatchIndex(ctx context.Context, nodes []model.SearchNode)
 			ID:               nodePathHash,
 			ParentHash:       parentHash,
 			ParentPathHashes: parentPathHashes,
+			ModifiedTime:     src.Modified.Unix(),
 			SearchNode:       src,
 		}, nil
 	})
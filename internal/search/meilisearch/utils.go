@@ -1,6 +1,8 @@
 package meilisearch
 
 import (
+	"time"
+
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 )
@@ -14,18 +16,40 @@ func hashPath(path string) string {
 
 func buildSearchDocumentFromResults(results map[string]any) *searchDocument {
 	searchNode := model.SearchNode{}
-	document := &searchDocument{
-		SearchNode: searchNode,
-	}
+	document := &searchDocument{}
 
 	// use assertion test to avoid panic
 	searchNode.Parent, _ = results["parent"].(string)
 	searchNode.Name, _ = results["name"].(string)
 	searchNode.IsDir, _ = results["is_dir"].(bool)
-	searchNode.Size, _ = results["size"].(int64)
+	if size, ok := results["size"].(float64); ok {
+		searchNode.Size = int64(size)
+	}
+	if modified, ok := results["modified"].(string); ok {
+		searchNode.Modified, _ = time.Parse(time.RFC3339, modified)
+	}
+	searchNode.Ext, _ = results["ext"].(string)
+	searchNode.MountPath, _ = results["mount_path"].(string)
 
 	document.ID, _ = results["id"].(string)
 	document.ParentHash, _ = results["parent_hash"].(string)
 	document.ParentPathHashes, _ = results["parent_path_hashes"].([]string)
+	if modifiedTime, ok := results["modified_time"].(float64); ok {
+		document.ModifiedTime = int64(modifiedTime)
+	}
+	document.SearchNode = searchNode
 	return document
 }
+
+// sortOrder maps the order of the request to meilisearch sort rules.
+func sortOrder(req model.SearchReq) []string {
+	field := req.OrderBy
+	if field == "modified" {
+		field = "modified_time"
+	}
+	sort := []string{field + ":" + req.OrderDirection}
+	if req.OrderBy != "name" {
+		sort = append(sort, "name:asc")
+	}
+	return sort
+}
@@ -3,12 +3,14 @@ package search
 import (
 	"context"
 	"fmt"
+	stdpath "path"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -55,12 +57,24 @@ func Index(ctx context.Context, parent string, obj model.Obj) error {
 	if instance == nil {
 		return errs.SearchNotAvailable
 	}
-	return instance.Index(ctx, model.SearchNode{
-		Parent: parent,
-		Name:   obj.GetName(),
-		IsDir:  obj.IsDir(),
-		Size:   obj.GetSize(),
-	})
+	return instance.Index(ctx, newSearchNode(parent, obj))
+}
+
+func newSearchNode(parent string, obj model.Obj) model.SearchNode {
+	node := model.SearchNode{
+		Parent:   parent,
+		Name:     obj.GetName(),
+		IsDir:    obj.IsDir(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+	}
+	if !node.IsDir {
+		node.Ext = model.NormalizeExt(stdpath.Ext(node.Name))
+	}
+	if storage, _, err := op.GetStorageAndActualPath(parent); err == nil {
+		node.MountPath = utils.GetActualMountPath(storage.GetStorage().MountPath)
+	}
+	return node
 }
 
 type ObjWithParent struct {
@@ -77,12 +91,7 @@ func BatchIndex(ctx context.Context, objs []ObjWithParent) error {
 	}
 	var searchNodes []model.SearchNode
 	for i := range objs {
-		searchNodes = append(searchNodes, model.SearchNode{
-			Parent: objs[i].Parent,
-			Name:   objs[i].GetName(),
-			IsDir:  objs[i].IsDir(),
-			Size:   objs[i].GetSize(),
-		})
+		searchNodes = append(searchNodes, newSearchNode(objs[i].Parent, objs[i].Obj))
 	}
 	return instance.BatchIndex(ctx, searchNodes)
 }
@@ -0,0 +1,19 @@
+package sign
+
+import (
+	"fmt"
+	"strings"
+)
+
+// packageData binds a package download to the dir, the selected names and the user creating it
+func packageData(dir string, names []string, userId uint) string {
+	return fmt.Sprintf("package:%s\n%s\n%d", dir, strings.Join(names, "\n"), userId)
+}
+
+func SignPackage(dir string, names []string, userId uint) string {
+	return Sign(packageData(dir, names, userId))
+}
+
+func VerifyPackage(dir string, names []string, userId uint, sign string) error {
+	return Verify(packageData(dir, names, userId), sign)
+}
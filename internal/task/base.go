@@ -16,6 +16,13 @@ type TaskExtension struct {
 	endTime    *time.Time
 	totalBytes int64
 	ApiUrl     string
+	// the waiting task with a higher priority runs first
+	Priority int `json:"priority"`
+	// a paused task is canceled and kept until it is resumed
+	Paused bool `json:"paused"`
+	// a waiting task is kept in the queue of its manager until a worker is free
+	Waiting bool `json:"waiting"`
+	queue   scheduler
 }
 
 func (t *TaskExtension) SetCtx(ctx context.Context) {
@@ -65,10 +72,20 @@ func (t *TaskExtension) GetTotalBytes() int64 {
 	return t.totalBytes
 }
 
+func (t *TaskExtension) GetPriority() int {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	return t.Priority
+}
+
+func (t *TaskExtension) IsPaused() bool {
+	return t.Paused
+}
+
 func (t *TaskExtension) ReinitCtx() error {
 	select {
 	case <-t.Ctx().Done():
-		if !conf.Conf.Tasks.AllowRetryCanceled {
+		if t.Paused || !conf.Conf.Tasks.AllowRetryCanceled {
 			return t.Ctx().Err()
 		}
 		ctx, cancel := context.WithCancel(context.Background())
@@ -85,4 +102,6 @@ type TaskExtensionInfo interface {
 	GetStartTime() *time.Time
 	GetEndTime() *time.Time
 	GetTotalBytes() int64
+	GetPriority() int
+	IsPaused() bool
 }
@@ -0,0 +1,65 @@
+package task
+
+import (
+	"sync"
+
+	"github.com/OpenListTeam/tache"
+)
+
+// StateHook is called after the state of a task changes, typ is the type its manager is registered with
+type StateHook func(typ string, t TaskExtensionInfo, state tache.State)
+
+type extensionTask interface {
+	TaskExtensionInfo
+	extension() *TaskExtension
+}
+
+var (
+	hooksMu    sync.RWMutex
+	stateHooks []StateHook
+	managers   = map[string]func(id string) (extensionTask, bool){}
+)
+
+func RegisterStateHook(hook StateHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	stateHooks = append(stateHooks, hook)
+}
+
+// registerManager makes the state changes of the tasks in m visible to the state hooks
+func registerManager[T extensionTask](typ string, m *tache.Manager[T]) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	managers[typ] = func(id string) (extensionTask, bool) {
+		return m.GetByID(id)
+	}
+}
+
+func (t *TaskExtension) extension() *TaskExtension {
+	return t
+}
+
+func (t *TaskExtension) SetState(state tache.State) {
+	t.Base.SetState(state)
+	switch state {
+	case tache.StateSucceeded, tache.StateCanceled, tache.StateFailed:
+		// a worker is free for the waiting tasks
+		if q := t.scheduler(); q != nil {
+			q.admit()
+		}
+	}
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	if len(stateHooks) == 0 {
+		return
+	}
+	// tasks that are not added to a registered manager yet are ignored
+	for typ, get := range managers {
+		if tsk, ok := get(t.GetID()); ok && tsk.extension() == t {
+			for _, hook := range stateHooks {
+				hook(typ, tsk, state)
+			}
+			return
+		}
+	}
+}
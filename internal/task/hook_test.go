@@ -0,0 +1,51 @@
+package task
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/OpenListTeam/tache"
+)
+
+type testTask struct {
+	TaskExtension
+	err error
+}
+
+func (t *testTask) GetName() string   { return "test" }
+func (t *testTask) GetStatus() string { return "" }
+func (t *testTask) Run() error        { return t.err }
+
+func TestStateHook(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		states = map[string][]tache.State{}
+		done   sync.WaitGroup
+	)
+	done.Add(2)
+	RegisterStateHook(func(typ string, tsk TaskExtensionInfo, state tache.State) {
+		if typ != "test" {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		states[tsk.GetID()] = append(states[tsk.GetID()], state)
+		if state == tache.StateSucceeded || state == tache.StateFailed {
+			done.Done()
+		}
+	})
+	m := NewPriorityManager[*testTask]("test", 1)
+	ok, failed := &testTask{}, &testTask{err: errors.New("failed")}
+	m.Add(ok)
+	m.Add(failed)
+	done.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	if s := states[ok.GetID()]; len(s) != 2 || s[0] != tache.StateRunning || s[1] != tache.StateSucceeded {
+		t.Errorf("unexpected states of succeeded task: %v", s)
+	}
+	if s := states[failed.GetID()]; len(s) != 3 || s[1] != tache.StateErrored || s[2] != tache.StateFailed {
+		t.Errorf("unexpected states of failed task: %v", s)
+	}
+}
@@ -0,0 +1,53 @@
+package task
+
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/tache"
+	"github.com/pkg/errors"
+)
+
+// Pause stops the task and keeps it until it is resumed. The task ends as canceled
+// or failed. The offline downloads fetched by SimpleHttp continue from the byte they
+// stopped at if the server supports ranges, and so do the copy and move tasks if
+// conf.Conf.Tasks.ResumableTransfer is on. The upload, decompress and other tasks
+// start the file they were working on over again, since the drivers can't continue
+// an upload; the files a transfer task has already handed to its sub tasks aren't
+// transferred again.
+func Pause(t TaskExtensionInfo) error {
+	ext, ok := t.(extensionTask)
+	if !ok {
+		return errors.Errorf("task %T can't be paused", t)
+	}
+	switch t.GetState() {
+	case tache.StateSucceeded, tache.StateCanceling, tache.StateCanceled, tache.StateFailing, tache.StateFailed:
+		return errors.WithStack(errs.TaskNotPausable)
+	}
+	e := ext.extension()
+	e.Paused = true
+	e.Persist()
+	// the Cancel of the task may clean up the data to resume with
+	e.Cancel()
+	return nil
+}
+
+// Resume retries the paused task with a new ctx after it stopped
+func Resume[T tache.Task](m Manager[T], t TaskExtensionInfo) error {
+	ext, ok := t.(extensionTask)
+	if !ok || !t.IsPaused() {
+		return errors.WithStack(errs.TaskNotPaused)
+	}
+	switch t.GetState() {
+	case tache.StateCanceled, tache.StateFailed, tache.StateErrored:
+	default:
+		return errors.WithStack(errs.TaskNotPaused)
+	}
+	e := ext.extension()
+	e.Paused = false
+	ctx, cancel := context.WithCancel(context.Background())
+	t.SetCtx(ctx)
+	t.SetCancelFunc(cancel)
+	m.Retry(t.GetID())
+	return nil
+}
@@ -0,0 +1,214 @@
+package task
+
+import (
+	"context"
+	"sync"
+
+	"github.com/OpenListTeam/tache"
+)
+
+// PriorityManager runs the tasks of a tache.Manager by their priority. tache runs the
+// queued tasks in FIFO order, so the added tasks wait here and are only handed to
+// tache when one of its workers is free, the ones with a higher priority first.
+type PriorityManager[T extensionTask] struct {
+	*tache.Manager[T]
+	workers int
+	seq     uint64
+	waiting []*waiter[T]
+}
+
+type waiter[T extensionTask] struct {
+	t     T
+	seq   uint64
+	retry bool
+}
+
+// scheduler is the queue of the manager a task is added to
+type scheduler interface {
+	admit()
+	dequeue(t *TaskExtension) bool
+}
+
+var queueMu sync.Mutex
+
+// NewPriorityManager creates the manager of the tasks of the type, at most workers of them run at the same time
+func NewPriorityManager[T extensionTask](typ string, workers int, opts ...tache.Option) *PriorityManager[T] {
+	m := &PriorityManager[T]{
+		Manager: tache.NewManager[T](append(opts, tache.WithWorks(workers))...),
+		workers: max(workers, 0),
+	}
+	registerManager(typ, m.Manager)
+	// the tasks waiting before the restart are recovered by tache as canceled, they wait again
+	queueMu.Lock()
+	for _, t := range m.Manager.GetAll() {
+		e := t.extension()
+		e.queue = m
+		if e.Waiting && e.Base.GetState() == tache.StateCanceled {
+			m.seq++
+			m.waiting = append(m.waiting, &waiter[T]{t: t, seq: m.seq})
+		} else {
+			e.Waiting = false
+		}
+	}
+	queueMu.Unlock()
+	m.admit()
+	return m
+}
+
+// Add stores the task and queues it until a worker is free
+func (m *PriorityManager[T]) Add(t T) {
+	e := t.extension()
+	if e.Base.GetState() != tache.StatePending {
+		m.Manager.Add(t)
+		return
+	}
+	// tache stores the canceled task without queuing it, it is pending again once admitted
+	e.Base.SetState(tache.StateCanceled)
+	m.Manager.Add(t)
+	m.push(t, false)
+}
+
+// Retry queues the task again until a worker is free
+func (m *PriorityManager[T]) Retry(id string) {
+	if t, ok := m.GetByID(id); ok {
+		t.SetErr(nil)
+		m.push(t, true)
+	}
+}
+
+func (m *PriorityManager[T]) RetryAllFailed() {
+	for _, t := range m.GetByState(tache.StateFailed) {
+		m.Retry(t.GetID())
+	}
+}
+
+// SetWorkersNumActive changes the number of the tasks running at the same time
+func (m *PriorityManager[T]) SetWorkersNumActive(active int64) {
+	queueMu.Lock()
+	m.workers = int(max(active, 0))
+	queueMu.Unlock()
+	m.Manager.SetWorkersNumActive(active)
+	m.admit()
+}
+
+func (m *PriorityManager[T]) push(t T, retry bool) {
+	e := t.extension()
+	queueMu.Lock()
+	e.queue = m
+	if !e.Waiting {
+		e.Waiting = true
+		m.seq++
+		m.waiting = append(m.waiting, &waiter[T]{t: t, seq: m.seq, retry: retry})
+	}
+	queueMu.Unlock()
+	t.Persist()
+	m.admit()
+}
+
+// admit hands the waiting tasks with the highest priority to tache while it has free workers
+func (m *PriorityManager[T]) admit() {
+	queueMu.Lock()
+	active := 0
+	for _, t := range m.Manager.GetAll() {
+		if e := t.extension(); !e.Waiting && isActive(e.Base.GetState()) {
+			active++
+		}
+	}
+	var admitted []*waiter[T]
+	for active < m.workers && len(m.waiting) > 0 {
+		best := 0
+		for i, w := range m.waiting {
+			if b := m.waiting[best]; w.t.extension().Priority > b.t.extension().Priority ||
+				w.t.extension().Priority == b.t.extension().Priority && w.seq < b.seq {
+				best = i
+			}
+		}
+		w := m.waiting[best]
+		m.waiting = append(m.waiting[:best], m.waiting[best+1:]...)
+		e := w.t.extension()
+		e.Waiting = false
+		if t, ok := m.Manager.GetByID(e.GetID()); !ok || t.extension() != e {
+			// removed while waiting
+			continue
+		}
+		// counted as running before tache gets it
+		if w.retry {
+			e.Base.SetState(tache.StateWaitingRetry)
+		} else {
+			e.Base.SetState(tache.StatePending)
+		}
+		admitted = append(admitted, w)
+		active++
+	}
+	queueMu.Unlock()
+	for _, w := range admitted {
+		if w.retry {
+			// keeps the ctx, a canceled task is only run again if it's allowed
+			m.Manager.Retry(w.t.GetID())
+		} else {
+			m.Manager.Add(w.t)
+		}
+	}
+}
+
+func (m *PriorityManager[T]) dequeue(e *TaskExtension) bool {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	for i, w := range m.waiting {
+		if w.t.extension() == e {
+			m.waiting = append(m.waiting[:i], m.waiting[i+1:]...)
+			e.Waiting = false
+			return true
+		}
+	}
+	return false
+}
+
+func isActive(state tache.State) bool {
+	switch state {
+	case tache.StatePending, tache.StateRunning, tache.StateWaitingRetry, tache.StateBeforeRetry,
+		tache.StateCanceling, tache.StateErrored, tache.StateFailing:
+		return true
+	}
+	return false
+}
+
+// SetPriority changes the priority of the task, it takes effect if the task is waiting
+func SetPriority(t TaskExtensionInfo, priority int) {
+	ext, ok := t.(extensionTask)
+	if !ok {
+		return
+	}
+	queueMu.Lock()
+	ext.extension().Priority = priority
+	queueMu.Unlock()
+	t.Persist()
+}
+
+func (t *TaskExtension) scheduler() scheduler {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	return t.queue
+}
+
+// GetState reports the tasks waiting in the queue of their manager as pending
+func (t *TaskExtension) GetState() tache.State {
+	queueMu.Lock()
+	waiting := t.queue != nil && t.Waiting
+	queueMu.Unlock()
+	if waiting {
+		return tache.StatePending
+	}
+	return t.Base.GetState()
+}
+
+// Cancel takes the waiting task out of the queue and cancels it at once
+func (t *TaskExtension) Cancel() {
+	if q := t.scheduler(); q != nil && q.dequeue(t) {
+		t.Base.Cancel()
+		t.SetErr(context.Canceled)
+		t.SetState(tache.StateCanceled)
+		return
+	}
+	t.Base.Cancel()
+}
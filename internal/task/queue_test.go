@@ -0,0 +1,146 @@
+package task
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/tache"
+)
+
+type queueTask struct {
+	TaskExtension
+	name   string
+	finish chan struct{}
+	ran    func(name string)
+}
+
+func (t *queueTask) GetName() string   { return t.name }
+func (t *queueTask) GetStatus() string { return "" }
+func (t *queueTask) Run() error {
+	if t.ran != nil {
+		t.ran(t.name)
+	}
+	if t.finish == nil {
+		return nil
+	}
+	select {
+	case <-t.finish:
+		return nil
+	case <-t.Ctx().Done():
+		return t.Ctx().Err()
+	}
+}
+
+func waitState(t *testing.T, tsk *queueTask, states ...tache.State) {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		for _, s := range states {
+			if tsk.GetState() == s {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("task %s is %v, expect %v", tsk.name, tsk.GetState(), states)
+}
+
+func TestPriority(t *testing.T) {
+	m := NewPriorityManager[*queueTask]("test_priority", 1)
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	ran := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+	}
+	blocker := &queueTask{name: "blocker", finish: make(chan struct{}), ran: ran}
+	m.Add(blocker)
+	waitState(t, blocker, tache.StateRunning)
+	low := &queueTask{name: "low", ran: ran}
+	high := &queueTask{name: "high", ran: ran, TaskExtension: TaskExtension{Priority: 5}}
+	m.Add(low)
+	m.Add(high)
+	// both wait for the slot held by the blocker
+	time.Sleep(50 * time.Millisecond)
+	if s := low.GetState(); s != tache.StatePending {
+		t.Fatalf("expect the low task pending, got %v", s)
+	}
+	close(blocker.finish)
+	waitState(t, low, tache.StateSucceeded)
+	waitState(t, high, tache.StateSucceeded)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 3 || order[1] != "high" || order[2] != "low" {
+		t.Errorf("unexpected order: %v", order)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	m := NewPriorityManager[*queueTask]("test_pause", 1)
+	running := &queueTask{name: "running", finish: make(chan struct{})}
+	waiting := &queueTask{name: "waiting"}
+	m.Add(running)
+	waitState(t, running, tache.StateRunning)
+	m.Add(waiting)
+
+	if err := Pause(waiting); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, waiting, tache.StateCanceled, tache.StateFailed)
+	if err := Pause(running); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, running, tache.StateCanceled, tache.StateFailed)
+	if !running.IsPaused() || Pause(running) == nil {
+		t.Fatal("expect the stopped task paused and not pausable again")
+	}
+
+	if err := Resume(m, running); err != nil {
+		t.Fatal(err)
+	}
+	waitState(t, running, tache.StateRunning)
+	if err := Resume(m, waiting); err != nil {
+		t.Fatal(err)
+	}
+	// the resumed task waits for the slot of the running one
+	time.Sleep(50 * time.Millisecond)
+	if waiting.GetState() == tache.StateSucceeded {
+		t.Fatal("expect the resumed task waiting")
+	}
+	close(running.finish)
+	waitState(t, running, tache.StateSucceeded)
+	waitState(t, waiting, tache.StateSucceeded)
+	if running.IsPaused() || waiting.IsPaused() {
+		t.Error("expect the resumed tasks not paused")
+	}
+}
+
+func TestWaitingWithoutGoroutine(t *testing.T) {
+	m := NewPriorityManager[*queueTask]("test_waiting", 1)
+	blocker := &queueTask{name: "blocker", finish: make(chan struct{})}
+	m.Add(blocker)
+	waitState(t, blocker, tache.StateRunning)
+	before := runtime.NumGoroutine()
+	waiting := make([]*queueTask, 50)
+	for i := range waiting {
+		waiting[i] = &queueTask{name: "waiting"}
+		m.Add(waiting[i])
+	}
+	time.Sleep(50 * time.Millisecond)
+	// the waiting tasks are kept by the manager, not started by tache
+	if n := runtime.NumGoroutine(); n > before+5 {
+		t.Fatalf("expect no goroutine for the waiting tasks, got %d more", n-before)
+	}
+	m.Cancel(waiting[0].GetID())
+	if s := waiting[0].GetState(); s != tache.StateCanceled {
+		t.Fatalf("expect the waiting task canceled at once, got %v", s)
+	}
+	close(blocker.finish)
+	for _, w := range waiting[1:] {
+		waitState(t, w, tache.StateSucceeded)
+	}
+}
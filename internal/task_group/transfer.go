@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -14,6 +15,12 @@ import (
 
 type SrcPathToRemove string
 
+// SrcPathToKeep is a src object which is not moved, it and its parents are kept
+type SrcPathToKeep string
+
+// errSrcKept is returned by verifyAndRemove if a src object is kept
+var errSrcKept = errors.New("src object is kept")
+
 // ActualPath
 type DstPathToRefresh string
 
@@ -29,6 +36,12 @@ func RefreshAndRemove(dstPath string, payloads ...any) {
 		op.DeleteCache(dstStorage, dstActualPath)
 	}
 	var ctx context.Context
+	keep := make(map[string]struct{})
+	for _, payload := range payloads {
+		if p, ok := payload.(SrcPathToKeep); ok {
+			keep[string(p)] = struct{}{}
+		}
+	}
 	for _, payload := range payloads {
 		switch p := payload.(type) {
 		case DstPathToRefresh:
@@ -44,15 +57,18 @@ func RefreshAndRemove(dstPath string, payloads ...any) {
 				log.Error(errors.WithMessage(err, "failed get src storage"))
 				continue
 			}
-			err = verifyAndRemove(ctx, srcStorage, dstStorage, srcActualPath, dstActualPath, dstNeedRefresh)
-			if err != nil {
+			err = verifyAndRemove(ctx, srcStorage, dstStorage, srcActualPath, dstActualPath, dstNeedRefresh, keep)
+			if err != nil && !errors.Is(err, errSrcKept) {
 				log.Error(err)
 			}
 		}
 	}
 }
 
-func verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver, srcPath, dstPath string, refresh bool) error {
+func verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver, srcPath, dstPath string, refresh bool, keep map[string]struct{}) error {
+	if _, ok := keep[path.Join(srcStorage.GetStorage().MountPath, srcPath)]; ok {
+		return errSrcKept
+	}
 	srcObj, err := op.Get(ctx, srcStorage, srcPath)
 	if err != nil {
 		return errors.WithMessagef(err, "failed get src [%s] file", path.Join(srcStorage.GetStorage().MountPath, srcPath))
@@ -65,7 +81,7 @@ func verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver,
 	}
 
 	if !dstObj.IsDir() {
-		err = op.Remove(ctx, srcStorage, srcPath)
+		err = op.Remove(context.WithValue(ctx, conf.NoRecycleKey, struct{}{}), srcStorage, srcPath)
 		if err != nil {
 			return fmt.Errorf("failed remove %s: %+v", path.Join(srcStorage.GetStorage().MountPath, srcPath), err)
 		}
@@ -81,11 +97,13 @@ func verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver,
 	if refresh {
 		op.DeleteCache(dstStorage, dstObjPath)
 	}
-	hasErr := false
+	hasErr, kept := false, false
 	for _, obj := range srcObjs {
 		srcSubPath := path.Join(srcPath, obj.GetName())
-		err := verifyAndRemove(ctx, srcStorage, dstStorage, srcSubPath, dstObjPath, refresh)
-		if err != nil {
+		err := verifyAndRemove(ctx, srcStorage, dstStorage, srcSubPath, dstObjPath, refresh, keep)
+		if errors.Is(err, errSrcKept) {
+			kept = true
+		} else if err != nil {
 			log.Error(err)
 			hasErr = true
 		}
@@ -93,7 +111,10 @@ func verifyAndRemove(ctx context.Context, srcStorage, dstStorage driver.Driver,
 	if hasErr {
 		return errors.Errorf("some subitems of [%s] failed to verify and remove", path.Join(srcStorage.GetStorage().MountPath, srcPath))
 	}
-	err = op.Remove(ctx, srcStorage, srcPath)
+	if kept {
+		return errSrcKept
+	}
+	err = op.Remove(context.WithValue(ctx, conf.NoRecycleKey, struct{}{}), srcStorage, srcPath)
 	if err != nil {
 		return fmt.Errorf("failed remove %s: %+v", path.Join(srcStorage.GetStorage().MountPath, srcPath), err)
 	}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func validate(w *model.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return errors.WithMessage(err, "invalid url")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+	if w.PathPrefix != "" {
+		w.PathPrefix = utils.FixAndCleanPath(w.PathPrefix)
+	}
+	return nil
+}
+
+func GetWebhooks(pageIndex, pageSize int) ([]model.Webhook, int64, error) {
+	return db.GetWebhooks(pageIndex, pageSize)
+}
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	return db.GetWebhookById(id)
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	if err := validate(w); err != nil {
+		return err
+	}
+	if err := db.CreateWebhook(w); err != nil {
+		return err
+	}
+	return Reload()
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	if err := validate(w); err != nil {
+		return err
+	}
+	if _, err := db.GetWebhookById(w.ID); err != nil {
+		return err
+	}
+	if err := db.UpdateWebhook(w); err != nil {
+		return err
+	}
+	return Reload()
+}
+
+func DeleteWebhookById(id uint) error {
+	if err := db.DeleteWebhookById(id); err != nil {
+		return err
+	}
+	return Reload()
+}
+
+func GetDeliveries(webhookId uint, pageIndex, pageSize int) ([]model.WebhookDelivery, int64, error) {
+	return db.GetWebhookDeliveries(webhookId, pageIndex, pageSize)
+}
+
+// TestWebhook sends a test event to the webhook synchronously, disabled webhooks can be tested too
+func TestWebhook(ctx context.Context, id uint) (*model.WebhookDelivery, error) {
+	w, err := db.GetWebhookById(id)
+	if err != nil {
+		return nil, err
+	}
+	body, err := utils.Json.Marshal(Payload{Event: Test, Time: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	return Send(ctx, *w, Test, body, 1), nil
+}
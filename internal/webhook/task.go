@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/tache"
+)
+
+// the states reported to webhooks, transient states like waiting_retry are left out
+var taskStates = map[tache.State]string{
+	tache.StateRunning:   "running",
+	tache.StateSucceeded: "succeeded",
+	tache.StateErrored:   "errored",
+	tache.StateCanceled:  "canceled",
+	tache.StateFailed:    "failed",
+}
+
+func onTaskState(typ string, t task.TaskExtensionInfo, state tache.State) {
+	name, ok := taskStates[state]
+	if !ok {
+		return
+	}
+	if t.IsPaused() {
+		// a paused task stops as canceled or errored and then fails, it's reported once
+		if state != tache.StateCanceled && state != tache.StateErrored {
+			return
+		}
+		name = "paused"
+	}
+	p := Payload{
+		Event: TaskPrefix + name,
+		Task: &TaskPayload{
+			ID:     t.GetID(),
+			Type:   typ,
+			Name:   t.GetName(),
+			State:  name,
+			Status: t.GetStatus(),
+		},
+	}
+	// the error of the last attempt is kept until the task succeeds
+	if err := t.GetErr(); err != nil && state != tache.StateRunning && state != tache.StateSucceeded {
+		p.Task.Error = err.Error()
+	}
+	if creator := t.GetCreator(); creator != nil {
+		p.Username = creator.Username
+	}
+	emit(p)
+}
+
+func init() {
+	task.RegisterStateHook(onTaskState)
+}
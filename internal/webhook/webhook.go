@@ -0,0 +1,272 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/net"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	FsPut    = "fs.put"
+	FsMkdir  = "fs.mkdir"
+	FsRename = "fs.rename"
+	FsMove   = "fs.move"
+	FsCopy   = "fs.copy"
+	FsRemove = "fs.remove"
+	// task events are named task.<state>, e.g. task.succeeded
+	TaskPrefix = "task."
+	Test       = "test"
+)
+
+const (
+	queueSize       = 1024
+	workers         = 4
+	maxResponseSize = 1024
+)
+
+// retryBaseDelay is doubled on every retry
+var retryBaseDelay = 10 * time.Second
+
+type Payload struct {
+	Event    string       `json:"event"`
+	Time     time.Time    `json:"time"`
+	Path     string       `json:"path,omitempty"`
+	DstPath  string       `json:"dst_path,omitempty"`
+	Size     int64        `json:"size,omitempty"`
+	Username string       `json:"username,omitempty"`
+	Task     *TaskPayload `json:"task,omitempty"`
+}
+
+type TaskPayload struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type job struct {
+	webhook model.Webhook
+	event   string
+	body    []byte
+	attempt int
+}
+
+var (
+	mu       sync.RWMutex
+	running  bool
+	webhooks []model.Webhook
+	queue    chan *job
+	wg       sync.WaitGroup
+	cleaner  *cron.Cron
+
+	client     *http.Client
+	clientOnce sync.Once
+)
+
+// Emit queues the event to the matched webhooks, it never blocks the caller
+func Emit(ctx context.Context, event, path, dstPath string, size int64) {
+	p := Payload{Event: event, Path: path, DstPath: dstPath, Size: size}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		p.Username = user.Username
+	}
+	emit(p, path, dstPath)
+}
+
+func emit(p Payload, paths ...string) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if !running {
+		return
+	}
+	var body []byte
+	for _, w := range webhooks {
+		if !w.Match(p.Event, paths...) {
+			continue
+		}
+		if body == nil {
+			p.Time = time.Now()
+			var err error
+			if body, err = utils.Json.Marshal(p); err != nil {
+				log.Errorf("failed marshal webhook payload: %+v", err)
+				return
+			}
+		}
+		enqueue(&job{webhook: w, event: p.Event, body: body, attempt: 1})
+	}
+}
+
+// enqueue must be called with mu held
+func enqueue(j *job) {
+	select {
+	case queue <- j:
+	default:
+		log.Warnf("webhook queue is full, drop %s to [%s]", j.event, j.webhook.URL)
+	}
+}
+
+// Send delivers the body to the webhook once and records the delivery
+func Send(ctx context.Context, w model.Webhook, event string, body []byte, attempt int) *model.WebhookDelivery {
+	d := &model.WebhookDelivery{
+		WebhookId: w.ID,
+		Event:     event,
+		Payload:   string(body),
+		Attempt:   attempt,
+		Time:      time.Now(),
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(setting.GetInt(conf.WebhookTimeout, 10))*time.Second)
+	defer cancel()
+	err := func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "OpenList-Webhook/"+conf.Version)
+		req.Header.Set("X-OpenList-Event", event)
+		req.Header.Set("X-OpenList-Attempt", strconv.Itoa(attempt))
+		if w.Secret != "" {
+			req.Header.Set("X-OpenList-Signature", "sha256="+Sign(w.Secret, body))
+		}
+		clientOnce.Do(func() { client = net.NewHttpClient() })
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		d.StatusCode = res.StatusCode
+		resp, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+		d.Response = string(resp)
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return errStatus(res.Status)
+		}
+		return nil
+	}()
+	d.Duration = time.Since(d.Time).Milliseconds()
+	d.Success = err == nil
+	if err != nil {
+		d.Error = err.Error()
+	}
+	if err = db.CreateWebhookDelivery(d); err != nil {
+		log.Errorf("failed save webhook delivery: %+v", err)
+	}
+	return d
+}
+
+type errStatus string
+
+func (e errStatus) Error() string {
+	return "unexpected status: " + string(e)
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the body, receivers verify the X-OpenList-Signature header with it
+func Sign(secret string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func deliver(j *job) {
+	d := Send(context.Background(), j.webhook, j.event, j.body, j.attempt)
+	if d.Success || j.attempt > setting.GetInt(conf.WebhookMaxRetry, 3) {
+		if !d.Success {
+			log.Warnf("failed deliver %s to [%s] after %d attempts: %s", j.event, j.webhook.URL, j.attempt, d.Error)
+		}
+		return
+	}
+	delay := retryBaseDelay << (j.attempt - 1)
+	j.attempt++
+	time.AfterFunc(delay, func() {
+		mu.RLock()
+		defer mu.RUnlock()
+		if running {
+			enqueue(j)
+		}
+	})
+}
+
+func work(queue <-chan *job) {
+	defer wg.Done()
+	for j := range queue {
+		deliver(j)
+	}
+}
+
+// Reload refreshes the enabled webhooks after they are changed
+func Reload() error {
+	hooks, err := db.GetEnabledWebhooks()
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	webhooks = hooks
+	mu.Unlock()
+	return nil
+}
+
+// Start loads the webhooks and runs the delivery workers and the log cleaner
+func Start() {
+	if err := Reload(); err != nil {
+		log.Errorf("failed load webhooks: %+v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if running {
+		return
+	}
+	queue = make(chan *job, queueSize)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go work(queue)
+	}
+	cleaner = cron.NewCron(time.Hour)
+	cleaner.Do(clean)
+	go clean()
+	running = true
+}
+
+// Stop waits for the queued deliveries, pending retries are dropped
+func Stop() {
+	mu.Lock()
+	if !running {
+		mu.Unlock()
+		return
+	}
+	running = false
+	close(queue)
+	cleaner.Stop()
+	mu.Unlock()
+	wg.Wait()
+}
+
+func clean() {
+	days := setting.GetInt(conf.WebhookLogRetentionDays, 30)
+	if days <= 0 {
+		return
+	}
+	n, err := db.DeleteWebhookDeliveriesBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Errorf("failed clean webhook deliveries: %+v", err)
+		return
+	}
+	if n > 0 {
+		log.Infof("cleaned %d webhook deliveries older than %d days", n, days)
+	}
+}
@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestMatch(t *testing.T) {
+	w := model.Webhook{Events: "fs.put, task.*", PathPrefix: "/media"}
+	tests := []struct {
+		event string
+		path  string
+		want  bool
+	}{
+		{FsPut, "/media/a.mp4", true},
+		{FsPut, "/mediax/a.mp4", false},
+		{FsRemove, "/media/a.mp4", false},
+		{"task.failed", "", true},
+	}
+	for _, tt := range tests {
+		if got := w.Match(tt.event, tt.path); got != tt.want {
+			t.Errorf("Match(%s, %s) = %v, want %v", tt.event, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	type received struct {
+		event     string
+		signature string
+		payload   Payload
+		body      []byte
+	}
+	ch := make(chan received, 4)
+	var failed atomic.Bool
+	retryBaseDelay = 100 * time.Millisecond
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		// fail the first attempt of the put event to check the retry
+		if r.Header.Get("X-OpenList-Event") == FsPut && failed.CompareAndSwap(false, true) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var p Payload
+		_ = utils.Json.Unmarshal(body, &p)
+		ch <- received{event: r.Header.Get("X-OpenList-Event"), signature: r.Header.Get("X-OpenList-Signature"), payload: p, body: body}
+	}))
+	defer srv.Close()
+
+	w := &model.Webhook{URL: srv.URL, Secret: "secret", Events: "fs.*", PathPrefix: "/local"}
+	if err := CreateWebhook(w); err != nil {
+		t.Fatalf("failed create webhook: %+v", err)
+	}
+	Start()
+	defer Stop()
+	ctx := context.WithValue(context.Background(), conf.UserKey, &model.User{Username: "alice"})
+	Emit(ctx, FsMkdir, "/other/dir", "", 0)
+	Emit(ctx, FsPut, "/local/a.txt", "", 5)
+
+	d, err := TestWebhook(context.Background(), w.ID)
+	if err != nil || !d.Success {
+		t.Fatalf("expect test delivered, got %+v: %v", d, err)
+	}
+	select {
+	case r := <-ch:
+		if r.event != Test {
+			t.Fatalf("expect test event, got %s", r.event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("test event not received")
+	}
+
+	// the put event failed once and is retried after the backoff
+	select {
+	case r := <-ch:
+		if r.event != FsPut || r.payload.Path != "/local/a.txt" || r.payload.Username != "alice" {
+			t.Errorf("unexpected event: %+v", r)
+		}
+		if r.signature != "sha256="+Sign("secret", r.body) {
+			t.Errorf("unexpected signature: %s", r.signature)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("put event not retried")
+	}
+
+	// the delivery is saved after the response is received
+	var puts []model.WebhookDelivery
+	for deadline := time.Now().Add(5 * time.Second); len(puts) < 2 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		deliveries, _, err := GetDeliveries(w.ID, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		puts = puts[:0]
+		for _, d := range deliveries {
+			if d.Event == FsPut {
+				puts = append(puts, d)
+			}
+		}
+	}
+	if len(puts) != 2 || puts[0].Attempt != 2 || !puts[0].Success || puts[1].StatusCode != http.StatusInternalServerError {
+		t.Errorf("unexpected deliveries: %+v", puts)
+	}
+}
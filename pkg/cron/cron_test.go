@@ -14,3 +14,36 @@ func TestCron(t *testing.T) {
 	c.Stop()
 	c.Stop()
 }
+
+func TestSchedule(t *testing.T) {
+	base := time.Date(2024, 1, 31, 10, 30, 15, 0, time.UTC) // wednesday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 45, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 2, 1, 3, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 9-17/4 * * mon-fri", time.Date(2024, 1, 31, 13, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week
+		{"0 0 15 * sat", time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", base.Add(90 * time.Minute)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("failed parse %s: %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(base); !got.Equal(tt.want) {
+			t.Errorf("%s: expect %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * foo *", "5-1 * * * *", "*/0 * * * *", "@every 1ms"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expect error for %s", expr)
+		}
+	}
+}
@@ -5,7 +5,8 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
-	"github.com/OpenListTeam/go-cache"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/pkg/errors"
 )
@@ -15,36 +16,44 @@ var SecretKey []byte
 type UserClaims struct {
 	Username string `json:"username"`
 	PwdTS    int64  `json:"pwd_ts"`
+	// the session the token is issued on
+	SID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-var validTokenCache = cache.NewMemCache[bool]()
+// CreateSession starts a session of the user for the request,
+// returns the token and the refresh token of it
+func CreateSession(c *gin.Context, user *model.User) (token string, refreshToken string, err error) {
+	session, refreshToken, err := op.CreateSession(user, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		return "", "", err
+	}
+	token, err = GenerateToken(user, session.SID)
+	if err != nil {
+		return "", "", err
+	}
+	return token, refreshToken, nil
+}
 
-func GenerateToken(user *model.User) (tokenString string, err error) {
+func GenerateToken(user *model.User, sid string) (tokenString string, err error) {
 	claim := UserClaims{
 		Username: user.Username,
 		PwdTS:    user.PwdTS,
+		SID:      sid,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(conf.Conf.TokenExpiresIn) * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		}}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
-	tokenString, err = token.SignedString(SecretKey)
-	if err != nil {
-		return "", err
-	}
-	validTokenCache.Set(tokenString, true)
-	return tokenString, err
+	return token.SignedString(SecretKey)
 }
 
+// ParseToken parses the token, and checks the session of it is neither revoked nor expired
 func ParseToken(tokenString string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return SecretKey, nil
 	})
-	if IsTokenInvalidated(tokenString) {
-		return nil, errors.New("token is invalidated")
-	}
 	if err != nil {
 		if ve, ok := err.(*jwt.ValidationError); ok {
 			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
@@ -58,21 +67,12 @@ func ParseToken(tokenString string) (*UserClaims, error) {
 			}
 		}
 	}
-	if claims, ok := token.Claims.(*UserClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*UserClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("couldn't handle this token")
 	}
-	return nil, errors.New("couldn't handle this token")
-}
-
-func InvalidateToken(tokenString string) error {
-	if tokenString == "" {
-		return nil // don't invalidate empty guest token
+	if _, err = op.GetSessionBySID(claims.SID); err != nil {
+		return nil, err
 	}
-	validTokenCache.Del(tokenString)
-	return nil
-}
-
-func IsTokenInvalidated(tokenString string) bool {
-	_, ok := validTokenCache.Get(tokenString)
-	return !ok
+	return claims, nil
 }
@@ -6,10 +6,12 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/dlclark/regexp2"
+	"github.com/pkg/errors"
 )
 
 func IsStorageSignEnabled(rawPath string) bool {
@@ -31,7 +33,39 @@ func IsApply(metaPath, reqPath string, applySub bool) bool {
 	return utils.IsSubPath(metaPath, reqPath) && applySub
 }
 
+// HasPermission reports whether the user can do the operation on all the paths, it is decided by
+// the acl entries of each path, or by fallback, the permission of the user, if no entry covers it
+func HasPermission(user *model.User, perm int32, fallback bool, paths ...string) bool {
+	if len(paths) == 0 {
+		return fallback
+	}
+	for _, p := range paths {
+		if !op.HasPermission(user, p, perm, fallback) {
+			return false
+		}
+	}
+	return true
+}
+
+// MountReadable returns the check whether the user can read the mount path of a storage
+// without a password, for summing up the storages of a virtual folder
+func MountReadable(user *model.User) func(mountPath string) bool {
+	return func(mountPath string) bool {
+		if !utils.IsSubPath(user.BasePath, mountPath) {
+			return false
+		}
+		meta, err := op.GetNearestMeta(mountPath)
+		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			return false
+		}
+		return CanAccess(user, meta, mountPath, "")
+	}
+}
+
 func CanAccess(user *model.User, meta *model.Meta, reqPath string, password string) bool {
+	if !op.HasPermission(user, reqPath, model.ACLRead, true) {
+		return false
+	}
 	// if the reqPath is in hide (only can check the nearest meta) and user can't see hides, can't access
 	if meta != nil && !user.CanSeeHides() && meta.Hide != "" &&
 		IsApply(meta.Path, path.Dir(reqPath), meta.HSub) { // the meta should apply to the parent of current path
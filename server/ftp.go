@@ -14,6 +14,7 @@ import (
 	"sync"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
@@ -117,6 +118,14 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 		if err != nil {
 			return nil, err
 		}
+	} else if op.IsAPIToken(pass) {
+		userObj, err = op.AuthAPIToken(pass, cc.RemoteAddr().String())
+		if err != nil {
+			return nil, err
+		}
+		if userObj.Username != user {
+			return nil, errs.InvalidAPIToken
+		}
 	} else {
 		userObj, err = op.GetUserByName(user)
 		if err != nil {
@@ -138,6 +147,7 @@ func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string)
 	} else {
 		ctx = context.WithValue(ctx, conf.MetaPassKey, "")
 	}
+	ctx = context.WithValue(ctx, conf.ProtocolKey, "ftp")
 	ctx = context.WithValue(ctx, conf.ClientIPKey, cc.RemoteAddr().String())
 	ctx = context.WithValue(ctx, conf.ProxyHeaderKey, d.proxyHeader)
 	return ftp.NewAferoAdapter(ctx), nil
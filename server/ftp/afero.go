@@ -79,6 +79,10 @@ func (a *AferoAdapter) Chtimes(_ string, _ time.Time, _ time.Time) error {
 	return errs.NotSupport
 }
 
+func (a *AferoAdapter) GetAvailableSpace(dirName string) (int64, error) {
+	return AvailableSpace(a.ctx, dirName)
+}
+
 func (a *AferoAdapter) ReadDir(name string) ([]os.FileInfo, error) {
 	return List(a.ctx, name)
 }
@@ -20,29 +20,31 @@ func Mkdir(ctx context.Context, path string) error {
 	if err != nil {
 		return err
 	}
-	if !user.CanWrite() || !user.CanFTPManage() {
+	canWrite := user.CanWrite() && user.CanFTPManage()
+	if !canWrite {
 		meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
 		if err != nil {
 			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
 				return err
 			}
 		}
-		if !common.CanWrite(meta, reqPath) {
-			return errs.PermissionDenied
-		}
+		canWrite = common.CanWrite(meta, reqPath)
+	}
+	if !common.HasPermission(user, model.ACLWrite, canWrite, reqPath) {
+		return errs.PermissionDenied
 	}
 	return fs.MakeDir(ctx, reqPath)
 }
 
 func Remove(ctx context.Context, path string) error {
 	user := ctx.Value(conf.UserKey).(*model.User)
-	if !user.CanRemove() || !user.CanFTPManage() {
-		return errs.PermissionDenied
-	}
 	reqPath, err := user.JoinPath(path)
 	if err != nil {
 		return err
 	}
+	if !user.CanFTPManage() || !common.HasPermission(user, model.ACLRemove, user.CanRemove(), reqPath) {
+		return errs.PermissionDenied
+	}
 	return fs.Remove(ctx, reqPath)
 }
 
@@ -59,12 +61,13 @@ func Rename(ctx context.Context, oldPath, newPath string) error {
 	srcDir, srcBase := stdpath.Split(srcPath)
 	dstDir, dstBase := stdpath.Split(dstPath)
 	if srcDir == dstDir {
-		if !user.CanRename() || !user.CanFTPManage() {
+		if !user.CanFTPManage() || !common.HasPermission(user, model.ACLRename, user.CanRename(), srcPath) {
 			return errs.PermissionDenied
 		}
 		return fs.Rename(ctx, srcPath, dstBase)
 	} else {
-		if !user.CanFTPManage() || !user.CanMove() || (srcBase != dstBase && !user.CanRename()) {
+		if !user.CanFTPManage() || !common.HasPermission(user, model.ACLMove, user.CanMove(), srcPath, dstDir) ||
+			(srcBase != dstBase && !common.HasPermission(user, model.ACLRename, user.CanRename(), srcPath)) {
 			return errs.PermissionDenied
 		}
 		if _, err = fs.Move(ctx, srcPath, dstDir); err != nil {
@@ -11,6 +11,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
@@ -21,7 +22,8 @@ import (
 type FileDownloadProxy struct {
 	model.File
 	io.Closer
-	ctx context.Context
+	ctx      context.Context
+	protocol string
 }
 
 func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownloadProxy, error) {
@@ -57,11 +59,13 @@ func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownl
 		_ = ss.Close()
 		return nil, err
 	}
-	return &FileDownloadProxy{File: reader, Closer: ss, ctx: ctx}, nil
+	protocol, _ := ctx.Value(conf.ProtocolKey).(string)
+	return &FileDownloadProxy{File: reader, Closer: ss, ctx: ctx, protocol: protocol}, nil
 }
 
 func (f *FileDownloadProxy) Read(p []byte) (n int, err error) {
 	n, err = f.File.Read(p)
+	metrics.AddServedBytes(f.protocol, n)
 	if err != nil {
 		return
 	}
@@ -71,6 +75,7 @@ func (f *FileDownloadProxy) Read(p []byte) (n int, err error) {
 
 func (f *FileDownloadProxy) ReadAt(p []byte, off int64) (n int, err error) {
 	n, err = f.File.ReadAt(p, off)
+	metrics.AddServedBytes(f.protocol, n)
 	if err != nil {
 		return
 	}
@@ -137,6 +142,23 @@ func Stat(ctx context.Context, path string) (os.FileInfo, error) {
 	return &OsFileInfoAdapter{obj: obj}, nil
 }
 
+// AvailableSpace returns the free space of the storage the path is in, for the AVBL command
+func AvailableSpace(ctx context.Context, path string) (int64, error) {
+	user := ctx.Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		return 0, err
+	}
+	details, err := op.GetStorageDetailsByPath(ctx, reqPath, common.MountReadable(user))
+	if err != nil {
+		return 0, err
+	}
+	if details.FreeSpace() < 0 {
+		return 0, errs.NotSupport
+	}
+	return details.FreeSpace(), nil
+}
+
 func List(ctx context.Context, path string) ([]os.FileInfo, error) {
 	user := ctx.Value(conf.UserKey).(*model.User)
 	reqPath, err := user.JoinPath(path)
@@ -28,7 +28,7 @@ type FileUploadProxy struct {
 	trunc  bool
 }
 
-func uploadAuth(ctx context.Context, path string) error {
+func uploadAuth(ctx context.Context, path string, size int64) error {
 	user := ctx.Value(conf.UserKey).(*model.User)
 	meta, err := op.GetNearestMeta(stdpath.Dir(path))
 	if err != nil {
@@ -37,14 +37,14 @@ func uploadAuth(ctx context.Context, path string) error {
 		}
 	}
 	if !(common.CanAccess(user, meta, path, ctx.Value(conf.MetaPassKey).(string)) &&
-		((user.CanFTPManage() && user.CanWrite()) || common.CanWrite(meta, stdpath.Dir(path)))) {
+		common.HasPermission(user, model.ACLWrite, (user.CanFTPManage() && user.CanWrite()) || common.CanWrite(meta, stdpath.Dir(path)), path)) {
 		return errs.PermissionDenied
 	}
-	return nil
+	return op.CheckQuota(user, size)
 }
 
 func OpenUpload(ctx context.Context, path string, trunc bool) (*FileUploadProxy, error) {
-	err := uploadAuth(ctx, path)
+	err := uploadAuth(ctx, path, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +118,7 @@ type FileUploadWithLengthProxy struct {
 }
 
 func OpenUploadWithLength(ctx context.Context, path string, trunc bool, length int64) (*FileUploadWithLengthProxy, error) {
-	err := uploadAuth(ctx, path)
+	err := uploadAuth(ctx, path, length)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,140 @@
+package handles
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type APITokenCreateReq struct {
+	Name       string     `json:"name" binding:"required"`
+	Scopes     int32      `json:"scopes"`
+	PathPrefix string     `json:"path_prefix"`
+	AllowedIPs string     `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type APITokenCreateResp struct {
+	model.APIToken
+	// only shown here
+	Token             string `json:"token"`
+	S3SecretAccessKey string `json:"s3_secret_access_key"`
+}
+
+func CreateMyAPIToken(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req APITokenCreateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	t := &model.APIToken{
+		Name:       req.Name,
+		Scopes:     req.Scopes,
+		PathPrefix: req.PathPrefix,
+		AllowedIPs: req.AllowedIPs,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	token, s3Secret, err := op.CreateAPIToken(userObj, t)
+	if err != nil {
+		if errors.Is(err, errs.InvalidAPITokenScope) || errors.Is(err, errs.InvalidAPITokenIPs) {
+			common.ErrorResp(c, err, 400)
+		} else {
+			common.ErrorResp(c, err, 500, true)
+		}
+		return
+	}
+	common.SuccessResp(c, APITokenCreateResp{
+		APIToken:          *t,
+		Token:             token,
+		S3SecretAccessKey: s3Secret,
+	})
+}
+
+func ListMyAPITokens(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	listAPITokens(c, userObj)
+}
+
+func DeleteMyAPIToken(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	tokenId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	t, err := op.GetAPITokenByIdAndUserId(uint(tokenId), userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get api token", 404)
+		return
+	}
+	if err = op.DeleteAPITokenById(t.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListAPITokens(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	userObj, err := op.GetUserById(uint(userId))
+	if err != nil {
+		common.ErrorStrResp(c, "user invalid", 404)
+		return
+	}
+	listAPITokens(c, userObj)
+}
+
+func DeleteAPIToken(c *gin.Context) {
+	tokenId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.DeleteAPITokenById(uint(tokenId)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func listAPITokens(c *gin.Context, userObj *model.User) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	tokens, total, err := op.GetAPITokensByUserId(userObj.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: tokens,
+		Total:   total,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	stdpath "path"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 
@@ -231,6 +232,8 @@ type ArchiveDecompressReq struct {
 	InnerPath     string        `json:"inner_path" form:"inner_path"`
 	CacheFull     bool          `json:"cache_full" form:"cache_full"`
 	PutIntoNewDir bool          `json:"put_into_new_dir" form:"put_into_new_dir"`
+	// what to do if a decompressed file exists in the destination, overwrite it by default
+	ConflictPolicy model.ConflictPolicy `json:"conflict_policy" form:"conflict_policy"`
 }
 
 func FsArchiveDecompress(c *gin.Context) {
@@ -239,6 +242,10 @@ func FsArchiveDecompress(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	if !req.ConflictPolicy.Valid() {
+		common.ErrorResp(c, errs.InvalidConflict, 400)
+		return
+	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	if !user.CanDecompress() {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
@@ -258,6 +265,11 @@ func FsArchiveDecompress(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	// decompressing is allowed by its own permission, only the acl entries may deny it
+	if !common.HasPermission(user, model.ACLRead, true, srcPaths...) || !common.HasPermission(user, model.ACLWrite, true, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	tasks := make([]task.TaskExtensionInfo, 0, len(srcPaths))
 	for _, srcPath := range srcPaths {
 		t, e := fs.ArchiveDecompress(c.Request.Context(), srcPath, dstDir, model.ArchiveDecompressArgs{
@@ -271,8 +283,9 @@ func FsArchiveDecompress(c *gin.Context) {
 				},
 				InnerPath: utils.FixAndCleanPath(req.InnerPath),
 			},
-			CacheFull:     req.CacheFull,
-			PutIntoNewDir: req.PutIntoNewDir,
+			CacheFull:      req.CacheFull,
+			PutIntoNewDir:  req.PutIntoNewDir,
+			ConflictPolicy: req.ConflictPolicy,
 		})
 		if e != nil {
 			if errors.Is(e, errs.WrongArchivePassword) {
@@ -291,6 +304,104 @@ func FsArchiveDecompress(c *gin.Context) {
 	})
 }
 
+type ArchiveCompressReq struct {
+	SrcDir      string        `json:"src_dir" form:"src_dir"`
+	DstDir      string        `json:"dst_dir" form:"dst_dir"`
+	Name        StringOrArray `json:"name" form:"name"`
+	ArchiveName string        `json:"archive_name" form:"archive_name"`
+	// zip, tar or tar.gz, defaults to zip, 7z can only be decompressed
+	Format      string `json:"format" form:"format"`
+	ArchivePass string `json:"archive_pass" form:"archive_pass"`
+}
+
+func FsArchiveCompress(c *gin.Context) {
+	var req ArchiveCompressReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if len(req.Name) == 0 {
+		common.ErrorStrResp(c, "name is empty", 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = "zip"
+	}
+	if _, err := tool.GetCompressor(req.Format); err != nil {
+		common.ErrorStrResp(c, fmt.Sprintf("can't compress into %s, the formats are %s",
+			req.Format, strings.Join(tool.CompressorFormats(), ", ")), 400)
+		return
+	}
+	if req.ArchiveName == "" {
+		req.ArchiveName = req.Name[0]
+		if len(req.Name) > 1 && stdpath.Base(req.SrcDir) != "/" {
+			req.ArchiveName = stdpath.Base(req.SrcDir)
+		}
+		req.ArchiveName += "." + req.Format
+	}
+	if strings.ContainsAny(req.ArchiveName, "/\\") {
+		common.ErrorStrResp(c, "archive name can't contain path separators", 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	srcDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	srcPaths := make([]string, 0, len(req.Name))
+	for _, name := range req.Name {
+		if err = checkRelativePath(name); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		srcPath, err := user.JoinPath(stdpath.Join(req.SrcDir, name))
+		if err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+		srcPaths = append(srcPaths, srcPath)
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	canWrite := user.CanWrite()
+	if !canWrite {
+		meta, err := op.GetNearestMeta(dstDir)
+		if err != nil {
+			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				common.ErrorResp(c, err, 500, true)
+				return
+			}
+		}
+		canWrite = common.CanWrite(meta, dstDir)
+	}
+	// compressing makes a copy of the sources, it requires the copy permission
+	if !common.HasPermission(user, model.ACLCopy, user.CanCopy(), append(srcPaths, dstDir)...) ||
+		!common.HasPermission(user, model.ACLWrite, canWrite, dstDir) ||
+		!common.HasPermission(user, model.ACLRead, true, srcPaths...) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	t, err := fs.ArchiveCompress(c.Request.Context(), srcDir, req.Name, dstDir, req.ArchiveName, model.ArchiveCompressArgs{
+		Format:   req.Format,
+		Password: req.ArchivePass,
+	})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	var tasks []task.TaskExtensionInfo
+	if t != nil {
+		tasks = append(tasks, t)
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfos(tasks),
+	})
+}
+
 func ArchiveDown(c *gin.Context) {
 	archiveRawPath := c.Request.Context().Value(conf.PathKey).(string)
 	innerPath := utils.FixAndCleanPath(c.Query("inner"))
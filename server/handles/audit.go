@@ -0,0 +1,74 @@
+package handles
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type ListAuditLogsReq struct {
+	model.PageReq
+	model.AuditQuery
+}
+
+func ListAuditLogs(c *gin.Context) {
+	var req ListAuditLogsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	if req.Path != "" {
+		req.Path = utils.FixAndCleanPath(req.Path)
+	}
+	logs, total, err := audit.GetLogs(req.AuditQuery, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
+
+func ExportAuditLogs(c *gin.Context) {
+	var req model.AuditQuery
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Path != "" {
+		req.Path = utils.FixAndCleanPath(req.Path)
+	}
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit-`+time.Now().Format("20060102150405")+`.jsonl"`)
+	c.Status(200)
+	if err := audit.Export(req, c.Writer); err != nil {
+		// the response has been partially written, nothing can be sent to the client
+		log.Errorf("failed export audit logs: %+v", err)
+	}
+}
+
+type ClearAuditLogsReq struct {
+	Before time.Time `json:"before" binding:"required"`
+}
+
+func ClearAuditLogs(c *gin.Context) {
+	var req ClearAuditLogsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	n, err := audit.Clear(req.Before)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{"deleted": n})
+}
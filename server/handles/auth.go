@@ -71,12 +71,12 @@ func loginHash(c *gin.Context, req *LoginReq) {
 		}
 	}
 	// generate token
-	token, err := common.GenerateToken(user)
+	token, refreshToken, err := common.CreateSession(c, user)
 	if err != nil {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
-	common.SuccessResp(c, gin.H{"token": token})
+	common.SuccessResp(c, gin.H{"token": token, "refresh_token": refreshToken})
 	model.LoginCache.Del(ip)
 }
 
@@ -180,8 +180,18 @@ func Verify2FA(c *gin.Context) {
 }
 
 func LogOut(c *gin.Context) {
-	err := common.InvalidateToken(c.GetHeader("Authorization"))
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		// don't revoke empty guest token
+		common.SuccessResp(c)
+		return
+	}
+	claims, err := common.ParseToken(token)
 	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	if err = op.RevokeSessionBySID(claims.SID); err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
@@ -30,10 +30,6 @@ func FsRecursiveMove(c *gin.Context) {
 	}
 
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanMove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -44,6 +40,10 @@ func FsRecursiveMove(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, model.ACLMove, user.CanMove(), srcDir, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(srcDir)
 	if err != nil {
@@ -150,16 +150,15 @@ func FsBatchRename(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanRename() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
-
 	reqPath, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, model.ACLRename, user.CanRename(), reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil {
@@ -179,6 +178,10 @@ func FsBatchRename(c *gin.Context) {
 			return
 		}
 		filePath := fmt.Sprintf("%s/%s", reqPath, renameObject.SrcName)
+		if !common.HasPermission(user, model.ACLRename, user.CanRename(), filePath) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
 		if err := fs.Rename(c.Request.Context(), filePath, renameObject.NewName); err != nil {
 			common.ErrorResp(c, err, 500)
 			return
@@ -200,16 +203,15 @@ func FsRegexRename(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanRename() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
-
 	reqPath, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, model.ACLRename, user.CanRename(), reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(reqPath)
 	if err != nil {
@@ -241,6 +243,10 @@ func FsRegexRename(c *gin.Context) {
 				return
 			}
 			filePath := fmt.Sprintf("%s/%s", reqPath, file.GetName())
+			if !common.HasPermission(user, model.ACLRename, user.CanRename(), filePath) {
+				common.ErrorResp(c, errs.PermissionDenied, 403)
+				return
+			}
 			if err := fs.Rename(c.Request.Context(), filePath, newFileName); err != nil {
 				common.ErrorResp(c, err, 500)
 				return
@@ -1,6 +1,7 @@
 package handles
 
 import (
+	"context"
 	"fmt"
 	stdpath "path"
 	"strings"
@@ -36,7 +37,8 @@ func FsMkdir(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
-	if !user.CanWrite() {
+	canWrite := user.CanWrite()
+	if !canWrite {
 		meta, err := op.GetNearestMeta(stdpath.Dir(reqPath))
 		if err != nil {
 			if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
@@ -44,10 +46,11 @@ func FsMkdir(c *gin.Context) {
 				return
 			}
 		}
-		if !common.CanWrite(meta, reqPath) {
-			common.ErrorResp(c, errs.PermissionDenied, 403)
-			return
-		}
+		canWrite = common.CanWrite(meta, reqPath)
+	}
+	if !common.HasPermission(user, model.ACLWrite, canWrite, reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
 	}
 	if err := fs.MakeDir(c.Request.Context(), reqPath); err != nil {
 		common.ErrorResp(c, err, 500)
@@ -61,6 +64,22 @@ type MoveCopyReq struct {
 	DstDir    string   `json:"dst_dir"`
 	Names     []string `json:"names"`
 	Overwrite bool     `json:"overwrite"`
+	Verify    bool     `json:"verify"` // compare the hashes of the transferred files
+	// applied to every file if set, otherwise the request fails if any name exists unless overwrite
+	ConflictPolicy model.ConflictPolicy `json:"conflict_policy"`
+}
+
+// transferCtx returns the context of the request with the options of the transfers,
+// the transferred files are verified if verify is true
+func transferCtx(c *gin.Context, verify bool, policy model.ConflictPolicy) context.Context {
+	ctx := c.Request.Context()
+	if verify {
+		ctx = context.WithValue(ctx, conf.VerifyTransferKey, struct{}{})
+	}
+	if policy != "" {
+		ctx = context.WithValue(ctx, conf.ConflictPolicyKey, policy)
+	}
+	return ctx
 }
 
 func FsMove(c *gin.Context) {
@@ -73,11 +92,11 @@ func FsMove(c *gin.Context) {
 		common.ErrorStrResp(c, "Empty file names", 400)
 		return
 	}
-	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanMove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
+	if !req.ConflictPolicy.Valid() {
+		common.ErrorResp(c, errs.InvalidConflict, 400)
 		return
 	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -88,8 +107,12 @@ func FsMove(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, model.ACLMove, user.CanMove(), append(joinNames(srcDir, req.Names), dstDir)...) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
-	if !req.Overwrite {
+	if !req.Overwrite && req.ConflictPolicy == "" {
 		for _, name := range req.Names {
 			if res, _ := fs.Get(c.Request.Context(), stdpath.Join(dstDir, name), &fs.GetArgs{NoLog: true}); res != nil {
 				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", name), 403)
@@ -101,8 +124,9 @@ func FsMove(c *gin.Context) {
 	// Create all tasks immediately without any synchronous validation
 	// All validation will be done asynchronously in the background
 	var addedTasks []task.TaskExtensionInfo
+	ctx := transferCtx(c, req.Verify, req.ConflictPolicy)
 	for i, name := range req.Names {
-		t, err := fs.Move(c.Request.Context(), stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
+		t, err := fs.Move(ctx, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
 		if t != nil {
 			addedTasks = append(addedTasks, t)
 		}
@@ -135,11 +159,11 @@ func FsCopy(c *gin.Context) {
 		common.ErrorStrResp(c, "Empty file names", 400)
 		return
 	}
-	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanCopy() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
+	if !req.ConflictPolicy.Valid() {
+		common.ErrorResp(c, errs.InvalidConflict, 400)
 		return
 	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -150,8 +174,12 @@ func FsCopy(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, model.ACLCopy, user.CanCopy(), append(joinNames(srcDir, req.Names), dstDir)...) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
-	if !req.Overwrite {
+	if !req.Overwrite && req.ConflictPolicy == "" {
 		for _, name := range req.Names {
 			if res, _ := fs.Get(c.Request.Context(), stdpath.Join(dstDir, name), &fs.GetArgs{NoLog: true}); res != nil {
 				common.ErrorStrResp(c, fmt.Sprintf("file [%s] exists", name), 403)
@@ -163,8 +191,9 @@ func FsCopy(c *gin.Context) {
 	// Create all tasks immediately without any synchronous validation
 	// All validation will be done asynchronously in the background
 	var addedTasks []task.TaskExtensionInfo
+	ctx := transferCtx(c, req.Verify, req.ConflictPolicy)
 	for i, name := range req.Names {
-		t, err := fs.Copy(c.Request.Context(), stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
+		t, err := fs.Copy(ctx, stdpath.Join(srcDir, name), dstDir, len(req.Names) > i+1)
 		if t != nil {
 			addedTasks = append(addedTasks, t)
 		}
@@ -187,6 +216,60 @@ func FsCopy(c *gin.Context) {
 	}
 }
 
+type SyncReq struct {
+	SrcDir string `json:"src_dir"`
+	DstDir string `json:"dst_dir"`
+	DryRun bool   `json:"dry_run"`
+	model.SyncArgs
+}
+
+func FsSync(c *gin.Context) {
+	var req SyncReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	srcDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	dstDir, err := user.JoinPath(req.DstDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if !common.HasPermission(user, model.ACLCopy, user.CanCopy(), srcDir, dstDir) ||
+		req.Delete && !common.HasPermission(user, model.ACLRemove, user.CanRemove(), dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if req.DryRun {
+		actions, err := fs.PlanSync(c.Request.Context(), srcDir, dstDir, req.SyncArgs)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		common.SuccessResp(c, gin.H{
+			"actions": actions,
+		})
+		return
+	}
+	t, err := fs.Sync(c.Request.Context(), srcDir, dstDir, req.SyncArgs)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	var tasks []task.TaskExtensionInfo
+	if t != nil {
+		tasks = append(tasks, t)
+	}
+	common.SuccessResp(c, gin.H{
+		"tasks": getTaskInfos(tasks),
+	})
+}
+
 type RenameReq struct {
 	Path      string `json:"path"`
 	Name      string `json:"name"`
@@ -200,10 +283,6 @@ func FsRename(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanRename() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	reqPath, err := user.JoinPath(req.Path)
 	if err == nil {
 		err = checkRelativePath(req.Name)
@@ -212,6 +291,10 @@ func FsRename(c *gin.Context) {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, model.ACLRename, user.CanRename(), reqPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	if !req.Overwrite {
 		dstPath := stdpath.Join(stdpath.Dir(reqPath), req.Name)
 		if dstPath != reqPath {
@@ -228,6 +311,15 @@ func FsRename(c *gin.Context) {
 	common.SuccessResp(c)
 }
 
+// joinNames returns the paths of the names in the dir
+func joinNames(dir string, names []string) []string {
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = stdpath.Join(dir, name)
+	}
+	return paths
+}
+
 func checkRelativePath(path string) error {
 	if strings.ContainsAny(path, "/\\") || path == "" || path == "." || path == ".." {
 		return errs.RelativePath
@@ -251,15 +343,15 @@ func FsRemove(c *gin.Context) {
 		return
 	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanRemove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	reqDir, err := user.JoinPath(req.Dir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, model.ACLRemove, user.CanRemove(), joinNames(reqDir, req.Names)...) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	for _, name := range req.Names {
 		err := fs.Remove(c.Request.Context(), stdpath.Join(reqDir, name))
 		if err != nil {
@@ -283,15 +375,15 @@ func FsRemoveEmptyDirectory(c *gin.Context) {
 	}
 
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	if !user.CanRemove() {
-		common.ErrorResp(c, errs.PermissionDenied, 403)
-		return
-	}
 	srcDir, err := user.JoinPath(req.SrcDir)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
+	if !common.HasPermission(user, model.ACLRemove, user.CanRemove(), srcDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 
 	meta, err := op.GetNearestMeta(srcDir)
 	if err != nil {
@@ -81,7 +81,8 @@ func FsList(c *gin.Context) {
 		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
 		return
 	}
-	if !user.CanWrite() && !common.CanWrite(meta, reqPath) && req.Refresh {
+	canWrite := common.HasPermission(user, model.ACLWrite, user.CanWrite() || common.CanWrite(meta, reqPath), reqPath)
+	if !canWrite && req.Refresh {
 		common.ErrorStrResp(c, "Refresh without permission", 403)
 		return
 	}
@@ -101,7 +102,7 @@ func FsList(c *gin.Context) {
 		Total:    int64(total),
 		Readme:   getReadme(meta, reqPath),
 		Header:   getHeader(meta, reqPath),
-		Write:    user.CanWrite() || common.CanWrite(meta, reqPath),
+		Write:    canWrite,
 		Provider: provider,
 	})
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
@@ -15,6 +16,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 func getLastModified(c *gin.Context) time.Time {
@@ -43,13 +45,18 @@ func FsStream(c *gin.Context) {
 	}
 	asTask := c.GetHeader("As-Task") == "true"
 	overwrite := c.GetHeader("Overwrite") != "false"
+	policy := model.ConflictPolicy(c.GetHeader("Conflict-Policy"))
+	if !policy.Valid() {
+		common.ErrorResp(c, errs.InvalidConflict, 400)
+		return
+	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	path, err = user.JoinPath(path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
-	if !overwrite {
+	if !overwrite && policy == "" {
 		if res, _ := fs.Get(c.Request.Context(), path, &fs.GetArgs{NoLog: true}); res != nil {
 			common.ErrorStrResp(c, "file exists", 403)
 			return
@@ -91,10 +98,15 @@ func FsStream(c *gin.Context) {
 		WebPutAsTask: asTask,
 	}
 	var t task.TaskExtensionInfo
+	ctx := transferCtx(c, c.GetHeader("Verify") == "true", policy)
 	if asTask {
-		t, err = fs.PutAsTask(c.Request.Context(), dir, s)
+		t, err = fs.PutAsTask(ctx, dir, s)
 	} else {
-		err = fs.PutDirectly(c.Request.Context(), dir, s, true)
+		err = fs.PutDirectly(ctx, dir, s, true)
+	}
+	if errors.Is(err, errs.ObjectSkipped) {
+		common.SuccessResp(c, gin.H{"skipped": true})
+		return
 	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
@@ -124,13 +136,18 @@ func FsForm(c *gin.Context) {
 	}
 	asTask := c.GetHeader("As-Task") == "true"
 	overwrite := c.GetHeader("Overwrite") != "false"
+	policy := model.ConflictPolicy(c.GetHeader("Conflict-Policy"))
+	if !policy.Valid() {
+		common.ErrorResp(c, errs.InvalidConflict, 400)
+		return
+	}
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	path, err = user.JoinPath(path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
 		return
 	}
-	if !overwrite {
+	if !overwrite && policy == "" {
 		if res, _ := fs.Get(c.Request.Context(), path, &fs.GetArgs{NoLog: true}); res != nil {
 			common.ErrorStrResp(c, "file exists", 403)
 			return
@@ -183,13 +200,18 @@ func FsForm(c *gin.Context) {
 		WebPutAsTask: asTask,
 	}
 	var t task.TaskExtensionInfo
+	ctx := transferCtx(c, c.GetHeader("Verify") == "true", policy)
 	if asTask {
 		s.Reader = struct {
 			io.Reader
 		}{f}
-		t, err = fs.PutAsTask(c.Request.Context(), dir, s)
+		t, err = fs.PutAsTask(ctx, dir, s)
 	} else {
-		err = fs.PutDirectly(c.Request.Context(), dir, s, true)
+		err = fs.PutDirectly(ctx, dir, s, true)
+	}
+	if errors.Is(err, errs.ObjectSkipped) {
+		common.SuccessResp(c, gin.H{"skipped": true})
+		return
 	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
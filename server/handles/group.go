@@ -0,0 +1,162 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func ListGroups(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	groups, total, err := op.GetGroups(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: groups,
+		Total:   total,
+	})
+}
+
+func GetGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	group, err := op.GetGroupById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, group)
+}
+
+func CreateGroup(c *gin.Context) {
+	var req model.Group
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.CreateGroup(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func UpdateGroup(c *gin.Context) {
+	var req model.Group
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.UpdateGroup(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteGroup(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteGroupById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListACLs(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	acls, total, err := op.GetACLs(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: acls,
+		Total:   total,
+	})
+}
+
+func GetACL(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	acl, err := op.GetACLById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, acl)
+}
+
+// aclErrStatus responds 400 to invalid entries
+func aclErrStatus(err error) int {
+	if errors.Is(err, errs.InvalidACLSubject) || errors.Is(err, errs.InvalidACLPerm) {
+		return 400
+	}
+	return 500
+}
+
+func CreateACL(c *gin.Context) {
+	var req model.ACL
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.CreateACL(&req); err != nil {
+		common.ErrorResp(c, err, aclErrStatus(err), true)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func UpdateACL(c *gin.Context) {
+	var req model.ACL
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.UpdateACL(&req); err != nil {
+		common.ErrorResp(c, err, aclErrStatus(err), true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteACL(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteACLById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
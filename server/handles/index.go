@@ -4,7 +4,10 @@ import (
 	"context"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/search"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -71,6 +74,50 @@ func UpdateIndex(c *gin.Context) {
 	common.SuccessResp(c)
 }
 
+type UpdateStorageIndexReq struct {
+	StorageIds []uint `json:"storage_ids"`
+}
+
+// UpdateStorageIndex applies the diffs between listings and the index of the storages
+func UpdateStorageIndex(c *gin.Context) {
+	var req UpdateStorageIndexReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if search.Running() {
+		common.ErrorStrResp(c, "index is running", 400)
+		return
+	}
+	if !search.Config(c).AutoUpdate {
+		common.ErrorStrResp(c, "update is not supported for current index", 400)
+		return
+	}
+	var storages []driver.Driver
+	for _, id := range req.StorageIds {
+		storage, err := db.GetStorageById(id)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		storageDriver, err := op.GetStorageByMountPath(storage.MountPath)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		storages = append(storages, storageDriver)
+	}
+	go func() {
+		for _, storage := range storages {
+			err := search.UpdateStorageIndex(context.Background(), storage)
+			if err != nil {
+				log.Errorf("update index of %s error: %+v", storage.GetStorage().MountPath, err)
+			}
+		}
+	}()
+	common.SuccessResp(c)
+}
+
 func StopIndex(c *gin.Context) {
 	quit := search.Quit.Load()
 	if quit == nil {
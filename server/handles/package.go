@@ -0,0 +1,164 @@
+package handles
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	stdpath "path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/archive/zip"
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type PackageDownloadReq struct {
+	SrcDir   string        `json:"src_dir" form:"src_dir"`
+	Names    StringOrArray `json:"names" form:"names"`
+	Password string        `json:"password" form:"password"`
+}
+
+// FsPackageDownload checks the access of the objects and returns a signed url
+// to download them as a zip file
+func FsPackageDownload(c *gin.Context) {
+	if !setting.GetBool(conf.PackageDownload) {
+		common.ErrorStrResp(c, "package download is disabled", 403)
+		return
+	}
+	var req PackageDownloadReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	srcDir, err := user.JoinPath(req.SrcDir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	if len(req.Names) == 0 && srcDir == "/" {
+		common.ErrorStrResp(c, "can't download the root folder", 400)
+		return
+	}
+	meta, err := op.GetNearestMeta(srcDir)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	paths := []string{srcDir}
+	for _, name := range req.Names {
+		if name == "" || strings.ContainsAny(name, "/\\") {
+			common.ErrorStrResp(c, fmt.Sprintf("invalid name: %s", name), 400)
+			return
+		}
+		paths = append(paths, stdpath.Join(srcDir, name))
+	}
+	for _, p := range paths {
+		if !common.CanAccess(user, meta, p, req.Password) {
+			common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+			return
+		}
+	}
+	query := url.Values{}
+	for _, name := range req.Names {
+		query.Add("names", name)
+	}
+	query.Set("uid", strconv.FormatUint(uint64(user.ID), 10))
+	query.Set("sign", sign.SignPackage(srcDir, req.Names, user.ID))
+	common.SuccessResp(c, gin.H{
+		"url": fmt.Sprintf("%s/z%s?%s", common.GetApiUrl(c.Request.Context()),
+			utils.EncodePath(srcDir, true), query.Encode()),
+	})
+}
+
+// PackageDown streams the objects signed by FsPackageDownload as a zip file in store mode,
+// the names are resolved in the path, or the path itself is downloaded if no names given
+func PackageDown(c *gin.Context) {
+	if !setting.GetBool(conf.PackageDownload) {
+		common.ErrorStrResp(c, "package download is disabled", 403)
+		return
+	}
+	srcDir := utils.FixAndCleanPath(c.Param("path"))
+	names := c.QueryArray("names")
+	uid, err := strconv.ParseUint(c.Query("uid"), 10, 64)
+	if err != nil {
+		common.ErrorStrResp(c, "invalid uid", 400)
+		return
+	}
+	if err = sign.VerifyPackage(srcDir, names, uint(uid), c.Query("sign")); err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	user, err := op.GetUserById(uint(uid))
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	if user.Disabled {
+		common.ErrorStrResp(c, "current user is disabled", 401)
+		return
+	}
+	common.GinWithValue(c, conf.UserKey, user)
+	archiveName := stdpath.Base(srcDir)
+	if len(names) == 0 {
+		if srcDir == "/" {
+			common.ErrorStrResp(c, "can't download the root folder", 400)
+			return
+		}
+		srcDir, names = stdpath.Dir(srcDir), []string{archiveName}
+	} else if len(names) == 1 || archiveName == "/" {
+		archiveName = names[0]
+	}
+	// the password of the meta of the src dir is checked when signing,
+	// objects protected by other metas are skipped
+	rootMeta, _ := op.GetNearestMeta(srcDir)
+	canAccess := func(path string) bool {
+		meta, _ := op.GetNearestMeta(path)
+		pass := ""
+		if meta != nil && rootMeta != nil && meta.Path == rootMeta.Path {
+			pass = meta.Password
+		}
+		return common.CanAccess(user, meta, path, pass)
+	}
+	ctx := c.Request.Context()
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", utils.GenerateContentDisposition(archiveName+".zip"))
+	aw := zip.NewStreamWriter(c.Writer)
+	err = fs.WalkArchiveEntries(ctx, srcDir, names, func(path, name string, obj model.Obj) error {
+		if !canAccess(path) {
+			return filepath.SkipDir
+		}
+		return fs.WriteArchiveEntry(ctx, aw, path, name, obj, nil)
+	})
+	if err == nil {
+		err = aw.Close()
+	}
+	audit.Record(ctx, audit.Download, srcDir, "", int64(c.Writer.Size()), err)
+	if err == nil {
+		return
+	}
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !errors.Is(err, context.Canceled) {
+		log.Errorf("failed package download of [%s]: %+v", srcDir, err)
+	}
+}
@@ -0,0 +1,117 @@
+package handles
+
+import (
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// relativeToBase returns the path relative to the base path of the user,
+// false if the path is out of the base path
+func relativeToBase(user *model.User, path string) (string, bool) {
+	base := strings.TrimSuffix(utils.FixAndCleanPath(user.BasePath), "/")
+	rel, ok := strings.CutPrefix(path, base)
+	if !ok || (rel != "" && rel[0] != '/') {
+		return "", false
+	}
+	return utils.FixAndCleanPath(rel), true
+}
+
+func ListRecycleItems(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	var items []model.RecycleItem
+	var total int64
+	var err error
+	if user.IsAdmin() {
+		items, total, err = op.GetRecycleItems(req.Page, req.PerPage)
+	} else {
+		items, total, err = op.GetRecycleItemsByUserId(user.ID, req.Page, req.PerPage)
+	}
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	for i := range items {
+		if rel, ok := relativeToBase(user, items[i].Path); ok {
+			items[i].Path = rel
+		}
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: items,
+		Total:   total,
+	})
+}
+
+type RecycleItemsReq struct {
+	Ids []uint `json:"ids" binding:"required"`
+}
+
+// getRecycleItems gets the items the user may restore or purge
+func getRecycleItems(c *gin.Context) ([]*model.RecycleItem, bool) {
+	var req RecycleItemsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return nil, false
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	items := make([]*model.RecycleItem, 0, len(req.Ids))
+	for _, id := range req.Ids {
+		item, err := op.GetRecycleItemById(id)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return nil, false
+		}
+		if !user.IsAdmin() {
+			if _, ok := relativeToBase(user, item.Path); item.UserId != user.ID || !ok {
+				common.ErrorResp(c, errs.PermissionDenied, 403)
+				return nil, false
+			}
+		}
+		if !common.HasPermission(user, model.ACLRemove, user.CanRemove(), item.Path) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return nil, false
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+func RestoreRecycleItems(c *gin.Context) {
+	items, ok := getRecycleItems(c)
+	if !ok {
+		return
+	}
+	for _, item := range items {
+		if err := op.RestoreRecycleItem(c.Request.Context(), item); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
+
+func PurgeRecycleItems(c *gin.Context) {
+	items, ok := getRecycleItems(c)
+	if !ok {
+		return
+	}
+	for _, item := range items {
+		if err := op.PurgeRecycleItem(c.Request.Context(), item); err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,239 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type S3KeyCreateReq struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type S3KeyCreateResp struct {
+	model.S3Key
+	// only shown here
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+type S3BucketReq struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name" binding:"required"`
+	Path string `json:"path"`
+}
+
+func CreateMyS3Key(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req S3KeyCreateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	k := &model.S3Key{Name: req.Name}
+	secret, err := op.CreateS3Key(userObj, k)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, S3KeyCreateResp{
+		S3Key:           *k,
+		SecretAccessKey: secret,
+	})
+}
+
+func ListMyS3Keys(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	listS3Keys(c, userObj)
+}
+
+func DeleteMyS3Key(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	keyId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	k, err := op.GetS3KeyByIdAndUserId(uint(keyId), userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get s3 key", 404)
+		return
+	}
+	if err = op.DeleteS3KeyById(k.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListMyS3Buckets(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	listS3Buckets(c, userObj)
+}
+
+func CreateMyS3Bucket(c *gin.Context) {
+	saveMyS3Bucket(c, false)
+}
+
+func UpdateMyS3Bucket(c *gin.Context) {
+	saveMyS3Bucket(c, true)
+}
+
+func saveMyS3Bucket(c *gin.Context, update bool) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req S3BucketReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	b := &model.S3Bucket{UserID: userObj.ID, Name: req.Name, Path: req.Path}
+	var err error
+	if update {
+		if _, err = op.GetS3BucketByIdAndUserId(req.ID, userObj.ID); err != nil {
+			common.ErrorStrResp(c, "failed to get s3 bucket", 404)
+			return
+		}
+		b.ID = req.ID
+		err = op.UpdateS3Bucket(b)
+	} else {
+		err = op.CreateS3Bucket(b)
+	}
+	if err != nil {
+		if errors.Is(err, errs.InvalidS3BucketName) || errors.Is(err, errs.S3BucketExists) {
+			common.ErrorResp(c, err, 400)
+		} else {
+			common.ErrorResp(c, err, 500, true)
+		}
+		return
+	}
+	common.SuccessResp(c, b)
+}
+
+func DeleteMyS3Bucket(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	bucketId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	b, err := op.GetS3BucketByIdAndUserId(uint(bucketId), userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get s3 bucket", 404)
+		return
+	}
+	if err = op.DeleteS3BucketById(b.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListS3Keys(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	userObj, err := op.GetUserById(uint(userId))
+	if err != nil {
+		common.ErrorStrResp(c, "user invalid", 404)
+		return
+	}
+	listS3Keys(c, userObj)
+}
+
+func DeleteS3Key(c *gin.Context) {
+	keyId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.DeleteS3KeyById(uint(keyId)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListS3Buckets(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	userObj, err := op.GetUserById(uint(userId))
+	if err != nil {
+		common.ErrorStrResp(c, "user invalid", 404)
+		return
+	}
+	listS3Buckets(c, userObj)
+}
+
+func DeleteS3Bucket(c *gin.Context) {
+	bucketId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err = op.DeleteS3BucketById(uint(bucketId)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func listS3Keys(c *gin.Context, userObj *model.User) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	keys, total, err := op.GetS3KeysByUserId(userObj.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: keys,
+		Total:   total,
+	})
+}
+
+func listS3Buckets(c *gin.Context, userObj *model.User) {
+	buckets, err := op.GetS3BucketsByUserId(userObj.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, buckets)
+}
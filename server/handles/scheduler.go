@@ -0,0 +1,123 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/scheduler"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListScheduledJobs(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	jobs, total, err := scheduler.GetJobs(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: jobs,
+		Total:   total,
+	})
+}
+
+func GetScheduledJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	job, err := scheduler.GetJobById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, job)
+}
+
+func CreateScheduledJob(c *gin.Context) {
+	var req model.ScheduledJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	// the job runs as the admin who creates it
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	req.ID, req.UserId = 0, user.ID
+	req.LastRunTime, req.LastSuccess, req.LastError = nil, false, ""
+	if err := scheduler.CreateJob(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func UpdateScheduledJob(c *gin.Context) {
+	var req model.ScheduledJob
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := scheduler.UpdateJob(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteScheduledJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := scheduler.DeleteJobById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func RunScheduledJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	r, err := scheduler.RunJob(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, r)
+}
+
+type ListScheduledJobRunsReq struct {
+	model.PageReq
+	JobId uint `json:"job_id" form:"job_id" binding:"required"`
+}
+
+func ListScheduledJobRuns(c *gin.Context) {
+	var req ListScheduledJobRunsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	runs, total, err := scheduler.GetRuns(req.JobId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: runs,
+		Total:   total,
+	})
+}
@@ -45,6 +45,10 @@ func Search(c *gin.Context) {
 		return
 	}
 	nodes, total, err := search.Search(c, req.SearchReq)
+	if errs.IsNotSupportError(err) {
+		common.ErrorResp(c, err, 400)
+		return
+	}
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
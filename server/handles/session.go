@@ -0,0 +1,138 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken issues a new token on the session of the refresh token, the refresh token is renewed too
+func RefreshToken(c *gin.Context) {
+	var req RefreshTokenReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	session, user, refreshToken, err := op.RefreshSession(req.RefreshToken, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	token, err := common.GenerateToken(user, session.SID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{"token": token, "refresh_token": refreshToken})
+}
+
+func ListMySessions(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var sid string
+	if claims, err := common.ParseToken(c.GetHeader("Authorization")); err == nil {
+		sid = claims.SID
+	}
+	listSessions(c, userObj, sid)
+}
+
+func RevokeMySession(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	sessionId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	session, err := op.GetSessionByIdAndUserId(uint(sessionId), userObj.ID)
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get session", 404)
+		return
+	}
+	if err = op.RevokeSession(session); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ListSessions(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	userObj, err := op.GetUserById(uint(userId))
+	if err != nil {
+		common.ErrorStrResp(c, "user invalid", 404)
+		return
+	}
+	listSessions(c, userObj, "")
+}
+
+func RevokeSession(c *gin.Context) {
+	userId, err := strconv.Atoi(c.Query("uid"))
+	if err != nil {
+		common.ErrorStrResp(c, "user id format invalid", 400)
+		return
+	}
+	// all the sessions of the user without id
+	if c.Query("id") == "" {
+		if err = op.RevokeUserSessions(uint(userId)); err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+		common.SuccessResp(c)
+		return
+	}
+	sessionId, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	session, err := op.GetSessionByIdAndUserId(uint(sessionId), uint(userId))
+	if err != nil {
+		common.ErrorStrResp(c, "failed to get session", 404)
+		return
+	}
+	if err = op.RevokeSession(session); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func listSessions(c *gin.Context, userObj *model.User, currentSID string) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	sessions, total, err := op.GetSessionsByUserId(userObj.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = currentSID != "" && sessions[i].SID == currentSID
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: sessions,
+		Total:   total,
+	})
+}
@@ -0,0 +1,349 @@
+package handles
+
+import (
+	"fmt"
+	"net/http"
+	stdpath "path"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type ShareReq struct {
+	ID          string     `json:"id"`
+	Path        string     `json:"path" binding:"required"`
+	Password    string     `json:"password"`
+	Expires     *time.Time `json:"expires"`
+	MaxAccessed int64      `json:"max_accessed"`
+	AllowUpload bool       `json:"allow_upload"`
+	Disabled    bool       `json:"disabled"`
+	Remark      string     `json:"remark"`
+}
+
+// shareResp shows the path relative to the base path of the user
+func shareResp(s model.Share, user *model.User) model.Share {
+	if rel, ok := relativeToBase(user, s.Path); ok {
+		s.Path = rel
+	}
+	return s
+}
+
+// checkSharePath validates that the user may share the path, it returns the full path
+func checkSharePath(c *gin.Context, user *model.User, req *ShareReq) (string, bool) {
+	if req.MaxAccessed < 0 {
+		common.ErrorStrResp(c, "max_accessed must not be negative", 400)
+		return "", false
+	}
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return "", false
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return "", false
+		}
+	}
+	if !common.CanAccess(user, meta, reqPath, "") {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return "", false
+	}
+	if req.AllowUpload && !common.HasPermission(user, model.ACLWrite, user.CanWrite(), reqPath) {
+		common.ErrorStrResp(c, "you have no permission to upload", 403)
+		return "", false
+	}
+	if _, err = fs.Get(c.Request.Context(), reqPath, &fs.GetArgs{}); err != nil {
+		common.ErrorResp(c, err, 404)
+		return "", false
+	}
+	return reqPath, true
+}
+
+func CreateShare(c *gin.Context) {
+	var req ShareReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, ok := checkSharePath(c, user, &req)
+	if !ok {
+		return
+	}
+	s := &model.Share{
+		UserId:      user.ID,
+		Path:        reqPath,
+		Password:    req.Password,
+		Expires:     req.Expires,
+		MaxAccessed: req.MaxAccessed,
+		AllowUpload: req.AllowUpload,
+		Disabled:    req.Disabled,
+		Remark:      req.Remark,
+	}
+	if err := op.CreateShare(s); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, shareResp(*s, user))
+}
+
+func UpdateShare(c *gin.Context) {
+	var req ShareReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, ok := getShare(c, user, req.ID)
+	if !ok {
+		return
+	}
+	reqPath, ok := checkSharePath(c, user, &req)
+	if !ok {
+		return
+	}
+	s.Path = reqPath
+	s.Password = req.Password
+	s.Expires = req.Expires
+	s.MaxAccessed = req.MaxAccessed
+	s.AllowUpload = req.AllowUpload
+	s.Disabled = req.Disabled
+	s.Remark = req.Remark
+	if err := op.UpdateShare(s); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, shareResp(*s, user))
+}
+
+func GetShare(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, ok := getShare(c, user, c.Query("id"))
+	if !ok {
+		return
+	}
+	common.SuccessResp(c, shareResp(*s, user))
+}
+
+func ListMyShares(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	shares, total, err := op.GetSharesByUserId(user.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	for i := range shares {
+		shares[i] = shareResp(shares[i], user)
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: shares,
+		Total:   total,
+	})
+}
+
+func DeleteShare(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, ok := getShare(c, user, c.Query("id"))
+	if !ok {
+		return
+	}
+	if err := op.DeleteShareById(s.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// ListShares lists the shares of all users, for admin
+func ListShares(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	shares, total, err := op.GetShares(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: shares,
+		Total:   total,
+	})
+}
+
+// getShare gets a share of the user, admin can get any share
+func getShare(c *gin.Context, user *model.User, id string) (*model.Share, bool) {
+	var s *model.Share
+	var err error
+	if user.IsAdmin() {
+		s, err = op.GetShareById(id)
+	} else {
+		s, err = op.GetShareByIdAndUserId(id, user.ID)
+	}
+	if err != nil {
+		if errors.Is(err, errs.ShareNotFound) {
+			common.ErrorResp(c, err, 404)
+		} else {
+			common.ErrorResp(c, err, 500, true)
+		}
+		return nil, false
+	}
+	return s, true
+}
+
+type ShareInfoResp struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Expires     *time.Time `json:"expires"`
+	AllowUpload bool       `json:"allow_upload"`
+}
+
+// the following handlers serve the public /s/:sid routes, the user in context is the share visitor
+
+func shareReqPath(c *gin.Context, reqPath, password string) (string, *model.Meta, bool) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return "", nil, false
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return "", nil, false
+		}
+	}
+	common.GinWithValue(c, conf.MetaKey, meta)
+	if !common.CanAccess(user, meta, reqPath, password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return "", nil, false
+	}
+	return reqPath, meta, true
+}
+
+func ShareInfo(c *gin.Context) {
+	s := c.Request.Context().Value(conf.ShareKey).(*model.Share)
+	common.SuccessResp(c, ShareInfoResp{
+		ID:          s.ID,
+		Name:        stdpath.Base(s.Path),
+		Expires:     s.Expires,
+		AllowUpload: s.AllowUpload,
+	})
+}
+
+func ShareList(c *gin.Context) {
+	var req ListReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	reqPath, meta, ok := shareReqPath(c, req.Path, req.Password)
+	if !ok {
+		return
+	}
+	objs, err := fs.List(c.Request.Context(), reqPath, &fs.ListArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	total, objs := pagination(objs, &req.PageReq)
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	common.SuccessResp(c, FsListResp{
+		Content:  toShareObjsResp(objs),
+		Total:    int64(total),
+		Readme:   getReadme(meta, reqPath),
+		Header:   getHeader(meta, reqPath),
+		Write:    common.HasPermission(user, model.ACLWrite, user.CanWrite() || common.CanWrite(meta, reqPath), reqPath),
+		Provider: "unknown",
+	})
+}
+
+func ShareGet(c *gin.Context) {
+	var req FsGetReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	reqPath, meta, ok := shareReqPath(c, req.Path, req.Password)
+	if !ok {
+		return
+	}
+	obj, err := fs.Get(c.Request.Context(), reqPath, &fs.GetArgs{})
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	var rawURL string
+	if !obj.IsDir() {
+		s := c.Request.Context().Value(conf.ShareKey).(*model.Share)
+		rawURL = fmt.Sprintf("%s/s/%s/d%s",
+			common.GetApiUrl(c),
+			s.ID,
+			utils.EncodePath(utils.FixAndCleanPath(req.Path), true))
+	}
+	common.SuccessResp(c, FsGetResp{
+		ObjResp:  toShareObjsResp([]model.Obj{obj})[0],
+		RawURL:   rawURL,
+		Readme:   getReadme(meta, reqPath),
+		Header:   getHeader(meta, reqPath),
+		Provider: "unknown",
+	})
+}
+
+func ShareDown(c *gin.Context) {
+	reqPath, _, ok := shareReqPath(c, c.Param("path"), c.Query("password"))
+	if !ok {
+		return
+	}
+	common.GinWithValue(c, conf.PathKey, reqPath)
+	if c.Request.Method != http.MethodHead {
+		s := c.Request.Context().Value(conf.ShareKey).(*model.Share)
+		if err := op.AccessShareDownload(s, c.ClientIP(), c.Param("path"), c.GetHeader("Range")); err != nil {
+			common.ErrorResp(c, err, 403)
+			return
+		}
+	}
+	Down(c)
+}
+
+func ShareStream(c *gin.Context) {
+	s := c.Request.Context().Value(conf.ShareKey).(*model.Share)
+	if err := op.AccessShare(s); err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	// visitors may add files but never replace existing ones, and can't track tasks
+	c.Request.Header.Set("Overwrite", "false")
+	c.Request.Header.Del("As-Task")
+	FsStream(c)
+}
+
+// toShareObjsResp hides the storage paths and signs, the share grants the access
+func toShareObjsResp(objs []model.Obj) []ObjResp {
+	resp := toObjsResp(objs, "", false)
+	for i := range resp {
+		resp[i].Path = ""
+		resp[i].Sign = ""
+	}
+	return resp
+}
@@ -239,14 +239,19 @@ func OIDCLoginCallback(c *gin.Context) {
 			c.Redirect(302, common.GetApiUrl(c)+"/@manage?sso_id="+userID)
 			return
 		}
+		origin, err := siteOrigin(c)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
 		html := fmt.Sprintf(`<!DOCTYPE html>
 				<head></head>
 				<body>
 				<script>
-				window.opener.postMessage({"sso_id": "%s"}, "*")
+				window.opener.postMessage({"sso_id": "%s"}, "%s")
 				window.close()
 				</script>
-				</body>`, userID)
+				</body>`, userID, origin)
 		c.Data(200, "text/html; charset=utf-8", []byte(html))
 		return
 	}
@@ -258,7 +263,7 @@ func OIDCLoginCallback(c *gin.Context) {
 				common.ErrorResp(c, err, 400)
 			}
 		}
-		token, err := common.GenerateToken(user)
+		token, refreshToken, err := common.CreateSession(c, user)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
 		}
@@ -266,14 +271,19 @@ func OIDCLoginCallback(c *gin.Context) {
 			c.Redirect(302, common.GetApiUrl(c)+"/@login?token="+token)
 			return
 		}
+		origin, err := siteOrigin(c)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
 		html := fmt.Sprintf(`<!DOCTYPE html>
 				<head></head>
 				<body>
 				<script>
-				window.opener.postMessage({"token":"%s"}, "*")
+				window.opener.postMessage({"token":"%s","refresh_token":"%s"}, "%s")
 				window.close()
 				</script>
-				</body>`, token)
+				</body>`, token, refreshToken, origin)
 		c.Data(200, "text/html; charset=utf-8", []byte(html))
 		return
 	}
@@ -404,14 +414,19 @@ func SSOLoginCallback(c *gin.Context) {
 			c.Redirect(302, common.GetApiUrl(c)+"/@manage?sso_id="+userID)
 			return
 		}
+		origin, err := siteOrigin(c)
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
 		html := fmt.Sprintf(`<!DOCTYPE html>
 				<head></head>
 				<body>
 				<script>
-				window.opener.postMessage({"sso_id": "%s"}, "*")
+				window.opener.postMessage({"sso_id": "%s"}, "%s")
 				window.close()
 				</script>
-				</body>`, userID)
+				</body>`, userID, origin)
 		c.Data(200, "text/html; charset=utf-8", []byte(html))
 		return
 	}
@@ -424,7 +439,7 @@ func SSOLoginCallback(c *gin.Context) {
 			return
 		}
 	}
-	token, err := common.GenerateToken(user)
+	token, refreshToken, err := common.CreateSession(c, user)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 	}
@@ -432,13 +447,28 @@ func SSOLoginCallback(c *gin.Context) {
 		c.Redirect(302, common.GetApiUrl(c)+"/@login?token="+token)
 		return
 	}
+	origin, err := siteOrigin(c)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
 	html := fmt.Sprintf(`<!DOCTYPE html>
 							<head></head>
 							<body>
 							<script>
-							window.opener.postMessage({"token":"%s"}, "*")
+							window.opener.postMessage({"token":"%s","refresh_token":"%s"}, "%s")
 							window.close()
 							</script>
-							</body>`, token)
+							</body>`, token, refreshToken, origin)
 	c.Data(200, "text/html; charset=utf-8", []byte(html))
 }
+
+// siteOrigin is the origin the login popup posts the token to, so only the pages of the site can read it
+func siteOrigin(c *gin.Context) (string, error) {
+	api := common.GetApiUrl(c)
+	u, err := url.Parse(api)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid site url %q", api)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
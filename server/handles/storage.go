@@ -2,10 +2,12 @@ package handles
 
 import (
 	"context"
+	"sort"
 	"strconv"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -26,6 +28,11 @@ func ListStorages(c *gin.Context) {
 		common.ErrorResp(c, err, 500)
 		return
 	}
+	for i := range storages {
+		if err = op.DecryptStorage(&storages[i]); err != nil {
+			storages[i].SetStatus(err.Error())
+		}
+	}
 	common.SuccessResp(c, common.PageResp{
 		Content: storages,
 		Total:   total,
@@ -116,6 +123,10 @@ func GetStorage(c *gin.Context) {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
+	if err = op.DecryptStorage(storage); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
 	common.SuccessResp(c, storage)
 }
 
@@ -150,3 +161,41 @@ func LoadAllStorages(c *gin.Context) {
 	}(storages)
 	common.SuccessResp(c)
 }
+
+type StorageUsageResp struct {
+	ID         uint   `json:"id"`
+	MountPath  string `json:"mount_path"`
+	Driver     string `json:"driver"`
+	TotalSpace int64  `json:"total_space"`
+	UsedSpace  int64  `json:"used_space"`
+	FreeSpace  int64  `json:"free_space"`
+	// why the usage is unavailable, empty if it's got
+	Error string `json:"error"`
+}
+
+func StorageUsage(c *gin.Context) {
+	storages := op.GetAllStorages()
+	sort.Slice(storages, func(i, j int) bool {
+		if storages[i].GetStorage().Order == storages[j].GetStorage().Order {
+			return storages[i].GetStorage().MountPath < storages[j].GetStorage().MountPath
+		}
+		return storages[i].GetStorage().Order < storages[j].GetStorage().Order
+	})
+	resp := make([]StorageUsageResp, 0, len(storages))
+	for _, storage := range storages {
+		s := storage.GetStorage()
+		usage := StorageUsageResp{ID: s.ID, MountPath: s.MountPath, Driver: s.Driver, FreeSpace: -1}
+		details, err := op.GetStorageDetails(c.Request.Context(), storage)
+		if err != nil {
+			if errs.IsNotImplement(err) {
+				usage.Error = "not supported by the driver"
+			} else {
+				usage.Error = err.Error()
+			}
+		} else {
+			usage.TotalSpace, usage.UsedSpace, usage.FreeSpace = details.TotalSpace, details.UsedSpace, details.FreeSpace()
+		}
+		resp = append(resp, usage)
+	}
+	common.SuccessResp(c, resp)
+}
@@ -2,6 +2,7 @@ package handles
 
 import (
 	"math"
+	"strconv"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -28,6 +29,8 @@ type TaskInfo struct {
 	EndTime     *time.Time  `json:"end_time"`
 	TotalBytes  int64       `json:"total_bytes"`
 	Error       string      `json:"error"`
+	Paused      bool        `json:"paused"`
+	Priority    int         `json:"priority"`
 }
 
 func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
@@ -58,6 +61,8 @@ func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
 		EndTime:     task.GetEndTime(),
 		TotalBytes:  task.GetTotalBytes(),
 		Error:       errMsg,
+		Paused:      task.IsPaused(),
+		Priority:    task.GetPriority(),
 	}
 }
 
@@ -134,9 +139,9 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 		}
 		common.SuccessResp(c, getTaskInfos(manager.GetByCondition(func(task T) bool {
 			// avoid directly passing the user object into the function to reduce closure size
-			return (isAdmin || uid == task.GetCreator().ID) &&
+			return (isAdmin || uid == task.GetCreator().ID) && (task.IsPaused() ||
 				argsContains(task.GetState(), tache.StatePending, tache.StateRunning, tache.StateCanceling,
-					tache.StateErrored, tache.StateFailing, tache.StateWaitingRetry, tache.StateBeforeRetry)
+					tache.StateErrored, tache.StateFailing, tache.StateWaitingRetry, tache.StateBeforeRetry))
 		})))
 	})
 	g.GET("/done", func(c *gin.Context) {
@@ -147,7 +152,7 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 			return
 		}
 		common.SuccessResp(c, getTaskInfos(manager.GetByCondition(func(task T) bool {
-			return (isAdmin || uid == task.GetCreator().ID) &&
+			return (isAdmin || uid == task.GetCreator().ID) && !task.IsPaused() &&
 				argsContains(task.GetState(), tache.StateCanceled, tache.StateFailed, tache.StateSucceeded)
 		})))
 	})
@@ -163,7 +168,35 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 		common.SuccessResp(c)
 	}))
 	g.POST("/retry", getTargetedHandler(manager, func(c *gin.Context, task T) {
-		manager.Retry(task.GetID())
+		retry(manager, task)
+		common.SuccessResp(c)
+	}))
+	g.POST("/pause", getTargetedHandler(manager, func(c *gin.Context, t T) {
+		if err := task.Pause(t); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		common.SuccessResp(c)
+	}))
+	g.POST("/resume", getTargetedHandler(manager, func(c *gin.Context, t T) {
+		if err := task.Resume(manager, t); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		common.SuccessResp(c)
+	}))
+	g.POST("/priority", getTargetedHandler(manager, func(c *gin.Context, t T) {
+		priority, err := strconv.Atoi(c.Query("priority"))
+		if err != nil {
+			common.ErrorStrResp(c, "invalid priority", 400)
+			return
+		}
+		// the tasks of all the users share the queue, only admins may put one ahead of the default
+		if isAdmin, _, _ := getUserInfo(c); !isAdmin && priority > 0 {
+			common.ErrorStrResp(c, "only admins can raise the priority", 403)
+			return
+		}
+		task.SetPriority(t, priority)
 		common.SuccessResp(c)
 	}))
 	g.POST("/cancel_some", getBatchHandler(manager, func(task T) {
@@ -173,7 +206,13 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 		manager.Remove(task.GetID())
 	}))
 	g.POST("/retry_some", getBatchHandler(manager, func(task T) {
-		manager.Retry(task.GetID())
+		retry(manager, task)
+	}))
+	g.POST("/pause_some", getBatchHandler(manager, func(t T) {
+		_ = task.Pause(t)
+	}))
+	g.POST("/resume_some", getBatchHandler(manager, func(t T) {
+		_ = task.Resume(manager, t)
 	}))
 	g.POST("/clear_done", func(c *gin.Context) {
 		isAdmin, uid, ok := getUserInfo(c)
@@ -183,7 +222,7 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 			return
 		}
 		manager.RemoveByCondition(func(task T) bool {
-			return (isAdmin || uid == task.GetCreator().ID) &&
+			return (isAdmin || uid == task.GetCreator().ID) && !task.IsPaused() &&
 				argsContains(task.GetState(), tache.StateCanceled, tache.StateFailed, tache.StateSucceeded)
 		})
 		common.SuccessResp(c)
@@ -208,7 +247,7 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 			return
 		}
 		tasks := manager.GetByCondition(func(task T) bool {
-			return (isAdmin || uid == task.GetCreator().ID) && task.GetState() == tache.StateFailed
+			return (isAdmin || uid == task.GetCreator().ID) && !task.IsPaused() && task.GetState() == tache.StateFailed
 		})
 		for _, t := range tasks {
 			manager.Retry(t.GetID())
@@ -217,6 +256,15 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 	})
 }
 
+// retry resumes the task if it is paused, the paused task needs a new ctx
+func retry[T task.TaskExtensionInfo](manager task.Manager[T], t T) {
+	if t.IsPaused() {
+		_ = task.Resume(manager, t)
+		return
+	}
+	manager.Retry(t.GetID())
+}
+
 func SetupTaskRoute(g *gin.RouterGroup) {
 	taskRoute(g.Group("/upload"), fs.UploadTaskManager)
 	taskRoute(g.Group("/copy"), fs.CopyTaskManager)
@@ -225,4 +273,6 @@ func SetupTaskRoute(g *gin.RouterGroup) {
 	taskRoute(g.Group("/offline_download_transfer"), tool.TransferTaskManager)
 	taskRoute(g.Group("/decompress"), fs.ArchiveDownloadTaskManager)
 	taskRoute(g.Group("/decompress_upload"), fs.ArchiveContentUploadTaskManager)
+	taskRoute(g.Group("/compress"), fs.ArchiveCompressTaskManager)
+	taskRoute(g.Group("/sync"), fs.SyncTaskManager)
 }
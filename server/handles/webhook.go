@@ -0,0 +1,119 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListWebhooks(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	webhooks, total, err := webhook.GetWebhooks(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: webhooks,
+		Total:   total,
+	})
+}
+
+func GetWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	w, err := webhook.GetWebhookById(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, w)
+}
+
+func CreateWebhook(c *gin.Context) {
+	var req model.Webhook
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = 0
+	if err := webhook.CreateWebhook(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func UpdateWebhook(c *gin.Context) {
+	var req model.Webhook
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := webhook.UpdateWebhook(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := webhook.DeleteWebhookById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func TestWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	d, err := webhook.TestWebhook(c.Request.Context(), uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, d)
+}
+
+type ListWebhookDeliveriesReq struct {
+	model.PageReq
+	WebhookId uint `json:"webhook_id" form:"webhook_id"` // 0 means all webhooks
+}
+
+func ListWebhookDeliveries(c *gin.Context) {
+	var req ListWebhookDeliveriesReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	deliveries, total, err := webhook.GetDeliveries(req.WebhookId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: deliveries,
+		Total:   total,
+	})
+}
@@ -2,8 +2,10 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
@@ -45,6 +47,18 @@ func Auth(c *gin.Context) {
 		c.Next()
 		return
 	}
+	if apiToken := strings.TrimPrefix(token, "Bearer "); op.IsAPIToken(apiToken) {
+		user, err := op.AuthAPIToken(apiToken, c.ClientIP())
+		if err != nil {
+			common.ErrorResp(c, err, 401)
+			c.Abort()
+			return
+		}
+		common.GinWithValue(c, conf.UserKey, user)
+		log.Debugf("use api token: %+v", user)
+		c.Next()
+		return
+	}
 	userClaims, err := common.ParseToken(token)
 	if err != nil {
 		common.ErrorResp(c, err, 401)
@@ -68,6 +82,7 @@ func Auth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	op.TouchSession(userClaims.SID, c.ClientIP())
 	common.GinWithValue(c, conf.UserKey, user)
 	log.Debugf("use login token: %+v", user)
 	c.Next()
@@ -122,6 +137,7 @@ func Authn(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	op.TouchSession(userClaims.SID, c.ClientIP())
 	common.GinWithValue(c, conf.UserKey, user)
 	log.Debugf("use login token: %+v", user)
 	c.Next()
@@ -146,3 +162,27 @@ func AuthAdmin(c *gin.Context) {
 		c.Next()
 	}
 }
+
+// TokenScope requires the api token the request is authenticated by to have the scope
+func TokenScope(scope int32) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.Request.Context().Value(conf.UserKey).(*model.User)
+		if user.Token != nil && !user.Token.HasScope(scope) {
+			common.ErrorStrResp(c, "The api token is out of scope", 403)
+			c.Abort()
+		} else {
+			c.Next()
+		}
+	}
+}
+
+// AuthNotAPIToken rejects the api tokens, for managing the account
+func AuthNotAPIToken(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.Token != nil {
+		common.ErrorResp(c, errs.APITokenNotAllowed, 403)
+		c.Abort()
+	} else {
+		c.Next()
+	}
+}
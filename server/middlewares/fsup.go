@@ -36,10 +36,16 @@ func FsUp(c *gin.Context) {
 			return
 		}
 	}
-	if !(common.CanAccess(user, meta, path, password) && (user.CanWrite() || common.CanWrite(meta, stdpath.Dir(path)))) {
+	if !(common.CanAccess(user, meta, path, password) &&
+		common.HasPermission(user, model.ACLWrite, user.CanWrite() || common.CanWrite(meta, stdpath.Dir(path)), path)) {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
 		c.Abort()
 		return
 	}
+	if err = op.CheckQuota(user, c.Request.ContentLength); err != nil {
+		common.ErrorResp(c, err, 403)
+		c.Abort()
+		return
+	}
 	c.Next()
 }
@@ -0,0 +1,14 @@
+package middlewares
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// CountServed adds the response body size to the served bytes of the endpoint
+func CountServed(endpoint string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		metrics.AddServedBytes(endpoint, c.Writer.Size())
+	}
+}
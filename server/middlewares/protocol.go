@@ -0,0 +1,16 @@
+package middlewares
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Protocol tags the request with the front end it comes from and the client ip,
+// they are recorded by the audit log
+func Protocol(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		common.GinWithValue(c, conf.ProtocolKey, name, conf.ClientIPKey, c.ClientIP())
+		c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// Share resolves the share of the request and sets the user to the share visitor,
+// so the following handlers are scoped to the shared path instead of a user's base path
+func Share(c *gin.Context) {
+	share, err := op.GetShareById(c.Param("sid"))
+	if err != nil {
+		if errors.Is(err, errs.ShareNotFound) {
+			common.ErrorResp(c, err, 404)
+		} else {
+			common.ErrorResp(c, err, 500, true)
+		}
+		c.Abort()
+		return
+	}
+	// the downloads counted before the share is exhausted can still seek or resume
+	if err = share.Valid(); err != nil && !(errors.Is(err, errs.ShareExhausted) &&
+		op.IsShareDownloadResumed(share, c.ClientIP(), c.Param("path"), c.GetHeader("Range"))) {
+		common.ErrorResp(c, err, 403)
+		c.Abort()
+		return
+	}
+	if share.Password != "" {
+		password := c.GetHeader("Share-Password")
+		if password == "" {
+			password = c.Query("pwd")
+		}
+		if subtle.ConstantTimeCompare([]byte(password), []byte(share.Password)) != 1 {
+			common.ErrorResp(c, errs.WrongShareCode, 401)
+			c.Abort()
+			return
+		}
+	}
+	creator, err := op.GetUserById(share.UserId)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		c.Abort()
+		return
+	}
+	if creator.Disabled {
+		common.ErrorResp(c, errs.ShareDisabled, 403)
+		c.Abort()
+		return
+	}
+	common.GinWithValue(c, conf.ShareKey, share)
+	common.GinWithValue(c, conf.UserKey, share.Visitor(creator))
+	c.Next()
+}
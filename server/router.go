@@ -4,6 +4,8 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/message"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/sign"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -34,27 +36,41 @@ func Init(e *gin.Engine) {
 	g.GET("/robots.txt", handles.Robots)
 	g.GET("/manifest.json", static.ManifestJSON)
 	g.GET("/i/:link_name", handles.Plist)
+	if conf.Conf.Metrics.Enable && conf.Conf.Metrics.Listen == "" {
+		// the metrics expose the mount paths and the per-user traffic, only admins may scrape them here
+		g.GET("/metrics", middlewares.Auth, middlewares.AuthAdmin, gin.WrapH(metrics.Handler()))
+	}
 	common.SecretKey = []byte(conf.Conf.JwtSecret)
 	g.Use(middlewares.StoragesLoaded)
+	g.Use(middlewares.Protocol("http"))
 	if conf.Conf.MaxConnections > 0 {
 		g.Use(middlewares.MaxAllowed(conf.Conf.MaxConnections))
 	}
-	WebDav(g.Group("/dav"))
-	S3(g.Group("/s3"))
+	WebDav(g.Group("/dav", middlewares.Protocol("webdav"), middlewares.CountServed("webdav")))
+	S3(g.Group("/s3", middlewares.Protocol("s3"), middlewares.CountServed("s3")))
 
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	signCheck := middlewares.Down(sign.Verify)
-	g.GET("/d/*path", signCheck, downloadLimiter, handles.Down)
-	g.GET("/p/*path", signCheck, downloadLimiter, handles.Proxy)
+	g.GET("/d/*path", middlewares.CountServed("d"), signCheck, downloadLimiter, handles.Down)
+	g.GET("/p/*path", middlewares.CountServed("p"), signCheck, downloadLimiter, handles.Proxy)
 	g.HEAD("/d/*path", signCheck, handles.Down)
 	g.HEAD("/p/*path", signCheck, handles.Proxy)
 	archiveSignCheck := middlewares.Down(sign.VerifyArchive)
-	g.GET("/ad/*path", archiveSignCheck, downloadLimiter, handles.ArchiveDown)
-	g.GET("/ap/*path", archiveSignCheck, downloadLimiter, handles.ArchiveProxy)
-	g.GET("/ae/*path", archiveSignCheck, downloadLimiter, handles.ArchiveInternalExtract)
+	g.GET("/ad/*path", middlewares.CountServed("ad"), archiveSignCheck, downloadLimiter, handles.ArchiveDown)
+	g.GET("/ap/*path", middlewares.CountServed("ap"), archiveSignCheck, downloadLimiter, handles.ArchiveProxy)
+	g.GET("/ae/*path", middlewares.CountServed("ae"), archiveSignCheck, downloadLimiter, handles.ArchiveInternalExtract)
 	g.HEAD("/ad/*path", archiveSignCheck, handles.ArchiveDown)
 	g.HEAD("/ap/*path", archiveSignCheck, handles.ArchiveProxy)
 	g.HEAD("/ae/*path", archiveSignCheck, handles.ArchiveInternalExtract)
+	g.GET("/z/*path", middlewares.CountServed("z"), downloadLimiter, handles.PackageDown)
+
+	shared := g.Group("/s/:sid", middlewares.Protocol("share"), middlewares.Share)
+	shared.Any("/info", handles.ShareInfo)
+	shared.Any("/list", handles.ShareList)
+	shared.Any("/get", handles.ShareGet)
+	shared.GET("/d/*path", middlewares.CountServed("share"), downloadLimiter, handles.ShareDown)
+	shared.HEAD("/d/*path", handles.ShareDown)
+	shared.PUT("/put", middlewares.FsUp, middlewares.UploadRateLimiter(stream.ClientUploadLimit), handles.ShareStream)
 
 	api := g.Group("/api")
 	auth := api.Group("", middlewares.Auth)
@@ -63,13 +79,26 @@ func Init(e *gin.Engine) {
 	api.POST("/auth/login", handles.Login)
 	api.POST("/auth/login/hash", handles.LoginHash)
 	api.POST("/auth/login/ldap", handles.LoginLdap)
+	api.POST("/auth/refresh", handles.RefreshToken)
 	auth.GET("/me", handles.CurrentUser)
-	auth.POST("/me/update", handles.UpdateCurrent)
+	auth.POST("/me/update", middlewares.AuthNotAPIToken, handles.UpdateCurrent)
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
-	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
-	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
-	auth.POST("/auth/2fa/generate", handles.Generate2FA)
-	auth.POST("/auth/2fa/verify", handles.Verify2FA)
+	auth.POST("/me/sshkey/add", middlewares.AuthNotAPIToken, handles.AddMyPublicKey)
+	auth.POST("/me/sshkey/delete", middlewares.AuthNotAPIToken, handles.DeleteMyPublicKey)
+	auth.GET("/me/token/list", handles.ListMyAPITokens)
+	auth.POST("/me/token/create", middlewares.AuthNotAPIToken, handles.CreateMyAPIToken)
+	auth.POST("/me/token/delete", middlewares.AuthNotAPIToken, handles.DeleteMyAPIToken)
+	auth.GET("/me/s3/key/list", handles.ListMyS3Keys)
+	auth.POST("/me/s3/key/create", middlewares.AuthNotAPIToken, handles.CreateMyS3Key)
+	auth.POST("/me/s3/key/delete", middlewares.AuthNotAPIToken, handles.DeleteMyS3Key)
+	auth.GET("/me/s3/bucket/list", handles.ListMyS3Buckets)
+	auth.POST("/me/s3/bucket/create", middlewares.AuthNotAPIToken, handles.CreateMyS3Bucket)
+	auth.POST("/me/s3/bucket/update", middlewares.AuthNotAPIToken, handles.UpdateMyS3Bucket)
+	auth.POST("/me/s3/bucket/delete", middlewares.AuthNotAPIToken, handles.DeleteMyS3Bucket)
+	auth.GET("/me/session/list", handles.ListMySessions)
+	auth.POST("/me/session/revoke", middlewares.AuthNotAPIToken, handles.RevokeMySession)
+	auth.POST("/auth/2fa/generate", middlewares.AuthNotAPIToken, handles.Generate2FA)
+	auth.POST("/auth/2fa/verify", middlewares.AuthNotAPIToken, handles.Verify2FA)
 	auth.GET("/auth/logout", handles.LogOut)
 
 	// auth
@@ -93,8 +122,9 @@ func Init(e *gin.Engine) {
 	public.Any("/archive_extensions", handles.ArchiveExtensions)
 
 	_fs(auth.Group("/fs"))
-	_task(auth.Group("/task", middlewares.AuthNotGuest))
-	admin(auth.Group("/admin", middlewares.AuthAdmin))
+	_task(auth.Group("/task", middlewares.AuthNotGuest, middlewares.TokenScope(model.TokenScopeTasks)))
+	_share(auth.Group("/share", middlewares.AuthNotGuest))
+	admin(auth.Group("/admin", middlewares.AuthAdmin, middlewares.TokenScope(model.TokenScopeAdmin)))
 	if flags.Debug || flags.Dev {
 		debug(g.Group("/debug"))
 	}
@@ -111,6 +141,33 @@ func admin(g *gin.RouterGroup) {
 	meta.POST("/update", handles.UpdateMeta)
 	meta.POST("/delete", handles.DeleteMeta)
 
+	share := g.Group("/share")
+	share.GET("/list", handles.ListShares)
+	share.POST("/delete", handles.DeleteShare)
+
+	audit := g.Group("/audit")
+	audit.GET("/list", handles.ListAuditLogs)
+	audit.GET("/export", handles.ExportAuditLogs)
+	audit.POST("/clear", handles.ClearAuditLogs)
+
+	webhook := g.Group("/webhook")
+	webhook.GET("/list", handles.ListWebhooks)
+	webhook.GET("/get", handles.GetWebhook)
+	webhook.POST("/create", handles.CreateWebhook)
+	webhook.POST("/update", handles.UpdateWebhook)
+	webhook.POST("/delete", handles.DeleteWebhook)
+	webhook.POST("/test", handles.TestWebhook)
+	webhook.GET("/deliveries", handles.ListWebhookDeliveries)
+
+	schedule := g.Group("/schedule")
+	schedule.GET("/list", handles.ListScheduledJobs)
+	schedule.GET("/get", handles.GetScheduledJob)
+	schedule.POST("/create", handles.CreateScheduledJob)
+	schedule.POST("/update", handles.UpdateScheduledJob)
+	schedule.POST("/delete", handles.DeleteScheduledJob)
+	schedule.POST("/run", handles.RunScheduledJob)
+	schedule.GET("/runs", handles.ListScheduledJobRuns)
+
 	user := g.Group("/user")
 	user.GET("/list", handles.ListUsers)
 	user.GET("/get", handles.GetUser)
@@ -119,8 +176,32 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/cancel_2fa", handles.Cancel2FAById)
 	user.POST("/delete", handles.DeleteUser)
 	user.POST("/del_cache", handles.DelUserCache)
+	user.GET("/usage", handles.UserUsageReport)
+	user.POST("/reset_usage", handles.ResetUserUsage)
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
+	user.GET("/token/list", handles.ListAPITokens)
+	user.POST("/token/delete", handles.DeleteAPIToken)
+	user.GET("/s3/key/list", handles.ListS3Keys)
+	user.POST("/s3/key/delete", handles.DeleteS3Key)
+	user.GET("/s3/bucket/list", handles.ListS3Buckets)
+	user.POST("/s3/bucket/delete", handles.DeleteS3Bucket)
+	user.GET("/session/list", handles.ListSessions)
+	user.POST("/session/revoke", handles.RevokeSession)
+
+	group := g.Group("/group")
+	group.GET("/list", handles.ListGroups)
+	group.GET("/get", handles.GetGroup)
+	group.POST("/create", handles.CreateGroup)
+	group.POST("/update", handles.UpdateGroup)
+	group.POST("/delete", handles.DeleteGroup)
+
+	acl := g.Group("/acl")
+	acl.GET("/list", handles.ListACLs)
+	acl.GET("/get", handles.GetACL)
+	acl.POST("/create", handles.CreateACL)
+	acl.POST("/update", handles.UpdateACL)
+	acl.POST("/delete", handles.DeleteACL)
 
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
@@ -131,6 +212,7 @@ func admin(g *gin.RouterGroup) {
 	storage.POST("/enable", handles.EnableStorage)
 	storage.POST("/disable", handles.DisableStorage)
 	storage.POST("/load_all", handles.LoadAllStorages)
+	storage.GET("/usage", handles.StorageUsage)
 
 	driver := g.Group("/driver")
 	driver.GET("/list", handles.ListDriverInfo)
@@ -164,6 +246,7 @@ func admin(g *gin.RouterGroup) {
 	index := g.Group("/index")
 	index.POST("/build", middlewares.SearchIndex, handles.BuildIndex)
 	index.POST("/update", middlewares.SearchIndex, handles.UpdateIndex)
+	index.POST("/update_storage", middlewares.SearchIndex, handles.UpdateStorageIndex)
 	index.POST("/stop", middlewares.SearchIndex, handles.StopIndex)
 	index.POST("/clear", middlewares.SearchIndex, handles.ClearIndex)
 	index.GET("/progress", middlewares.SearchIndex, handles.GetProgress)
@@ -175,6 +258,7 @@ func _fs(g *gin.RouterGroup) {
 	g.Any("/get", handles.FsGet)
 	g.Any("/other", handles.FsOther)
 	g.Any("/dirs", handles.FsDirs)
+	g.POST("/package_download", handles.FsPackageDownload)
 	g.POST("/mkdir", handles.FsMkdir)
 	g.POST("/rename", handles.FsRename)
 	g.POST("/batch_rename", handles.FsBatchRename)
@@ -182,8 +266,12 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/move", handles.FsMove)
 	g.POST("/recursive_move", handles.FsRecursiveMove)
 	g.POST("/copy", handles.FsCopy)
+	g.POST("/sync", handles.FsSync)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
+	g.Any("/recycle/list", handles.ListRecycleItems)
+	g.POST("/recycle/restore", handles.RestoreRecycleItems)
+	g.POST("/recycle/delete", handles.PurgeRecycleItems)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
@@ -196,6 +284,15 @@ func _fs(g *gin.RouterGroup) {
 	a.Any("/meta", handles.FsArchiveMeta)
 	a.Any("/list", handles.FsArchiveList)
 	a.POST("/decompress", handles.FsArchiveDecompress)
+	a.POST("/compress", handles.FsArchiveCompress)
+}
+
+func _share(g *gin.RouterGroup) {
+	g.GET("/list", handles.ListMyShares)
+	g.GET("/get", handles.GetShare)
+	g.POST("/create", handles.CreateShare)
+	g.POST("/update", handles.UpdateShare)
+	g.POST("/delete", handles.DeleteShare)
 }
 
 func _task(g *gin.RouterGroup) {
@@ -213,5 +310,5 @@ func Cors(r *gin.Engine) {
 
 func InitS3(e *gin.Engine) {
 	Cors(e)
-	S3Server(e.Group("/"))
+	S3Server(e.Group("/", middlewares.Protocol("s3"), middlewares.CountServed("s3")))
 }
@@ -0,0 +1,106 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/itsHenry35/gofakes3/signature"
+)
+
+type errorResponse struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string
+	Message string
+}
+
+// withUserKey serves the requests signed by the s3 secret of an api token or an s3 key of a user
+// as the user, the requests signed by the global key are left to the auth of gofakes3
+func withUserKey(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyID := accessKeyID(r)
+		user, secret, err := userResolver(keyID, r.RemoteAddr)
+		if err != nil {
+			writeError(w, http.StatusForbidden, "AccessDenied", err.Error())
+			return
+		}
+		if user == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+		// verified against the key row on every request, so a deleted key stops working at once
+		if err := verifySigV4(r, keyID, secret); err != nil {
+			writeError(w, err.status, err.code, err.message)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), conf.UserKey, user)))
+	})
+}
+
+// byUser serves the requests of the users verified by withUserKey by user, and the others by global
+func byUser(global, user http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(conf.UserKey).(*model.User); ok {
+			user.ServeHTTP(w, r)
+			return
+		}
+		global.ServeHTTP(w, r)
+	})
+}
+
+// verifySignature verifies the v4 or v2 signature of the request by the global key, and writes
+// the error response if it's invalid
+func verifySignature(w http.ResponseWriter, r *http.Request) bool {
+	result := signature.V4SignVerify(r)
+	if result == signature.ErrUnsupportAlgorithm {
+		result = signature.V2SignVerify(r)
+	}
+	if result != signature.ErrNone {
+		resp := signature.GetAPIError(result)
+		writeError(w, resp.HTTPStatusCode, resp.Code, resp.Description)
+		return false
+	}
+	return true
+}
+
+// userResolver returns the user owning the access key id and the secret of it when it's the one
+// of an api token or an s3 key, nil for the global key
+func userResolver(keyID, ip string) (*model.User, string, error) {
+	switch {
+	case strings.HasPrefix(keyID, model.APITokenPrefix):
+		return op.AuthAPITokenS3Key(keyID, ip)
+	case strings.HasPrefix(keyID, model.S3KeyPrefix):
+		return op.AuthS3Key(keyID, ip)
+	}
+	return nil, "", nil
+}
+
+// accessKeyID returns the access key id the request is signed by, from the header or the query
+func accessKeyID(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if _, cred, ok := strings.Cut(auth, "Credential="); ok {
+		key, _, _ := strings.Cut(cred, "/")
+		return key
+	}
+	if v2, ok := strings.CutPrefix(auth, "AWS "); ok {
+		key, _, _ := strings.Cut(v2, ":")
+		return key
+	}
+	query := r.URL.Query()
+	if cred := query.Get("X-Amz-Credential"); cred != "" {
+		key, _, _ := strings.Cut(cred, "/")
+		return key
+	}
+	return query.Get("AWSAccessKeyId")
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(errorResponse{Code: code, Message: message})
+}
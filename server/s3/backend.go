@@ -39,7 +39,7 @@ type s3Backend struct {
 }
 
 // newBackend creates a new SimpleBucketBackend.
-func newBackend() gofakes3.Backend {
+func newBackend() *s3Backend {
 	return &s3Backend{
 		meta: new(sync.Map),
 	}
@@ -47,13 +47,17 @@ func newBackend() gofakes3.Backend {
 
 // ListBuckets always returns the default bucket.
 func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, error) {
-	buckets, err := getAndParseBuckets()
+	buckets, err := getAndParseBuckets(ctx)
 	if err != nil {
 		return nil, err
 	}
 	var response []gofakes3.BucketInfo
 	for _, b := range buckets {
-		node, _ := fs.Get(ctx, b.Path, &fs.GetArgs{})
+		node, err := fs.Get(ctx, b.Path, &fs.GetArgs{})
+		if err != nil {
+			log.Warnf("failed get the path of bucket %s: %+v", b.Name, err)
+			continue
+		}
 		response = append(response, gofakes3.BucketInfo{
 			// Name:         gofakes3.URLEncode(b.Name),
 			Name:         b.Name,
@@ -65,7 +69,7 @@ func (b *s3Backend) ListBuckets(ctx context.Context) ([]gofakes3.BucketInfo, err
 
 // ListBucket lists the objects in the given bucket.
 func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *gofakes3.Prefix, page gofakes3.ListBucketPage) (*gofakes3.ObjectList, error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +90,7 @@ func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *g
 	response := gofakes3.NewObjectList()
 	path, remaining := prefixParser(prefix)
 
-	err = b.entryListR(bucketPath, path, remaining, prefix.HasDelimiter, response)
+	err = b.entryListR(ctx, bucketPath, path, remaining, prefix.HasDelimiter, response)
 	if err == gofakes3.ErrNoSuchKey {
 		// AWS just returns an empty list
 		response = gofakes3.NewObjectList()
@@ -101,13 +105,16 @@ func (b *s3Backend) ListBucket(ctx context.Context, bucketName string, prefix *g
 //
 // Note that the metadata is not supported yet.
 func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName string) (*gofakes3.Object, error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if err = checkAccess(ctx, model.ACLRead, fp); err != nil {
+		return nil, err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -144,13 +151,16 @@ func (b *s3Backend) HeadObject(ctx context.Context, bucketName, objectName strin
 
 // GetObject fetchs the object from the filesystem.
 func (b *s3Backend) GetObject(ctx context.Context, bucketName, objectName string, rangeRequest *gofakes3.ObjectRangeRequest) (s3Obj *gofakes3.Object, err error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return nil, err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if err = checkAccess(ctx, model.ACLRead, fp); err != nil {
+		return nil, err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	node, err := fs.Get(context.WithValue(ctx, conf.MetaKey, fmeta), fp, &fs.GetArgs{})
 	if err != nil {
@@ -231,7 +241,7 @@ func (b *s3Backend) PutObject(
 	meta map[string]string,
 	input io.Reader, size int64,
 ) (result gofakes3.PutObjectResult, err error) {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return result, err
 	}
@@ -250,9 +260,18 @@ func (b *s3Backend) PutObject(
 		reqPath = path.Dir(fp)
 	}
 	log.Debugf("reqPath: %s", reqPath)
+	if err = checkAccess(ctx, model.ACLWrite, fp); err != nil {
+		return result, err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	ctx = context.WithValue(ctx, conf.MetaKey, fmeta)
 
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok && !isDir {
+		if err = op.CheckQuota(user, size); err != nil {
+			return result, err
+		}
+	}
+
 	_, err = fs.Get(ctx, reqPath, &fs.GetArgs{})
 	if err != nil {
 		if errs.IsObjectNotFound(err) && strings.Contains(objectName, "/") {
@@ -335,13 +354,16 @@ func (b *s3Backend) DeleteObject(ctx context.Context, bucketName, objectName str
 
 // deleteObject deletes the object from the filesystem.
 func (b *s3Backend) deleteObject(ctx context.Context, bucketName, objectName string) error {
-	bucket, err := getBucketByName(bucketName)
+	bucket, err := getBucketByName(ctx, bucketName)
 	if err != nil {
 		return err
 	}
 	bucketPath := bucket.Path
 
 	fp := path.Join(bucketPath, objectName)
+	if err = checkAccess(ctx, model.ACLRemove, fp); err != nil {
+		return err
+	}
 	fmeta, _ := op.GetNearestMeta(fp)
 	// S3 does not report an error when attemping to delete a key that does not exist, so
 	// we need to skip IsNotExist errors.
@@ -365,7 +387,7 @@ func (b *s3Backend) DeleteBucket(ctx context.Context, name string) error {
 
 // BucketExists checks if the bucket exists.
 func (b *s3Backend) BucketExists(ctx context.Context, name string) (exists bool, err error) {
-	buckets, err := getAndParseBuckets()
+	buckets, err := getAndParseBuckets(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -384,7 +406,7 @@ func (b *s3Backend) CopyObject(ctx context.Context, srcBucket, srcKey, dstBucket
 		return result, nil
 	}
 
-	srcB, err := getBucketByName(srcBucket)
+	srcB, err := getBucketByName(ctx, srcBucket)
 	if err != nil {
 		return result, err
 	}
@@ -3,18 +3,21 @@
 package s3
 
 import (
+	"context"
 	"path"
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/itsHenry35/gofakes3"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
-func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, response *gofakes3.ObjectList) error {
+func (b *s3Backend) entryListR(ctx context.Context, bucket, fdPath, name string, addPrefix bool, response *gofakes3.ObjectList) error {
 	fp := path.Join(bucket, fdPath)
 
-	dirEntries, err := getDirEntries(fp)
+	dirEntries, err := getDirEntries(ctx, fp)
 	if err != nil {
 		return err
 	}
@@ -50,7 +53,11 @@ func (b *s3Backend) entryListR(bucket, fdPath, name string, addPrefix bool, resp
 				response.AddPrefix(objectPath)
 				continue
 			}
-			err := b.entryListR(bucket, path.Join(fdPath, object), "", false, response)
+			err := b.entryListR(ctx, bucket, path.Join(fdPath, object), "", false, response)
+			// the folders the user can't access are left out
+			if errors.Is(err, errs.PermissionDenied) {
+				continue
+			}
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,921 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/itsHenry35/gofakes3"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// multipartExpiry is how long an upload without any request is kept before it's dropped
+	multipartExpiry = 24 * time.Hour
+	// multipartCleanInterval is how often the expired uploads and the interrupted completions are checked
+	multipartCleanInterval = time.Minute
+	// minPartSize is the min size of the parts except the last one of an upload
+	minPartSize = 5 * 1024 * 1024
+	// completeKeepAlive is the interval of the whitespaces sent while the driver is uploading
+	// the completed object, so that the clients don't time out
+	completeKeepAlive = 10 * time.Second
+	// completeStale is how long a completion not kept alive is taken as interrupted, so that
+	// the client can complete the upload again
+	completeStale = 3 * completeKeepAlive
+)
+
+const (
+	errAccessDenied   gofakes3.ErrorCode = "AccessDenied"
+	errEntityTooSmall gofakes3.ErrorCode = "EntityTooSmall"
+	errSlowDown       gofakes3.ErrorCode = "SlowDown"
+)
+
+type multipartPart struct {
+	path     string
+	size     int64
+	md5      []byte
+	modified time.Time
+}
+
+func (p *multipartPart) etag() string {
+	return `"` + hex.EncodeToString(p.md5) + `"`
+}
+
+type multipartUpload struct {
+	id        string
+	bucket    string
+	object    string
+	userID    uint
+	meta      map[string]string
+	dir       string
+	initiated time.Time
+	parts     map[int]*multipartPart
+}
+
+func newMultipartUpload(u *model.S3MultipartUpload) *multipartUpload {
+	upload := &multipartUpload{
+		id:        u.ID,
+		bucket:    u.Bucket,
+		object:    u.Object,
+		userID:    u.UserID,
+		dir:       multipartUploadDir(u.ID),
+		initiated: u.Initiated,
+		parts:     make(map[int]*multipartPart),
+	}
+	if err := utils.Json.UnmarshalFromString(u.Meta, &upload.meta); err != nil {
+		log.Warnf("failed unmarshal the meta of the multipart upload %s: %+v", u.ID, err)
+	}
+	return upload
+}
+
+// loadParts reads the parts of the upload from the database
+func (u *multipartUpload) loadParts() error {
+	parts, err := db.GetS3MultipartParts(u.id)
+	if err != nil {
+		return err
+	}
+	for _, p := range parts {
+		sum, _ := hex.DecodeString(p.MD5)
+		u.parts[p.Number] = &multipartPart{
+			path:     filepath.Join(u.dir, p.File),
+			size:     p.Size,
+			md5:      sum,
+			modified: p.Modified,
+		}
+	}
+	return nil
+}
+
+func multipartUploadDir(id string) string {
+	return filepath.Join(conf.Conf.TempDir, conf.S3MultipartDir, id)
+}
+
+// multipartServer serves the multipart uploads instead of gofakes3, which assembles the parts in
+// memory. The parts are spooled in the temp dir, and handed to the driver as a single seekable
+// stream on completion. The uploads and their parts are kept in the database, so that they
+// survive restarts, and any instance sharing the temp dir can serve them.
+type multipartServer struct {
+	next     http.Handler
+	backend  *s3Backend
+	haveAuth bool
+
+	mu sync.Mutex
+	// the size of the parts being spooled, the spooled ones are counted in the database
+	spooling int64
+}
+
+var multipartCleaner sync.Once
+
+func withMultipart(h http.Handler, b *s3Backend, haveAuth bool) http.Handler {
+	multipartCleaner.Do(func() {
+		go cleanMultipartUploads()
+	})
+	return &multipartServer{
+		next:     h,
+		backend:  b,
+		haveAuth: haveAuth,
+	}
+}
+
+func (s *multipartServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+	_, uploads := query["uploads"]
+	bucket, object, _ := strings.Cut(strings.Trim(r.URL.Path, "/"), "/")
+	if uploadID == "" && !uploads || bucket == "" {
+		s.next.ServeHTTP(w, r)
+		return
+	}
+	// the requests of users are verified by withUserKey, and the global key is left to us
+	// since the requests don't reach the auth of gofakes3
+	if _, ok := r.Context().Value(conf.UserKey).(*model.User); !ok && s.haveAuth && !verifySignature(w, r) {
+		return
+	}
+	var err error
+	switch {
+	case uploads && r.Method == http.MethodPost && object != "":
+		err = s.initiate(w, r, bucket, object)
+	case uploads && r.Method == http.MethodGet:
+		err = s.listUploads(w, r, bucket)
+	case uploadID != "" && r.Method == http.MethodPut:
+		err = s.uploadPart(w, r, bucket, object, uploadID)
+	case uploadID != "" && r.Method == http.MethodGet:
+		err = s.listParts(w, r, bucket, object, uploadID)
+	case uploadID != "" && r.Method == http.MethodPost:
+		err = s.complete(w, r, bucket, object, uploadID)
+	case uploadID != "" && r.Method == http.MethodDelete:
+		err = s.abort(w, r, bucket, object, uploadID)
+	default:
+		err = gofakes3.ErrMethodNotAllowed
+	}
+	if err != nil {
+		writeS3Error(w, err)
+	}
+}
+
+func (s *multipartServer) initiate(w http.ResponseWriter, r *http.Request, bucketName, object string) error {
+	ctx := r.Context()
+	bucket, err := getBucketByName(ctx, bucketName)
+	if err != nil {
+		return err
+	}
+	if len(object) > gofakes3.KeySizeLimit {
+		return gofakes3.ResourceError(gofakes3.ErrKeyTooLong, object)
+	}
+	if err = checkAccess(ctx, model.ACLWrite, path.Join(bucket.Path, object)); err != nil {
+		return err
+	}
+	meta, err := utils.Json.MarshalToString(objectMeta(r.Header))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	now := time.Now()
+	upload := &model.S3MultipartUpload{
+		ID:        random.String(32),
+		Bucket:    bucketName,
+		Object:    object,
+		UserID:    requestUserID(ctx),
+		Meta:      meta,
+		Initiated: now,
+		Active:    now.UnixNano(),
+	}
+	// the upload is recorded before its dir is made, so that a dir without a record is an orphan
+	if err = db.CreateS3MultipartUpload(upload); err != nil {
+		return err
+	}
+	if err = os.MkdirAll(multipartUploadDir(upload.ID), 0o777); err != nil {
+		_, _ = db.DeleteS3MultipartUpload(upload.ID, false, 0)
+		return errors.WithMessage(err, "failed create the spool dir")
+	}
+	return writeXML(w, gofakes3.InitiateMultipartUpload{
+		Bucket:   bucketName,
+		Key:      object,
+		UploadID: gofakes3.UploadID(upload.ID),
+	})
+}
+
+func (s *multipartServer) uploadPart(w http.ResponseWriter, r *http.Request, bucket, object, uploadID string) error {
+	number, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || number <= 0 || number > gofakes3.MaxUploadPartNumber {
+		return gofakes3.ErrInvalidPart
+	}
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		return gofakes3.ErrorMessage(gofakes3.ErrNotImplemented, "UploadPartCopy is not supported")
+	}
+	var expectMD5 []byte
+	if values, ok := r.Header["Content-Md5"]; ok {
+		if expectMD5, err = base64.StdEncoding.DecodeString(values[0]); err != nil || len(expectMD5) != md5.Size {
+			return gofakes3.ErrInvalidDigest
+		}
+	}
+	upload, err := s.get(r.Context(), bucket, object, uploadID)
+	if err != nil {
+		return err
+	}
+	body, size, err := partBody(r)
+	if err != nil {
+		return err
+	}
+	if err = s.reserve(size); err != nil {
+		return err
+	}
+	// the part is counted in the database once it's saved
+	defer s.release(size)
+	part, err := spoolPart(upload.dir, number, body, size)
+	if err != nil {
+		return err
+	}
+	if expectMD5 != nil && !slices.Equal(expectMD5, part.md5) {
+		_ = os.Remove(part.path)
+		return gofakes3.ErrBadDigest
+	}
+	saved, old, err := db.SaveS3MultipartPart(&model.S3MultipartPart{
+		UploadID: uploadID,
+		Number:   number,
+		File:     filepath.Base(part.path),
+		Size:     part.size,
+		MD5:      hex.EncodeToString(part.md5),
+		Modified: part.modified,
+	}, part.modified.UnixNano())
+	if err != nil || !saved {
+		// aborted or being completed while the part was uploading
+		_ = os.Remove(part.path)
+		if err != nil {
+			return err
+		}
+		return gofakes3.ErrNoSuchUpload
+	}
+	if old != nil {
+		_ = os.Remove(filepath.Join(upload.dir, old.File))
+	}
+	w.Header().Set("ETag", part.etag())
+	return nil
+}
+
+func (s *multipartServer) listParts(w http.ResponseWriter, r *http.Request, bucket, object, uploadID string) error {
+	query := r.URL.Query()
+	marker, err := queryInt(query.Get("part-number-marker"), 0, 0, gofakes3.MaxUploadPartNumber)
+	if err != nil {
+		return err
+	}
+	maxParts, err := queryInt(query.Get("max-parts"), gofakes3.MaxUploadPartNumber, 0, gofakes3.MaxUploadPartNumber)
+	if err != nil {
+		return err
+	}
+	upload, err := s.get(r.Context(), bucket, object, uploadID)
+	if err != nil {
+		return err
+	}
+	if err = upload.loadParts(); err != nil {
+		return err
+	}
+	result := gofakes3.ListMultipartUploadPartsResult{
+		Bucket:           bucket,
+		Key:              object,
+		UploadID:         gofakes3.UploadID(uploadID),
+		PartNumberMarker: marker,
+		MaxParts:         int64(maxParts),
+	}
+	numbers := make([]int, 0, len(upload.parts))
+	for number := range upload.parts {
+		if number > marker {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	for _, number := range numbers {
+		if len(result.Parts) >= maxParts {
+			result.IsTruncated = true
+			break
+		}
+		part := upload.parts[number]
+		result.Parts = append(result.Parts, gofakes3.ListMultipartUploadPartItem{
+			PartNumber:   number,
+			LastModified: gofakes3.NewContentTime(part.modified),
+			ETag:         part.etag(),
+			Size:         part.size,
+		})
+		result.NextPartNumberMarker = number
+	}
+	return writeXML(w, result)
+}
+
+func (s *multipartServer) listUploads(w http.ResponseWriter, r *http.Request, bucketName string) error {
+	ctx := r.Context()
+	if _, err := getBucketByName(ctx, bucketName); err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	maxUploads, err := queryInt(query.Get("max-uploads"), gofakes3.DefaultMaxUploads, 0, gofakes3.DefaultMaxUploads)
+	if err != nil {
+		return err
+	}
+	result := gofakes3.ListMultipartUploadsResult{
+		Bucket:         bucketName,
+		KeyMarker:      query.Get("key-marker"),
+		UploadIDMarker: gofakes3.UploadID(query.Get("upload-id-marker")),
+		MaxUploads:     int64(maxUploads),
+		Prefix:         query.Get("prefix"),
+	}
+	all, err := db.GetS3MultipartUploads(bucketName, requestUserID(ctx))
+	if err != nil {
+		return err
+	}
+	var uploads []model.S3MultipartUpload
+	for _, upload := range all {
+		if strings.HasPrefix(upload.Object, result.Prefix) &&
+			(upload.Object > result.KeyMarker || upload.Object == result.KeyMarker && upload.ID > string(result.UploadIDMarker)) {
+			uploads = append(uploads, upload)
+		}
+	}
+	sort.Slice(uploads, func(i, j int) bool {
+		if uploads[i].Object != uploads[j].Object {
+			return uploads[i].Object < uploads[j].Object
+		}
+		return uploads[i].ID < uploads[j].ID
+	})
+	for _, upload := range uploads {
+		if len(result.Uploads) >= maxUploads {
+			result.IsTruncated = true
+			break
+		}
+		result.Uploads = append(result.Uploads, gofakes3.ListMultipartUploadItem{
+			Key:       upload.Object,
+			UploadID:  gofakes3.UploadID(upload.ID),
+			Initiated: gofakes3.NewContentTime(upload.Initiated),
+		})
+		result.NextKeyMarker = upload.Object
+		result.NextUploadIDMarker = gofakes3.UploadID(upload.ID)
+	}
+	return writeXML(w, result)
+}
+
+func (s *multipartServer) abort(w http.ResponseWriter, r *http.Request, bucket, object, uploadID string) error {
+	upload, err := s.get(r.Context(), bucket, object, uploadID)
+	if err != nil {
+		return err
+	}
+	deleted, err := db.DeleteS3MultipartUpload(uploadID, false, 0)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return gofakes3.ErrNoSuchUpload
+	}
+	dropUploadDir(upload.dir)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *multipartServer) complete(w http.ResponseWriter, r *http.Request, bucket, object, uploadID string) error {
+	ctx := r.Context()
+	var req gofakes3.CompleteMultipartUploadRequest
+	if err := xml.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+		return gofakes3.ErrorMessage(gofakes3.ErrMalformedXML, err.Error())
+	}
+	if len(req.Parts) == 0 {
+		return gofakes3.ErrorMessage(gofakes3.ErrMalformedXML, "no parts specified")
+	}
+	upload, err := s.get(ctx, bucket, object, uploadID)
+	if err != nil {
+		return err
+	}
+	// mark the upload completing, so that the parts can't be changed while the driver is
+	// reading them, and unmark it if the completion fails so that the client can complete it again
+	ok, err := db.SetS3MultipartUploadCompleting(uploadID, true, time.Now().UnixNano())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return gofakes3.ErrNoSuchUpload
+	}
+	var parts []*multipartPart
+	if err = upload.loadParts(); err == nil {
+		parts, err = completedParts(upload, req.Parts)
+	}
+	responded := false
+	if err == nil {
+		responded, err = s.put(w, r, upload, parts)
+	}
+	if err != nil {
+		if _, e := db.SetS3MultipartUploadCompleting(uploadID, false, time.Now().UnixNano()); e != nil {
+			log.Errorf("failed unmark the completing multipart upload %s: %+v", uploadID, e)
+		}
+		if responded {
+			return nil
+		}
+		return err
+	}
+	if _, err = db.DeleteS3MultipartUpload(uploadID, true, 0); err != nil {
+		log.Errorf("failed delete the completed multipart upload %s: %+v", uploadID, err)
+	}
+	dropUploadDir(upload.dir)
+	return nil
+}
+
+// put hands the parts to the driver as a single seekable stream. Since it may take long, the
+// 200 status is sent after a while and followed by whitespaces until the driver finishes, the
+// error is then sent in the body like s3 does, and responded is true.
+func (s *multipartServer) put(w http.ResponseWriter, r *http.Request, upload *multipartUpload, parts []*multipartPart) (responded bool, err error) {
+	reader, err := openParts(parts)
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+	hash := md5.New()
+	for _, part := range parts {
+		hash.Write(part.md5)
+	}
+	result := gofakes3.CompleteMultipartUploadResult{
+		Bucket: upload.bucket,
+		Key:    upload.object,
+		ETag:   fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(hash.Sum(nil)), len(parts)),
+	}
+	meta := make(map[string]string, len(upload.meta)+1)
+	for k, v := range upload.meta {
+		meta[k] = v
+	}
+	meta["Last-Modified"] = time.Now().UTC().Format(http.TimeFormat)
+
+	// the upload goes on if the client drops, the values of the user and the meta are kept
+	ctx := context.WithoutCancel(r.Context())
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.backend.PutObject(ctx, upload.bucket, upload.object, meta,
+			io.NewSectionReader(reader, 0, reader.size), reader.size)
+		done <- err
+	}()
+	ticker := time.NewTicker(completeKeepAlive)
+	defer ticker.Stop()
+	flusher, _ := w.(http.Flusher)
+	started := false
+	for {
+		select {
+		case err = <-done:
+			if !started {
+				if err != nil {
+					return false, err
+				}
+				return true, writeXML(w, result)
+			}
+			if err != nil {
+				log.Errorf("failed complete the multipart upload of %s/%s: %+v", upload.bucket, upload.object, err)
+				code, message := errorCode(err)
+				_ = xml.NewEncoder(w).Encode(errorResponse{Code: string(code), Message: message})
+				return true, err
+			}
+			return true, xml.NewEncoder(w).Encode(result)
+		case <-ticker.C:
+			// keep the completion from being taken as interrupted
+			if err := db.TouchS3MultipartUpload(upload.id, time.Now().UnixNano()); err != nil {
+				log.Warnf("failed touch the completing multipart upload %s: %+v", upload.id, err)
+			}
+			if !started {
+				started = true
+				w.Header().Set("Content-Type", "application/xml")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(xml.Header))
+			} else {
+				_, _ = w.Write([]byte(" "))
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// get returns the upload of the id if it's of the object and the user of the request,
+// and not being completed
+func (s *multipartServer) get(ctx context.Context, bucket, object, uploadID string) (*multipartUpload, error) {
+	u, err := db.GetS3MultipartUpload(uploadID)
+	if err != nil {
+		if errors.Is(errors.Cause(err), gorm.ErrRecordNotFound) {
+			return nil, gofakes3.ErrNoSuchUpload
+		}
+		return nil, err
+	}
+	if u.Bucket != bucket || u.Object != object || u.UserID != requestUserID(ctx) || u.Completing {
+		return nil, gofakes3.ErrNoSuchUpload
+	}
+	if err = db.TouchS3MultipartUpload(uploadID, time.Now().UnixNano()); err != nil {
+		return nil, err
+	}
+	return newMultipartUpload(u), nil
+}
+
+// reserve takes the size from the spool limit before a part is spooled
+func (s *multipartServer) reserve(size int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	limit := conf.Conf.S3.MultipartSpoolLimit * 1024 * 1024
+	if limit > 0 {
+		spooled, err := db.SumS3MultipartPartSize()
+		if err != nil {
+			return err
+		}
+		if spooled+s.spooling+size > limit {
+			return gofakes3.ErrorMessage(errSlowDown, "the spool of the multipart uploads is full, please retry later")
+		}
+	}
+	s.spooling += size
+	return nil
+}
+
+func (s *multipartServer) release(size int64) {
+	s.mu.Lock()
+	s.spooling -= size
+	s.mu.Unlock()
+}
+
+// cleanMultipartUploads removes the spooled files left by the uploads dropped or interrupted,
+// then drops the expired uploads and unmarks the interrupted completions periodically
+func cleanMultipartUploads() {
+	cleanMultipartSpool()
+	for {
+		now := time.Now()
+		expiredBefore := now.Add(-multipartExpiry).UnixNano()
+		uploads, err := db.GetAllS3MultipartUploads(expiredBefore)
+		if err != nil {
+			log.Errorf("failed get the expired multipart uploads: %+v", err)
+		}
+		for _, u := range uploads {
+			deleted, err := db.DeleteS3MultipartUpload(u.ID, u.Completing, expiredBefore)
+			if err != nil {
+				log.Errorf("failed delete the expired multipart upload %s: %+v", u.ID, err)
+			} else if deleted {
+				log.Infof("drop the expired multipart upload of %s/%s", u.Bucket, u.Object)
+				dropUploadDir(multipartUploadDir(u.ID))
+			}
+		}
+		// the instances completing them stopped, let the clients complete them again
+		if err = db.ResetStaleS3MultipartCompletions(now.Add(-completeStale).UnixNano()); err != nil {
+			log.Errorf("failed unmark the interrupted multipart uploads: %+v", err)
+		}
+		time.Sleep(multipartCleanInterval)
+	}
+}
+
+// cleanMultipartSpool removes the spool dirs without uploads, drops the uploads whose spool dir
+// is gone and the parts whose file is gone, and removes the old files without parts, which are
+// left by the interrupted part uploads
+func cleanMultipartSpool() {
+	root := filepath.Join(conf.Conf.TempDir, conf.S3MultipartDir)
+	uploads, err := db.GetAllS3MultipartUploads(0)
+	if err != nil {
+		log.Errorf("failed get the multipart uploads: %+v", err)
+		return
+	}
+	ids := make(map[string]struct{}, len(uploads))
+	for _, u := range uploads {
+		ids[u.ID] = struct{}{}
+		dir := multipartUploadDir(u.ID)
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			log.Warnf("drop the multipart upload of %s/%s whose spool dir is gone", u.Bucket, u.Object)
+			if _, err = db.DeleteS3MultipartUpload(u.ID, u.Completing, 0); err != nil {
+				log.Errorf("failed delete the multipart upload %s: %+v", u.ID, err)
+			}
+			continue
+		}
+		parts, err := db.GetS3MultipartParts(u.ID)
+		if err != nil {
+			log.Errorf("failed get the parts of the multipart upload %s: %+v", u.ID, err)
+			continue
+		}
+		files := make(map[string]struct{}, len(parts))
+		for _, p := range parts {
+			files[p.File] = struct{}{}
+			if _, err := os.Stat(filepath.Join(dir, p.File)); os.IsNotExist(err) {
+				log.Warnf("drop the part %d of the multipart upload %s whose file is gone", p.Number, u.ID)
+				if err = db.DeleteS3MultipartPart(u.ID, p.Number); err != nil {
+					log.Errorf("failed delete the part %d of the multipart upload %s: %+v", p.Number, u.ID, err)
+				}
+			}
+		}
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if _, ok := files[entry.Name()]; ok {
+				continue
+			}
+			// the part may be uploading to another instance sharing the temp dir
+			if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > multipartExpiry {
+				_ = os.Remove(filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	entries, _ := os.ReadDir(root)
+	for _, entry := range entries {
+		if _, ok := ids[entry.Name()]; ok {
+			continue
+		}
+		// the upload may be initiated after the uploads were read
+		if _, err := db.GetS3MultipartUpload(entry.Name()); err != nil && errors.Is(errors.Cause(err), gorm.ErrRecordNotFound) {
+			log.Infof("remove the orphaned spool dir of the multipart upload %s", entry.Name())
+			dropUploadDir(filepath.Join(root, entry.Name()))
+		}
+	}
+}
+
+// dropUploadDir removes the spooled parts of the upload which has been deleted
+func dropUploadDir(dir string) {
+	if err := os.RemoveAll(dir); err != nil {
+		log.Warnf("failed remove the spool dir %s: %+v", dir, err)
+	}
+}
+
+// completedParts checks the parts of the completion request against the uploaded ones
+func completedParts(upload *multipartUpload, completed []gofakes3.CompletedPart) ([]*multipartPart, error) {
+	for i := 1; i < len(completed); i++ {
+		if completed[i].PartNumber <= completed[i-1].PartNumber {
+			return nil, gofakes3.ErrInvalidPartOrder
+		}
+	}
+	parts := make([]*multipartPart, 0, len(completed))
+	for i, c := range completed {
+		part, ok := upload.parts[c.PartNumber]
+		if !ok || strings.Trim(c.ETag, `"`) != hex.EncodeToString(part.md5) {
+			return nil, gofakes3.ErrorMessagef(gofakes3.ErrInvalidPart, "part %d not found or etag mismatched", c.PartNumber)
+		}
+		if part.size < minPartSize && i < len(completed)-1 {
+			return nil, gofakes3.ErrorMessagef(errEntityTooSmall, "part %d is smaller than the min size", c.PartNumber)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// spoolPart writes the part to a file in the dir, and fails if the body is shorter than the size
+func spoolPart(dir string, number int, body io.Reader, size int64) (*multipartPart, error) {
+	f, err := os.CreateTemp(dir, fmt.Sprintf("%05d-*", number))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed create the spool file")
+	}
+	hash := md5.New()
+	_, err = utils.CopyWithBufferN(io.MultiWriter(f, hash), body, size)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		var s3Err gofakes3.Error
+		if errors.As(err, &s3Err) {
+			return nil, err
+		}
+		return nil, gofakes3.ErrorMessage(gofakes3.ErrIncompleteBody, err.Error())
+	}
+	return &multipartPart{
+		path:     f.Name(),
+		size:     size,
+		md5:      hash.Sum(nil),
+		modified: time.Now(),
+	}, nil
+}
+
+// partBody returns the decoded body of the part and the size of it
+func partBody(r *http.Request) (io.Reader, int64, error) {
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") ||
+		strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+		size, err := strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64)
+		if err != nil || size < 0 {
+			return nil, 0, gofakes3.ErrMissingContentLength
+		}
+		return newChunkedReader(r.Body), size, nil
+	}
+	if r.ContentLength < 0 {
+		return nil, 0, gofakes3.ErrMissingContentLength
+	}
+	return r.Body, r.ContentLength, nil
+}
+
+// chunkedReader decodes the aws-chunked body, either signed or not, with or without trailers.
+// The chunk signatures and the trailing checksums are not verified, like gofakes3 does.
+type chunkedReader struct {
+	r       *bufio.Reader
+	remain  int64
+	started bool
+	done    bool
+}
+
+func newChunkedReader(r io.Reader) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r)}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.remain == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		if err := c.next(); err != nil {
+			return 0, err
+		}
+	}
+	if int64(len(p)) > c.remain {
+		p = p[:c.remain]
+	}
+	n, err := c.r.Read(p)
+	c.remain -= int64(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// next reads the header of the next chunk, and the trailers after the last one
+func (c *chunkedReader) next() error {
+	if c.started {
+		// the CRLF after the data of the previous chunk
+		if line, err := c.line(); err != nil || line != "" {
+			return errMalformedChunk(err)
+		}
+	}
+	c.started = true
+	line, err := c.line()
+	if err != nil {
+		return errMalformedChunk(err)
+	}
+	sizeStr, _, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(strings.TrimSpace(sizeStr), 16, 64)
+	if err != nil || size < 0 {
+		return errMalformedChunk(err)
+	}
+	if size > 0 {
+		c.remain = size
+		return nil
+	}
+	c.done = true
+	for {
+		line, err = c.line()
+		if line == "" || err != nil {
+			// some clients end the body without the final CRLF
+			return nil
+		}
+	}
+}
+
+func (c *chunkedReader) line() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = io.ErrUnexpectedEOF
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+func errMalformedChunk(err error) error {
+	if err == nil {
+		err = errors.New("unexpected chunk")
+	}
+	return gofakes3.ErrorMessage(gofakes3.ErrIncompleteBody, "malformed aws-chunked body: "+err.Error())
+}
+
+// partsReader reads the spooled parts as a whole
+type partsReader struct {
+	files   []*os.File
+	offsets []int64
+	sizes   []int64
+	size    int64
+}
+
+func openParts(parts []*multipartPart) (*partsReader, error) {
+	reader := &partsReader{}
+	for _, part := range parts {
+		f, err := os.Open(part.path)
+		if err != nil {
+			_ = reader.Close()
+			return nil, errors.WithMessage(err, "failed open the spooled part")
+		}
+		reader.files = append(reader.files, f)
+		reader.offsets = append(reader.offsets, reader.size)
+		reader.sizes = append(reader.sizes, part.size)
+		reader.size += part.size
+	}
+	return reader, nil
+}
+
+func (p *partsReader) ReadAt(b []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	if off >= p.size {
+		return 0, io.EOF
+	}
+	i := sort.Search(len(p.offsets), func(i int) bool { return p.offsets[i] > off }) - 1
+	for ; n < len(b) && i < len(p.files); i++ {
+		end := p.offsets[i] + p.sizes[i]
+		if off >= end {
+			continue
+		}
+		want := int(min(int64(len(b)-n), end-off))
+		m, err := p.files[i].ReadAt(b[n:n+want], off-p.offsets[i])
+		n += m
+		off += int64(m)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return n, err
+		}
+	}
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (p *partsReader) Close() (err error) {
+	for _, f := range p.files {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// objectMeta returns the headers of the initiation request which are stored with the object
+func objectMeta(header http.Header) map[string]string {
+	meta := make(map[string]string)
+	for k, v := range header {
+		switch {
+		case strings.HasPrefix(k, "X-Amz-Meta-"), k == "Content-Type", k == "Content-Disposition",
+			k == "Content-Encoding", k == "Content-Language", k == "Cache-Control":
+			meta[k] = v[0]
+		}
+	}
+	return meta
+}
+
+func requestUserID(ctx context.Context) uint {
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		return user.ID
+	}
+	return 0
+}
+
+func queryInt(value string, def, minValue, maxValue int) (int, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < minValue {
+		return 0, gofakes3.ErrInvalidArgument
+	}
+	return min(n, maxValue), nil
+}
+
+func writeXML(w http.ResponseWriter, v any) error {
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write([]byte(xml.Header))
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func writeS3Error(w http.ResponseWriter, err error) {
+	code, message := errorCode(err)
+	status := code.Status()
+	switch code {
+	case errAccessDenied:
+		status = http.StatusForbidden
+	case errEntityTooSmall:
+		status = http.StatusBadRequest
+	case errSlowDown:
+		status = http.StatusServiceUnavailable
+	case gofakes3.ErrInternal:
+		log.Errorf("failed serve the multipart upload: %+v", err)
+	}
+	writeError(w, status, string(code), message)
+}
+
+func errorCode(err error) (gofakes3.ErrorCode, string) {
+	var resp *gofakes3.ErrorResponse
+	var s3Err gofakes3.Error
+	switch {
+	case errors.Is(err, errs.PermissionDenied):
+		return errAccessDenied, err.Error()
+	case errors.As(err, &resp):
+		return resp.Code, resp.Message
+	case errors.As(err, &s3Err):
+		return s3Err.ErrorCode(), string(s3Err.ErrorCode())
+	}
+	return gofakes3.ErrInternal, err.Error()
+}
@@ -0,0 +1,90 @@
+package s3
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/itsHenry35/gofakes3"
+)
+
+func TestChunkedReader(t *testing.T) {
+	sig := strings.Repeat("0", 64)
+	tests := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "signed",
+			body: "5;chunk-signature=" + sig + "\r\nhello\r\n6;chunk-signature=" + sig + "\r\n world\r\n0;chunk-signature=" + sig + "\r\n\r\n",
+		},
+		{
+			name: "unsigned with trailer",
+			body: "5\r\nhello\r\n6\r\n world\r\n0\r\nx-amz-checksum-crc32:AAAAAA==\r\n\r\n",
+		},
+		{
+			name: "without final crlf",
+			body: "b\r\nhello world\r\n0\r\n",
+		},
+	}
+	for _, tt := range tests {
+		data, err := io.ReadAll(newChunkedReader(strings.NewReader(tt.body)))
+		if err != nil || string(data) != "hello world" {
+			t.Errorf("%s: expect hello world, got %q, %v", tt.name, data, err)
+		}
+	}
+	if _, err := io.ReadAll(newChunkedReader(strings.NewReader("5\r\nhel"))); err == nil {
+		t.Errorf("expect error for the truncated body")
+	}
+}
+
+func TestPartsReader(t *testing.T) {
+	dir := t.TempDir()
+	upload := &multipartUpload{parts: make(map[int]*multipartPart)}
+	var completed []gofakes3.CompletedPart
+	for i, content := range []string{"0123", "", "456", "789"} {
+		part, err := spoolPart(dir, i+1, strings.NewReader(content), int64(len(content)))
+		if err != nil {
+			t.Fatalf("failed spool part: %+v", err)
+		}
+		upload.parts[i+1] = part
+		completed = append(completed, gofakes3.CompletedPart{PartNumber: i + 1, ETag: part.etag()})
+	}
+	if _, err := completedParts(upload, completed); !gofakes3.HasErrorCode(err, errEntityTooSmall) {
+		t.Fatalf("expect the small parts rejected, got %v", err)
+	}
+	if _, err := completedParts(upload, []gofakes3.CompletedPart{completed[1], completed[0]}); !gofakes3.HasErrorCode(err, gofakes3.ErrInvalidPartOrder) {
+		t.Errorf("expect the disordered parts rejected, got %v", err)
+	}
+	if _, err := completedParts(upload, []gofakes3.CompletedPart{{PartNumber: 1, ETag: fmt.Sprintf("%x", md5.Sum(nil))}}); !gofakes3.HasErrorCode(err, gofakes3.ErrInvalidPart) {
+		t.Errorf("expect the mismatched etag rejected, got %v", err)
+	}
+
+	parts := []*multipartPart{upload.parts[1], upload.parts[2], upload.parts[3], upload.parts[4]}
+	reader, err := openParts(parts)
+	if err != nil {
+		t.Fatalf("failed open parts: %+v", err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(io.NewSectionReader(reader, 0, reader.size))
+	if err != nil || string(data) != "0123456789" {
+		t.Fatalf("expect 0123456789, got %q, %v", data, err)
+	}
+	buf := make([]byte, 5)
+	if n, err := reader.ReadAt(buf, 2); err != nil || string(buf[:n]) != "23456" {
+		t.Errorf("expect 23456 at 2, got %q, %v", buf[:n], err)
+	}
+	if n, err := reader.ReadAt(buf, 8); err != io.EOF || string(buf[:n]) != "89" {
+		t.Errorf("expect 89 and eof at 8, got %q, %v", buf[:n], err)
+	}
+
+	if _, err = spoolPart(dir, 5, strings.NewReader("short"), 10); !gofakes3.HasErrorCode(err, gofakes3.ErrIncompleteBody) {
+		t.Errorf("expect the short part rejected, got %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "00005-*")); len(files) != 0 {
+		t.Errorf("expect the short part removed, got %v", files)
+	}
+}
@@ -13,15 +13,18 @@ import (
 // Make a new S3 Server to serve the remote
 func NewServer(ctx context.Context) (h http.Handler, err error) {
 	var newLogger logger
-	faker := gofakes3.New(
-		newBackend(),
+	backend := newBackend()
+	authPairs := authlistResolver()
+	opts := []gofakes3.Option{
 		// gofakes3.WithHostBucket(!opt.pathBucketMode),
 		gofakes3.WithLogger(newLogger),
 		gofakes3.WithRequestID(rand.Uint64()),
 		gofakes3.WithoutVersioning(),
-		gofakes3.WithV4Auth(authlistResolver()),
 		gofakes3.WithIntegrityCheck(true), // Check Content-MD5 if supplied
-	)
+	}
+	faker := gofakes3.New(backend, append(opts, gofakes3.WithV4Auth(authPairs))...)
+	// the requests of the users are verified by withUserKey, the keys of them aren't given to gofakes3
+	userFaker := gofakes3.New(backend, opts...)
 
-	return faker.Server(), nil
+	return withUserKey(withMultipart(byUser(faker.Server(), userFaker.Server()), backend, len(authPairs) > 0)), nil
 }
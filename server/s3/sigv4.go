@@ -0,0 +1,232 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the signature v4 of aws, https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
+const (
+	sigV4Algorithm   = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat  = "20060102T150405Z"
+	sigV4DateFormat  = "20060102"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	// how long the requests signed in the header are valid, and the max expiry of the presigned urls
+	sigV4HeaderExpiry = 15 * time.Minute
+	sigV4MaxExpiry    = 7 * 24 * time.Hour
+)
+
+// sigError is the error response of a request failing the verification
+type sigError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *sigError) Error() string {
+	return e.message
+}
+
+func accessDenied(message string) *sigError {
+	return &sigError{status: http.StatusForbidden, code: "AccessDenied", message: message}
+}
+
+type sigV4Values struct {
+	accessKey     string
+	date          string
+	region        string
+	service       string
+	signedHeaders []string
+	signature     string
+	signedAt      time.Time
+	expiry        time.Duration
+	payloadHash   string
+	presigned     bool
+}
+
+// parseSigV4 reads the signature v4 of the request from the authorization header or the query
+func parseSigV4(r *http.Request) (*sigV4Values, *sigError) {
+	query := r.URL.Query()
+	var (
+		v    = &sigV4Values{}
+		cred string
+		amz  string
+	)
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		algorithm, fields, _ := strings.Cut(auth, " ")
+		if algorithm != sigV4Algorithm {
+			return nil, accessDenied("only the signature v4 is supported for the keys of the users")
+		}
+		for _, field := range strings.Split(fields, ",") {
+			k, val, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch k {
+			case "Credential":
+				cred = val
+			case "SignedHeaders":
+				v.signedHeaders = strings.Split(val, ";")
+			case "Signature":
+				v.signature = val
+			}
+		}
+		amz = r.Header.Get("X-Amz-Date")
+		if amz == "" {
+			amz = r.Header.Get("Date")
+		}
+		v.expiry = sigV4HeaderExpiry
+		v.payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+		if v.payloadHash == "" {
+			v.payloadHash = emptyPayloadHash
+		}
+	} else {
+		if query.Get("X-Amz-Algorithm") != sigV4Algorithm {
+			return nil, accessDenied("only the signature v4 is supported for the keys of the users")
+		}
+		cred = query.Get("X-Amz-Credential")
+		v.signedHeaders = strings.Split(query.Get("X-Amz-SignedHeaders"), ";")
+		v.signature = query.Get("X-Amz-Signature")
+		amz = query.Get("X-Amz-Date")
+		seconds, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+		if err != nil || seconds <= 0 || time.Duration(seconds)*time.Second > sigV4MaxExpiry {
+			return nil, &sigError{status: http.StatusBadRequest, code: "AuthorizationQueryParametersError", message: "X-Amz-Expires is invalid"}
+		}
+		v.expiry = time.Duration(seconds) * time.Second
+		v.payloadHash = unsignedPayload
+		v.presigned = true
+	}
+	parts := strings.Split(cred, "/")
+	if len(parts) != 5 || parts[4] != "aws4_request" || v.signature == "" {
+		return nil, accessDenied("the credential of the signature is malformed")
+	}
+	v.accessKey, v.date, v.region, v.service = parts[0], parts[1], parts[2], parts[3]
+	signedAt, err := time.Parse(sigV4TimeFormat, amz)
+	if err != nil {
+		return nil, accessDenied("the date of the signature is malformed")
+	}
+	v.signedAt = signedAt
+	if v.signedAt.Format(sigV4DateFormat) != v.date {
+		return nil, accessDenied("the date of the credential doesn't match the date of the signature")
+	}
+	return v, nil
+}
+
+// verifySigV4 verifies the signature v4 of the request is made by the secret of the key
+func verifySigV4(r *http.Request, accessKey, secret string) *sigError {
+	v, err := parseSigV4(r)
+	if err != nil {
+		return err
+	}
+	if v.accessKey != accessKey {
+		return accessDenied("the access key id doesn't match the credential of the signature")
+	}
+	now := time.Now()
+	if now.After(v.signedAt.Add(v.expiry)) || !v.presigned && v.signedAt.After(now.Add(sigV4HeaderExpiry)) {
+		return &sigError{status: http.StatusForbidden, code: "RequestTimeTooSkewed", message: "the request is expired or signed in the future"}
+	}
+	headers, err := signedHeaderValues(r, v.signedHeaders)
+	if err != nil {
+		return err
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		encodeSigV4Path(r.URL.Path),
+		canonicalQuery(r.URL.Query()),
+		headers,
+		strings.Join(v.signedHeaders, ";"),
+		v.payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{v.date, v.region, v.service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{sigV4Algorithm, v.signedAt.Format(sigV4TimeFormat), scope, hex.EncodeToString(requestHash[:])}, "\n")
+	key := hmacSHA256([]byte("AWS4"+secret), v.date)
+	for _, s := range []string{v.region, v.service, "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	expected := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(v.signature)) != 1 {
+		return &sigError{status: http.StatusForbidden, code: "SignatureDoesNotMatch", message: "the signature doesn't match"}
+	}
+	return nil
+}
+
+// signedHeaderValues returns the canonical headers of the signed headers, which must include the host
+func signedHeaderValues(r *http.Request, names []string) (string, *sigError) {
+	var b strings.Builder
+	hasHost := false
+	for _, name := range names {
+		var values []string
+		switch name {
+		case "host":
+			hasHost = true
+			values = []string{r.Host}
+		case "content-length":
+			values = []string{strconv.FormatInt(r.ContentLength, 10)}
+		case "transfer-encoding":
+			values = r.TransferEncoding
+		default:
+			var ok bool
+			if values, ok = r.Header[http.CanonicalHeaderKey(name)]; !ok {
+				if name != "expect" {
+					return "", accessDenied("the signed header " + name + " is missing")
+				}
+				// removed by the http server of go
+				values = []string{"100-continue"}
+			}
+		}
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		b.WriteString(name + ":" + strings.Join(trimmed, ",") + "\n")
+	}
+	if !hasHost {
+		return "", accessDenied("the host header must be signed")
+	}
+	return b.String(), nil
+}
+
+// canonicalQuery encodes the query sorted by the keys, without the signature
+func canonicalQuery(query url.Values) string {
+	query.Del("X-Amz-Signature")
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, sigV4Escape(k)+"="+sigV4Escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// sigV4Escape percent-encodes everything but the unreserved characters
+func sigV4Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func encodeSigV4Path(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = sigV4Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
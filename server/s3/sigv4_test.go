@@ -0,0 +1,83 @@
+package s3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+func TestVerifySigV4(t *testing.T) {
+	const (
+		keyID  = "s3k_0123456789abcdefg"
+		secret = "0123456789abcdefghijklmnopqrstuvwxyzABCD"
+	)
+	signer := v4.NewSigner(credentials.NewStaticCredentials(keyID, secret, ""), func(s *v4.Signer) {
+		s.DisableURIPathEscaping = true
+	})
+	newRequest := func(presign bool, secret string) *http.Request {
+		body := "hello world"
+		r := httptest.NewRequest(http.MethodPut, "http://example.com/photos/a%20b.txt?tagging=&x-id=PutObject", strings.NewReader(body))
+		s := signer
+		if secret != "" {
+			s = v4.NewSigner(credentials.NewStaticCredentials(keyID, secret, ""), func(s *v4.Signer) {
+				s.DisableURIPathEscaping = true
+			})
+		}
+		var err error
+		if presign {
+			_, err = s.Presign(r, strings.NewReader(body), "s3", "us-east-1", time.Hour, time.Now())
+		} else {
+			_, err = s.Sign(r, strings.NewReader(body), "s3", "us-east-1", time.Now())
+		}
+		if err != nil {
+			t.Fatalf("failed sign request: %+v", err)
+		}
+		return r
+	}
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		code    string
+	}{
+		{name: "header", request: func() *http.Request { return newRequest(false, "") }},
+		{name: "presigned", request: func() *http.Request { return newRequest(true, "") }},
+		{name: "wrong secret", request: func() *http.Request { return newRequest(false, "wrong") }, code: "SignatureDoesNotMatch"},
+		{name: "presigned wrong secret", request: func() *http.Request { return newRequest(true, "wrong") }, code: "SignatureDoesNotMatch"},
+		{name: "tampered path", request: func() *http.Request {
+			r := newRequest(false, "")
+			r.URL.Path = "/photos/c.txt"
+			return r
+		}, code: "SignatureDoesNotMatch"},
+		{name: "tampered query", request: func() *http.Request {
+			r := newRequest(true, "")
+			q := r.URL.Query()
+			q.Set("x-id", "DeleteObject")
+			r.URL.RawQuery = q.Encode()
+			return r
+		}, code: "SignatureDoesNotMatch"},
+		{name: "signature v2", request: func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "http://example.com/photos", nil)
+			r.Header.Set("Authorization", "AWS "+keyID+":c2lnbmF0dXJl")
+			return r
+		}, code: "AccessDenied"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySigV4(tt.request(), keyID, secret)
+			if tt.code == "" && err != nil {
+				t.Errorf("expect the request verified, got %v", err)
+			}
+			if tt.code != "" && (err == nil || err.code != tt.code) {
+				t.Errorf("expect %s, got %v", tt.code, err)
+			}
+		})
+	}
+	if err := verifySigV4(newRequest(false, ""), "s3k_other", secret); err == nil {
+		t.Errorf("expect the request signed by another key rejected")
+	}
+}
@@ -5,6 +5,8 @@ package s3
 import (
 	"context"
 	"encoding/json"
+	stdpath "path"
+	"slices"
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -13,7 +15,9 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/itsHenry35/gofakes3"
+	"github.com/pkg/errors"
 )
 
 type Bucket struct {
@@ -23,14 +27,42 @@ type Bucket struct {
 
 const emptyObjectName = "ThisIsAnEmptyFolderInTheS3Bucket"
 
-func getAndParseBuckets() ([]Bucket, error) {
+// getAndParseBuckets returns the buckets the request can see. A request of a user sees the
+// global buckets and the ones of the user, which override the global ones with the same name,
+// and the paths of them are relative to the base path of the user.
+func getAndParseBuckets(ctx context.Context) ([]Bucket, error) {
 	var res []Bucket
 	err := json.Unmarshal([]byte(setting.GetStr(conf.S3Buckets)), &res)
-	return res, err
+	if err != nil {
+		return nil, err
+	}
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if !ok {
+		return res, nil
+	}
+	own, err := op.GetS3BucketsByUserId(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	buckets := make([]Bucket, 0, len(res)+len(own))
+	for _, b := range res {
+		if !slices.ContainsFunc(own, func(o model.S3Bucket) bool { return o.Name == b.Name }) {
+			buckets = append(buckets, b)
+		}
+	}
+	for _, b := range own {
+		buckets = append(buckets, Bucket{Name: b.Name, Path: b.Path})
+	}
+	for i := range buckets {
+		if buckets[i].Path, err = user.JoinPath(buckets[i].Path); err != nil {
+			return nil, err
+		}
+	}
+	return buckets, nil
 }
 
-func getBucketByName(name string) (Bucket, error) {
-	buckets, err := getAndParseBuckets()
+func getBucketByName(ctx context.Context, name string) (Bucket, error) {
+	buckets, err := getAndParseBuckets(ctx)
 	if err != nil {
 		return Bucket{}, err
 	}
@@ -42,8 +74,10 @@ func getBucketByName(name string) (Bucket, error) {
 	return Bucket{}, gofakes3.BucketNotFound(name)
 }
 
-func getDirEntries(path string) ([]model.Obj, error) {
-	ctx := context.Background()
+func getDirEntries(ctx context.Context, path string) ([]model.Obj, error) {
+	if err := checkAccess(ctx, model.ACLRead, path); err != nil {
+		return nil, err
+	}
 	meta, _ := op.GetNearestMeta(path)
 	fi, err := fs.Get(context.WithValue(ctx, conf.MetaKey, meta), path, &fs.GetArgs{})
 	if errs.IsNotFoundError(err) {
@@ -64,6 +98,32 @@ func getDirEntries(path string) ([]model.Obj, error) {
 	return dirEntries, nil
 }
 
+// checkAccess denies the operation on the path if the user of the request can't do it by the
+// permissions, the metas or the acl entries. The requests signed by the global key act as admin.
+func checkAccess(ctx context.Context, perm int32, fp string) error {
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if !ok {
+		return nil
+	}
+	meta, _ := op.GetNearestMeta(fp)
+	if !common.CanAccess(user, meta, fp, "") {
+		return errors.WithStack(errs.PermissionDenied)
+	}
+	var allowed bool
+	switch perm {
+	case model.ACLRead:
+		return nil
+	case model.ACLWrite:
+		allowed = user.CanWrite() || common.CanWrite(meta, stdpath.Dir(fp))
+	case model.ACLRemove:
+		allowed = user.CanRemove()
+	}
+	if !common.HasPermission(user, perm, allowed, fp) {
+		return errors.WithStack(errs.PermissionDenied)
+	}
+	return nil
+}
+
 // func getFileHashByte(node interface{}) []byte {
 // 	b, err := hex.DecodeString(getFileHash(node))
 // 	if err != nil {
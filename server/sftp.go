@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
@@ -17,6 +18,9 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
+// apiTokenExtension keeps the key id of the api token the connection is authenticated by
+const apiTokenExtension = "openlist-api-token"
+
 type SftpDriver struct {
 	proxyHeader http.Header
 	config      *sftpd.Config
@@ -56,13 +60,20 @@ func (d *SftpDriver) GetConfig() *sftpd.Config {
 }
 
 func (d *SftpDriver) GetFileSystem(sc *ssh.ServerConn) (sftpd.FileSystem, error) {
-	userObj, err := op.GetUserByName(sc.User())
+	var userObj *model.User
+	var err error
+	if keyID := apiTokenKeyID(sc.Permissions); keyID != "" {
+		userObj, err = op.AuthAPITokenKey(keyID, sc.RemoteAddr().String())
+	} else {
+		userObj, err = op.GetUserByName(sc.User())
+	}
 	if err != nil {
 		return nil, err
 	}
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, conf.UserKey, userObj)
 	ctx = context.WithValue(ctx, conf.MetaPassKey, "")
+	ctx = context.WithValue(ctx, conf.ProtocolKey, "sftp")
 	ctx = context.WithValue(ctx, conf.ClientIPKey, sc.RemoteAddr().String())
 	ctx = context.WithValue(ctx, conf.ProxyHeaderKey, d.proxyHeader)
 	return &sftp.DriverAdapter{FtpDriver: ftp.NewAferoAdapter(ctx)}, nil
@@ -86,6 +97,9 @@ func (d *SftpDriver) NoClientAuth(conn ssh.ConnMetadata) (*ssh.Permissions, erro
 }
 
 func (d *SftpDriver) PasswordAuth(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	if op.IsAPIToken(string(password)) {
+		return d.APITokenAuth(conn, string(password))
+	}
 	userObj, err := op.GetUserByName(conn.User())
 	if err != nil {
 		return nil, err
@@ -100,6 +114,29 @@ func (d *SftpDriver) PasswordAuth(conn ssh.ConnMetadata, password []byte) (*ssh.
 	return nil, nil
 }
 
+// APITokenAuth takes an api token of the user as the password, the key id of it is kept in
+// the permissions of the connection for the file system to act as the token
+func (d *SftpDriver) APITokenAuth(conn ssh.ConnMetadata, token string) (*ssh.Permissions, error) {
+	userObj, err := op.AuthAPIToken(token, conn.RemoteAddr().String())
+	if err != nil {
+		return nil, err
+	}
+	if userObj.Username != conn.User() {
+		return nil, errs.InvalidAPIToken
+	}
+	if userObj.Disabled || !userObj.CanFTPAccess() {
+		return nil, errors.New("user is not allowed to access via SFTP")
+	}
+	return &ssh.Permissions{Extensions: map[string]string{apiTokenExtension: userObj.Token.KeyID}}, nil
+}
+
+func apiTokenKeyID(p *ssh.Permissions) string {
+	if p == nil {
+		return ""
+	}
+	return p.Extensions[apiTokenExtension]
+}
+
 func (d *SftpDriver) PublicKeyAuth(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
 	userObj, err := op.GetUserByName(conn.User())
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
@@ -14,17 +15,23 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/webdav"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 var handler *webdav.Handler
 
 func WebDav(dav *gin.RouterGroup) {
+	lockSystem := webdav.NewDBLS()
+	if conf.Conf.WebDAV.LockSystem == "memory" {
+		lockSystem = webdav.NewMemLS()
+	}
 	handler = &webdav.Handler{
 		Prefix:     path.Join(conf.URL.Path, "/dav"),
-		LockSystem: webdav.NewMemLS(),
+		LockSystem: lockSystem,
 		Logger: func(request *http.Request, err error) {
 			log.Errorf("%s %s %+v", request.Method, request.URL.Path, err)
 		},
@@ -83,7 +90,12 @@ func WebDAVAuth(c *gin.Context) {
 				c.Next()
 				return
 			}
+			if op.IsAPIToken(bt) {
+				password, ok = bt, true
+			}
 		}
+	}
+	if !ok {
 		if c.Request.Method == "OPTIONS" {
 			common.GinWithValue(c, conf.UserKey, guest)
 			c.Next()
@@ -94,8 +106,8 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	user, err := op.GetUserByName(username)
-	if err != nil || user.ValidateRawPassword(password) != nil {
+	user, err := webdavUser(username, password, ip)
+	if err != nil {
 		if c.Request.Method == "OPTIONS" {
 			common.GinWithValue(c, conf.UserKey, guest)
 			c.Next()
@@ -118,27 +130,8 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
-	if (c.Request.Method == "PUT" || c.Request.Method == "MKCOL") && (!user.CanWebdavManage() || !user.CanWrite()) {
-		c.Status(http.StatusForbidden)
-		c.Abort()
-		return
-	}
-	if c.Request.Method == "MOVE" && (!user.CanWebdavManage() || (!user.CanMove() && !user.CanRename())) {
-		c.Status(http.StatusForbidden)
-		c.Abort()
-		return
-	}
-	if c.Request.Method == "COPY" && (!user.CanWebdavManage() || !user.CanCopy()) {
-		c.Status(http.StatusForbidden)
-		c.Abort()
-		return
-	}
-	if c.Request.Method == "DELETE" && (!user.CanWebdavManage() || !user.CanRemove()) {
-		c.Status(http.StatusForbidden)
-		c.Abort()
-		return
-	}
-	if c.Request.Method == "PROPPATCH" && !user.CanWebdavManage() {
+	// the operations are checked on their paths by the handlers, the acl entries may grant them
+	if utils.SliceContains([]string{"PUT", "MKCOL", "MOVE", "COPY", "DELETE", "PROPPATCH"}, c.Request.Method) && !user.CanWebdavManage() {
 		c.Status(http.StatusForbidden)
 		c.Abort()
 		return
@@ -146,3 +139,20 @@ func WebDAVAuth(c *gin.Context) {
 	common.GinWithValue(c, conf.UserKey, user)
 	c.Next()
 }
+
+// webdavUser authenticates by the password of the user or by an api token of it,
+// the username can be omitted for the api token
+func webdavUser(username, password, ip string) (*model.User, error) {
+	if op.IsAPIToken(password) {
+		user, err := op.AuthAPIToken(password, ip)
+		if err == nil && username != "" && username != user.Username {
+			return nil, errors.WithStack(errs.InvalidAPIToken)
+		}
+		return user, err
+	}
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		return nil, err
+	}
+	return user, user.ValidateRawPassword(password)
+}
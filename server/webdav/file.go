@@ -14,6 +14,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
 )
 
 // slashClean is equivalent to but slightly more efficient than
@@ -34,10 +35,10 @@ func moveFiles(ctx context.Context, src, dst string, overwrite bool) (status int
 	srcName := path.Base(src)
 	dstName := path.Base(dst)
 	user := ctx.Value(conf.UserKey).(*model.User)
-	if srcDir != dstDir && !user.CanMove() {
+	if srcDir != dstDir && !common.HasPermission(user, model.ACLMove, user.CanMove(), src, dstDir) {
 		return http.StatusForbidden, nil
 	}
-	if srcName != dstName && !user.CanRename() {
+	if srcName != dstName && !common.HasPermission(user, model.ACLRename, user.CanRename(), src) {
 		return http.StatusForbidden, nil
 	}
 	if srcDir == dstDir {
@@ -0,0 +1,205 @@
+package webdav
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// dbLSHoldTimeout is how long a lock held by a request is taken as held. It's released
+// by the request, unless the instance serving it is gone.
+const dbLSHoldTimeout = 6 * time.Hour
+
+// NewDBLS returns a LockSystem keeping the locks in the database, so that they are kept
+// over restarts and shared by all the instances using the same database.
+func NewDBLS() LockSystem {
+	return dbLS{}
+}
+
+type dbLS struct{}
+
+func staleBefore(now time.Time) int64 {
+	return now.Add(-dbLSHoldTimeout).UnixNano()
+}
+
+func (dbLS) collectExpired(now time.Time) error {
+	return db.DeleteExpiredWebDAVLocks(now.UnixNano(), staleBefore(now))
+}
+
+// lookup returns the lock that locks the named resource, provided that it matches
+// at least one of the given conditions and that it isn't held by another party.
+func (dbLS) lookup(now time.Time, name string, conditions ...Condition) (*model.WebDAVLock, error) {
+	for _, c := range conditions {
+		if c.Token == "" {
+			continue
+		}
+		lock, err := db.GetWebDAVLockByToken(c.Token)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if lock.Held && lock.HeldAt >= staleBefore(now) {
+			continue
+		}
+		if name == lock.Root {
+			return lock, nil
+		}
+		if lock.ZeroDepth {
+			continue
+		}
+		if lock.Root == "/" || strings.HasPrefix(name, lock.Root+"/") {
+			return lock, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m dbLS) Confirm(now time.Time, name0, name1 string, conditions ...Condition) (func(), error) {
+	if err := m.collectExpired(now); err != nil {
+		return nil, err
+	}
+	var n0, n1 *model.WebDAVLock
+	var err error
+	if name0 != "" {
+		if n0, err = m.lookup(now, slashClean(name0), conditions...); err != nil {
+			return nil, err
+		} else if n0 == nil {
+			return nil, ErrConfirmationFailed
+		}
+	}
+	if name1 != "" {
+		if n1, err = m.lookup(now, slashClean(name1), conditions...); err != nil {
+			return nil, err
+		} else if n1 == nil {
+			return nil, ErrConfirmationFailed
+		}
+	}
+
+	// Don't hold the same lock twice.
+	if n0 != nil && n1 != nil && n0.ID == n1.ID {
+		n1 = nil
+	}
+
+	var held []*model.WebDAVLock
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			_ = db.ReleaseWebDAVLock(held[i].ID)
+		}
+	}
+	for _, n := range []*model.WebDAVLock{n0, n1} {
+		if n == nil {
+			continue
+		}
+		// another request may hold it since the lookup
+		ok, err := db.HoldWebDAVLock(n.ID, now.UnixNano(), staleBefore(now))
+		if err != nil || !ok {
+			release()
+			if err != nil {
+				return nil, err
+			}
+			return nil, ErrConfirmationFailed
+		}
+		held = append(held, n)
+	}
+	return release, nil
+}
+
+func (m dbLS) Create(now time.Time, details LockDetails) (string, error) {
+	if err := m.collectExpired(now); err != nil {
+		return "", err
+	}
+	details.Root = slashClean(details.Root)
+	var ancestors []string
+	walkToRoot(details.Root, func(name0 string, first bool) bool {
+		if !first {
+			ancestors = append(ancestors, name0)
+		}
+		return true
+	})
+	lock := &model.WebDAVLock{
+		Token:     "urn:uuid:" + uuid.NewString(),
+		Root:      details.Root,
+		ZeroDepth: details.ZeroDepth,
+		OwnerXML:  details.OwnerXML,
+		Duration:  int64(details.Duration),
+	}
+	if details.Duration >= 0 {
+		lock.Expiry = now.Add(details.Duration).UnixNano()
+	}
+	created, err := db.CreateWebDAVLock(lock, ancestors)
+	if err != nil {
+		return "", err
+	}
+	if !created {
+		return "", ErrLocked
+	}
+	return lock.Token, nil
+}
+
+// get returns the lock of the token, and whether it's held by a request
+func (m dbLS) get(now time.Time, token string) (*model.WebDAVLock, bool, error) {
+	if err := m.collectExpired(now); err != nil {
+		return nil, false, err
+	}
+	lock, err := db.GetWebDAVLockByToken(token)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrNoSuchLock
+		}
+		return nil, false, err
+	}
+	return lock, lock.Held && lock.HeldAt >= staleBefore(now), nil
+}
+
+func (m dbLS) Refresh(now time.Time, token string, duration time.Duration) (LockDetails, error) {
+	lock, held, err := m.get(now, token)
+	if err != nil {
+		return LockDetails{}, err
+	}
+	if held {
+		return LockDetails{}, ErrLocked
+	}
+	var expiry int64
+	if duration >= 0 {
+		expiry = now.Add(duration).UnixNano()
+	}
+	ok, err := db.RefreshWebDAVLock(lock.ID, int64(duration), expiry, staleBefore(now))
+	if err != nil {
+		return LockDetails{}, err
+	}
+	// nothing is changed if the lock is held since, or it's refreshed to the same expiry
+	if !ok && (lock.Duration != int64(duration) || lock.Expiry != expiry) {
+		return LockDetails{}, ErrLocked
+	}
+	return LockDetails{
+		Root:      lock.Root,
+		Duration:  duration,
+		OwnerXML:  lock.OwnerXML,
+		ZeroDepth: lock.ZeroDepth,
+	}, nil
+}
+
+func (m dbLS) Unlock(now time.Time, token string) error {
+	lock, held, err := m.get(now, token)
+	if err != nil {
+		return err
+	}
+	if held {
+		return ErrLocked
+	}
+	ok, err := db.DeleteWebDAVLock(lock.ID, staleBefore(now))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLocked
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package webdav
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func newTestDBLS(t *testing.T) LockSystem {
+	if err := db.GetDb().Where("1 = 1").Delete(&model.WebDAVLock{}).Error; err != nil {
+		t.Fatalf("failed clear locks: %v", err)
+	}
+	return NewDBLS()
+}
+
+func TestDBLSCanCreate(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := newTestDBLS(t)
+	for _, name := range lockTestNames {
+		_, err := m.Create(now, LockDetails{
+			Root:      name,
+			Duration:  infiniteTimeout,
+			ZeroDepth: lockTestZeroDepth(name),
+		})
+		if err != nil {
+			t.Fatalf("creating lock for %q: %v", name, err)
+		}
+	}
+
+	wantCanCreate := func(name string, zeroDepth bool) bool {
+		for _, n := range lockTestNames {
+			switch {
+			case n == name:
+				return false
+			case strings.HasPrefix(n, name):
+				if !zeroDepth {
+					return false
+				}
+			case strings.HasPrefix(name, n):
+				if n[len(n)-1] == 'i' {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	var check func(int, string)
+	check = func(recursion int, name string) {
+		for _, zeroDepth := range []bool{false, true} {
+			token, err := m.Create(now, LockDetails{
+				Root:      name,
+				Duration:  infiniteTimeout,
+				ZeroDepth: zeroDepth,
+			})
+			if err != nil && err != ErrLocked {
+				t.Fatalf("Create name=%q: %v", name, err)
+			}
+			if got, want := err == nil, wantCanCreate(name, zeroDepth); got != want {
+				t.Errorf("Create name=%q zeroDepth=%t: got %t, want %t", name, zeroDepth, got, want)
+			}
+			if err == nil {
+				if err = m.Unlock(now, token); err != nil {
+					t.Fatalf("Unlock name=%q: %v", name, err)
+				}
+			}
+		}
+		if recursion == 4 {
+			return
+		}
+		if name != "/" {
+			name += "/"
+		}
+		for _, c := range "_iz" {
+			check(recursion+1, name+string(c))
+		}
+	}
+	check(0, "/")
+}
+
+func TestDBLSConfirm(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := newTestDBLS(t)
+	alice, err := m.Create(now, LockDetails{Root: "/alice", Duration: infiniteTimeout})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	tweedle, err := m.Create(now, LockDetails{Root: "/tweedle", Duration: infiniteTimeout})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Test a mismatch between name and condition.
+	if _, err = m.Confirm(now, "/tweedle/dee", "", Condition{Token: alice}); err != ErrConfirmationFailed {
+		t.Fatalf("Confirm (mismatch): got %v, want ErrConfirmationFailed", err)
+	}
+
+	// Test two names (that fall under the same lock) in the one Confirm call.
+	release, err := m.Confirm(now, "/tweedle/dee", "/tweedle/dum", Condition{Token: tweedle})
+	if err != nil {
+		t.Fatalf("Confirm (twins): %v", err)
+	}
+	release()
+
+	// Test the same two names in overlapping Confirm / release calls,
+	// the second one made by another instance.
+	releaseDee, err := m.Confirm(now, "/tweedle/dee", "", Condition{Token: tweedle})
+	if err != nil {
+		t.Fatalf("Confirm (sequence #0): %v", err)
+	}
+	if _, err = NewDBLS().Confirm(now, "/tweedle/dum", "", Condition{Token: tweedle}); err != ErrConfirmationFailed {
+		t.Fatalf("Confirm (sequence #1): got %v, want ErrConfirmationFailed", err)
+	}
+	releaseDee()
+	releaseDum, err := NewDBLS().Confirm(now, "/tweedle/dum", "", Condition{Token: tweedle})
+	if err != nil {
+		t.Fatalf("Confirm (sequence #3): %v", err)
+	}
+
+	// Test that you can't refresh or unlock a held lock.
+	if _, err = m.Refresh(now, tweedle, time.Second); err != ErrLocked {
+		t.Fatalf("Refresh (sequence #4): got %v, want ErrLocked", err)
+	}
+	if err = m.Unlock(now, tweedle); err != ErrLocked {
+		t.Fatalf("Unlock (sequence #4): got %v, want ErrLocked", err)
+	}
+	releaseDum()
+	if err = m.Unlock(now, tweedle); err != nil {
+		t.Fatalf("Unlock (sequence #6): %v", err)
+	}
+	if err = m.Unlock(now, tweedle); err != ErrNoSuchLock {
+		t.Fatalf("Unlock (sequence #7): got %v, want ErrNoSuchLock", err)
+	}
+
+	// A lock held by a request which never releases it is taken as released at last.
+	releaseAlice, err := m.Confirm(now, "/alice", "", Condition{Token: alice})
+	if err != nil || releaseAlice == nil {
+		t.Fatalf("Confirm (stale): %v", err)
+	}
+	if err = m.Unlock(now.Add(dbLSHoldTimeout+time.Second), alice); err != nil {
+		t.Fatalf("Unlock (stale): %v", err)
+	}
+}
+
+func TestDBLSExpiry(t *testing.T) {
+	m := newTestDBLS(t)
+	at := func(d int) time.Time {
+		return time.Unix(0, 0).Add(time.Duration(d) * time.Second)
+	}
+	want := func(now time.Time, roots ...string) {
+		t.Helper()
+		if err := m.(dbLS).collectExpired(now); err != nil {
+			t.Fatalf("collectExpired: %v", err)
+		}
+		var locks []model.WebDAVLock
+		if err := db.GetDb().Find(&locks).Error; err != nil {
+			t.Fatalf("failed find locks: %v", err)
+		}
+		got := []string{}
+		for _, l := range locks {
+			got = append(got, fmt.Sprintf("%s.%d", l.Root, time.Duration(l.Expiry)/time.Second))
+		}
+		sort.Strings(got)
+		if roots == nil {
+			roots = []string{}
+		}
+		if !reflect.DeepEqual(got, roots) {
+			t.Fatalf("at %v: got %q, want %q", now.Unix(), got, roots)
+		}
+	}
+	create := func(now time.Time, root string, d int) string {
+		t.Helper()
+		token, err := m.Create(now, LockDetails{Root: root, Duration: at(d).Sub(now), ZeroDepth: true})
+		if err != nil {
+			t.Fatalf("Create %s: %v", root, err)
+		}
+		return token
+	}
+
+	create(at(0), "/a", 5)
+	c := create(at(0), "/c", 6)
+	create(at(0), "/a/b", 7)
+	want(at(4), "/a.5", "/a/b.7", "/c.6")
+	want(at(5), "/a/b.7", "/c.6")
+	want(at(6), "/a/b.7")
+	want(at(7))
+	if _, err := m.Refresh(at(7), c, time.Second); err != ErrNoSuchLock {
+		t.Fatalf("Refresh expired: got %v, want ErrNoSuchLock", err)
+	}
+
+	a := create(at(8), "/a", 12)
+	create(at(8), "/b", 13)
+	got, err := m.Refresh(at(8), a, 6*time.Second)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if wantDetails := (LockDetails{Root: "/a", Duration: 6 * time.Second, ZeroDepth: true}); got != wantDetails {
+		t.Fatalf("Refresh: got %v, want %v", got, wantDetails)
+	}
+	want(at(13), "/a.14")
+	want(at(14))
+}
@@ -17,6 +17,7 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 )
@@ -102,6 +103,62 @@ type DeadPropsHolder interface {
 	Patch([]Proppatch) ([]Propstat, error)
 }
 
+// dbDeadProps is the DeadPropsHolder of the object at the full path, keeping the dead
+// properties in the database so that they follow the object moved, copied or removed.
+type dbDeadProps string
+
+func (p dbDeadProps) DeadProps() (map[xml.Name]Property, error) {
+	props, err := op.GetDeadProps(string(p))
+	if err != nil {
+		return nil, err
+	}
+	if len(props) == 0 {
+		return nil, nil
+	}
+	m := make(map[xml.Name]Property, len(props))
+	for _, prop := range props {
+		name := xml.Name{Space: prop.Space, Local: prop.Local}
+		m[name] = Property{
+			XMLName:  name,
+			Lang:     prop.Lang,
+			InnerXML: []byte(prop.InnerXML),
+		}
+	}
+	return m, nil
+}
+
+func (p dbDeadProps) Patch(patches []Proppatch) ([]Propstat, error) {
+	pstat := Propstat{Status: http.StatusOK}
+	// the later instructions on the same property win
+	var names []xml.Name
+	removed := make(map[xml.Name]bool)
+	values := make(map[xml.Name]Property)
+	for _, patch := range patches {
+		for _, prop := range patch.Props {
+			pstat.Props = append(pstat.Props, Property{XMLName: prop.XMLName})
+			if _, ok := removed[prop.XMLName]; !ok {
+				names = append(names, prop.XMLName)
+			}
+			removed[prop.XMLName] = patch.Remove
+			values[prop.XMLName] = prop
+		}
+	}
+	var set, remove []model.DeadProp
+	for _, name := range names {
+		prop := model.DeadProp{Space: name.Space, Local: name.Local}
+		if removed[name] {
+			remove = append(remove, prop)
+			continue
+		}
+		prop.Lang, prop.InnerXML = values[name].Lang, string(values[name].InnerXML)
+		set = append(set, prop)
+	}
+	if err := op.PatchDeadProps(string(p), set, remove); err != nil {
+		return nil, err
+	}
+	return []Propstat{pstat}, nil
+}
+
 // liveProps contains all supported properties.
 var liveProps = map[xml.Name]struct {
 	// findFn implements the propfind function of this property. If nil,
@@ -109,6 +166,9 @@ var liveProps = map[xml.Name]struct {
 	findFn func(context.Context, LockSystem, string, model.Obj) (string, error)
 	// dir is true if the property applies to directories.
 	dir bool
+	// explicit is true if the property is only returned when it's named,
+	// as it's expensive to compute. See RFC 4331 section 3.
+	explicit bool
 }{
 	{Space: "DAV:", Local: "resourcetype"}: {
 		findFn: findResourceType,
@@ -165,6 +225,16 @@ var liveProps = map[xml.Name]struct {
 		findFn: findChecksums,
 		dir:    false,
 	},
+	{Space: "DAV:", Local: "quota-available-bytes"}: {
+		findFn:   findQuotaAvailableBytes,
+		dir:      true,
+		explicit: true,
+	},
+	{Space: "DAV:", Local: "quota-used-bytes"}: {
+		findFn:   findQuotaUsedBytes,
+		dir:      true,
+		explicit: true,
+	},
 }
 
 // TODO(nigeltao) merge props and allprop?
@@ -173,7 +243,7 @@ var liveProps = map[xml.Name]struct {
 //
 // Each Propstat has a unique status and each property name will only be part
 // of one Propstat element.
-func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
+func props(ctx context.Context, ls LockSystem, name string, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return nil, err
@@ -185,14 +255,10 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 	//}
 	isDir := fi.IsDir()
 
-	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
+	deadProps, err := dbDeadProps(name).DeadProps()
+	if err != nil {
+		return nil, err
+	}
 
 	pstatOK := Propstat{Status: http.StatusOK}
 	pstatNotFound := Propstat{Status: http.StatusNotFound}
@@ -204,7 +270,13 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 		}
 		// Otherwise, it must either be a live property or we don't know it.
 		if prop := liveProps[pn]; prop.findFn != nil && (prop.dir || !isDir) {
-			innerXML, err := prop.findFn(ctx, ls, fi.GetName(), fi)
+			innerXML, err := prop.findFn(ctx, ls, name, fi)
+			if errors.Is(err, ErrNotImplemented) {
+				pstatNotFound.Props = append(pstatNotFound.Props, Property{
+					XMLName: pn,
+				})
+				continue
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -222,7 +294,7 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 }
 
 // Propnames returns the property names defined for resource name.
-func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, error) {
+func propnames(ctx context.Context, ls LockSystem, name string, fi model.Obj) ([]xml.Name, error) {
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return nil, err
@@ -234,18 +306,14 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 	//}
 	isDir := fi.IsDir()
 
-	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
+	deadProps, err := dbDeadProps(name).DeadProps()
+	if err != nil {
+		return nil, err
+	}
 
 	pnames := make([]xml.Name, 0, len(liveProps)+len(deadProps))
 	for pn, prop := range liveProps {
-		if prop.findFn != nil && (prop.dir || !isDir) {
+		if prop.findFn != nil && !prop.explicit && (prop.dir || !isDir) {
 			pnames = append(pnames, pn)
 		}
 	}
@@ -263,8 +331,8 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 // returned if they are named in 'include'.
 //
 // See http://www.webdav.org/specs/rfc4918.html#METHOD_PROPFIND
-func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Name) ([]Propstat, error) {
-	pnames, err := propnames(ctx, ls, fi)
+func allprop(ctx context.Context, ls LockSystem, name string, fi model.Obj, include []xml.Name) ([]Propstat, error) {
+	pnames, err := propnames(ctx, ls, name, fi)
 	if err != nil {
 		return nil, err
 	}
@@ -278,7 +346,7 @@ func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Nam
 			pnames = append(pnames, pn)
 		}
 	}
-	return props(ctx, ls, fi, pnames)
+	return props(ctx, ls, name, fi, pnames)
 }
 
 // Patch patches the properties of resource name. The return values are
@@ -314,38 +382,19 @@ loop:
 		return makePropstats(pstatForbidden, pstatFailedDep), nil
 	}
 
-	// ------------------------------------------------------------
-	//f, err := fs.OpenFile(ctx, name, os.O_RDWR, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	ret, err := dph.Patch(patches)
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//	// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
-	//	// "The contents of the prop XML element must only list the names of
-	//	// properties to which the result in the status element applies."
-	//	for _, pstat := range ret {
-	//		for i, p := range pstat.Props {
-	//			pstat.Props[i] = Property{XMLName: p.XMLName}
-	//		}
-	//	}
-	//	return ret, nil
-	//}
-	// ------------------------------------------------------------
-
-	// The file doesn't implement the optional DeadPropsHolder interface, so
-	// all patches are forbidden.
-	pstat := Propstat{Status: http.StatusForbidden}
-	for _, patch := range patches {
-		for _, p := range patch.Props {
-			pstat.Props = append(pstat.Props, Property{XMLName: p.XMLName})
+	ret, err := dbDeadProps(name).Patch(patches)
+	if err != nil {
+		return nil, err
+	}
+	// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
+	// "The contents of the prop XML element must only list the names of
+	// properties to which the result in the status element applies."
+	for _, pstat := range ret {
+		for i, p := range pstat.Props {
+			pstat.Props[i] = Property{XMLName: p.XMLName}
 		}
 	}
-	return []Propstat{pstat}, nil
+	return ret, nil
 }
 
 func escapeXML(s string) string {
@@ -376,7 +425,7 @@ func findResourceType(ctx context.Context, ls LockSystem, name string, fi model.
 }
 
 func findDisplayName(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
-	if slashClean(name) == "/" {
+	if slashClean(fi.GetName()) == "/" {
 		// Hide the real name of a possibly prefixed root directory.
 		return "", nil
 	}
@@ -486,6 +535,22 @@ func findSupportedLock(ctx context.Context, ls LockSystem, name string, fi model
 		`</D:lockentry>`, nil
 }
 
+func findQuotaAvailableBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	details, err := op.GetStorageDetailsByPath(ctx, name, common.MountReadable(ctx.Value(conf.UserKey).(*model.User)))
+	if err != nil || details.FreeSpace() < 0 {
+		return "", ErrNotImplemented
+	}
+	return strconv.FormatInt(details.FreeSpace(), 10), nil
+}
+
+func findQuotaUsedBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	details, err := op.GetStorageDetailsByPath(ctx, name, common.MountReadable(ctx.Value(conf.UserKey).(*model.User)))
+	if err != nil {
+		return "", ErrNotImplemented
+	}
+	return strconv.FormatInt(details.UsedSpace, 10), nil
+}
+
 func findChecksums(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
 	checksums := ""
 	for hashType, hashValue := range fi.GetHash().All() {
@@ -24,6 +24,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 )
@@ -228,6 +229,9 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request) (sta
 	if err != nil {
 		return http.StatusForbidden, err
 	}
+	if !common.HasPermission(user, model.ACLRead, true, reqPath) {
+		return http.StatusForbidden, errs.PermissionDenied
+	}
 	fi, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
 	if err != nil {
 		return http.StatusNotFound, err
@@ -296,6 +300,9 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 	if err != nil {
 		return 403, err
 	}
+	if !common.HasPermission(user, model.ACLRemove, user.CanRemove(), reqPath) {
+		return http.StatusForbidden, errs.PermissionDenied
+	}
 	// TODO: return MultiStatus where appropriate.
 
 	// "godoc os RemoveAll" says that "If the path does not exist, RemoveAll
@@ -341,6 +348,12 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if err != nil {
 		return http.StatusForbidden, err
 	}
+	if !common.HasPermission(user, model.ACLWrite, user.CanWrite(), reqPath) {
+		return http.StatusForbidden, errs.PermissionDenied
+	}
+	if err = op.CheckQuota(user, r.ContentLength); err != nil {
+		return StatusInsufficientStorage, err
+	}
 	obj := model.Object{
 		Name:     path.Base(reqPath),
 		Size:     r.ContentLength,
@@ -359,6 +372,9 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request) (status int,
 	if errs.IsNotFoundError(err) {
 		return http.StatusNotFound, err
 	}
+	if errors.Is(err, errs.QuotaExceeded) {
+		return StatusInsufficientStorage, err
+	}
 
 	// TODO(rost): Returning 405 Method Not Allowed might not be appropriate.
 	// if err != nil {
@@ -393,6 +409,9 @@ func (h *Handler) handleMkcol(w http.ResponseWriter, r *http.Request) (status in
 	if err != nil {
 		return 403, err
 	}
+	if !common.HasPermission(user, model.ACLWrite, user.CanWrite(), reqPath) {
+		return http.StatusForbidden, errs.PermissionDenied
+	}
 
 	if r.ContentLength > 0 {
 		return http.StatusUnsupportedMediaType, nil
@@ -485,6 +504,9 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 				return http.StatusBadRequest, errInvalidDepth
 			}
 		}
+		if !common.HasPermission(user, model.ACLCopy, user.CanCopy(), src, path.Dir(dst)) {
+			return http.StatusForbidden, errs.PermissionDenied
+		}
 		return copyFiles(ctx, src, dst, r.Header.Get("Overwrite") != "F")
 	}
 
@@ -641,6 +663,9 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 	if err != nil {
 		return 403, err
 	}
+	if !common.HasPermission(user, model.ACLRead, true, reqPath) {
+		return http.StatusForbidden, errs.PermissionDenied
+	}
 	fi, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
 	if err != nil {
 		if errs.IsNotFoundError(err) {
@@ -668,7 +693,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		}
 		var pstats []Propstat
 		if pf.Propname != nil {
-			pnames, err := propnames(ctx, h.LockSystem, info)
+			pnames, err := propnames(ctx, h.LockSystem, reqPath, info)
 			if err != nil {
 				return err
 			}
@@ -678,9 +703,9 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			}
 			pstats = append(pstats, pstat)
 		} else if pf.Allprop != nil {
-			pstats, err = allprop(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = allprop(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		} else {
-			pstats, err = props(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = props(ctx, h.LockSystem, reqPath, info, pf.Prop)
 		}
 		if err != nil {
 			return err
@@ -720,6 +745,9 @@ func (h *Handler) handleProppatch(w http.ResponseWriter, r *http.Request) (statu
 	if err != nil {
 		return 403, err
 	}
+	if !common.HasPermission(user, model.ACLWrite, user.CanWrite(), reqPath) {
+		return http.StatusForbidden, errs.PermissionDenied
+	}
 	if _, err := fs.Get(ctx, reqPath, &fs.GetArgs{}); err != nil {
 		if errs.IsObjectNotFound(err) {
 			return http.StatusNotFound, err